@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+)
+
+type metadata map[string]string
+
+func (m metadata) String() string {
+	pairs := []string{}
+	for key, value := range m {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m metadata) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("Metadata %s must be in key=value form", value)
+	}
+	m[parts[0]] = parts[1]
+	return nil
+}
+
+func main() {
+	privateKey := flag.String("private", "alfa/key.pem", "Private key file path of the authority")
+	publicKey := flag.String("public", "alfa/key_pub.pem", "Public key file path of the authority")
+	action := flag.String("action", "", "Action to perform: register-party, update-party, deactivate-party, register-voter or schedule-upgrade [required]")
+	address := flag.String("address", "", "Address of the party or voter")
+	name := flag.String("name", "", "Name of the party")
+	height := flag.Int("height", 0, "Height from which the upgrade applies")
+	version := flag.Int("version", 0, "Protocol version of the upgrade")
+	maxBlockSize := flag.Int("max-block-size", 0, "Maximum number of transactions in a block after the upgrade, 0 keeps the current one")
+	meta := metadata{}
+	flag.Var(meta, "meta", "Party metadata in key=value form, may be repeated")
+	apiURL := flag.String("api", client.DefaultBaseURL, "Base URL of the alfa node HTTP API")
+	timeout := flag.Duration("timeout", client.DefaultTimeout, "Timeout of HTTP API requests")
+	flag.Parse()
+	switch {
+	case *action == "":
+		log.Fatal("Action must be provided")
+	case api.AdminAction(*action) == api.ScheduleUpgradeAction && (*height == 0 || *version == 0):
+		log.Fatal("Both height and version of the upgrade must be provided")
+	case api.AdminAction(*action) != api.ScheduleUpgradeAction && *address == "":
+		log.Fatal("Address must be provided")
+	}
+	w, err := wallet.Import(keyfiles.KeyFiles{
+		PrivateKeyFile: *privateKey,
+		PublicKeyFile:  *publicKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load authority wallet %s", err)
+	}
+	request := api.AdminRequest{
+		Action:  api.AdminAction(*action),
+		Address: *address,
+		Name:    *name,
+	}
+	if len(meta) > 0 {
+		request.Metadata = meta
+	}
+	if request.Action == api.ScheduleUpgradeAction {
+		request.Upgrade = &transaction.Upgrade{
+			Height:       *height,
+			Version:      *version,
+			MaxBlockSize: *maxBlockSize,
+		}
+	}
+	request, err = request.Signed(*w)
+	if err != nil {
+		log.Fatalf("Failed to sign request %s", err)
+	}
+	c := client.New(*apiURL, *timeout)
+	var result *api.Transaction
+	switch request.Action {
+	case api.RegisterPartyAction:
+		result, err = c.RegisterParty(request)
+	case api.UpdatePartyAction:
+		result, err = c.UpdateParty(request)
+	case api.DeactivatePartyAction:
+		result, err = c.DeactivateParty(request)
+	case api.RegisterVoterAction:
+		result, err = c.RegisterVoter(request)
+	case api.ScheduleUpgradeAction:
+		result, err = c.ScheduleUpgrade(request)
+	default:
+		log.Fatalf("Unknown action %s", *action)
+	}
+	if err != nil {
+		log.Fatalf("Failed to perform %s %s", request.Action, err)
+	}
+	log.Printf("Submitted transaction %s", result.ID)
+}
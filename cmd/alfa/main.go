@@ -1,22 +1,32 @@
 package main
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 
 	"github.com/gorilla/mux"
 
 	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/limit"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/repository"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
@@ -34,6 +44,17 @@ const (
 	dbFileName = "db"
 )
 
+type apiLimits struct {
+	voteIPRate      int
+	voteIPBurst     int
+	voteSenderRate  int
+	voteSenderBurst int
+	verifiers       int
+	verifyQueue     int
+	maxBody         int64
+	timeout         time.Duration
+}
+
 func getKeyFiles(keyDirectory string) (keyfiles.KeyFilesList, error) {
 	files, err := ioutil.ReadDir(keyDirectory)
 	if err != nil {
@@ -68,8 +89,52 @@ func main() {
 	publicKey := flag.String("public", "alfa/key_pub.pem", "Public key file path")
 	clientKeysDir := flag.String("clients", "clients", "Client key pair files directory")
 	nodeKeysDir := flag.String("nodes", "nodes", "Nodes key pair files directory")
+	genesisSpec := flag.String("genesis", "", "Genesis spec file. Built from key directories and election flags if not provided")
+	electionKeyFile := flag.String("election", "", "Election key file path. Ballots are encrypted if provided")
+	closes := flag.String("closes", "", "Time when election closes in RFC3339 format")
+	ringVoting := flag.Bool("ring", false, "Votes are signed with linkable ring signatures over eligible voters")
+	recasting := flag.Bool("recast", false, "Voters can recast their vote until the election closes")
+	genesisFile := flag.String("genesis-hash", "alfa/genesis_hash.txt", "File the hex encoded hash of a new genesis block is written to")
+	checkpoints := flag.Duration("checkpoints", 0, "Interval between checkpoints signed by the alfa node. One epoch of the chain parameters if not provided")
+	limits := apiLimits{}
+	flag.IntVar(&limits.voteIPRate, "vote-ip-rate", 60, "Votes accepted per minute from a single IP address, 0 disables the limit")
+	flag.IntVar(&limits.voteIPBurst, "vote-ip-burst", 20, "Votes accepted at once from a single IP address")
+	flag.IntVar(&limits.voteSenderRate, "vote-sender-rate", 6, "Votes accepted per minute from a single sender, 0 disables the limit")
+	flag.IntVar(&limits.voteSenderBurst, "vote-sender-burst", 3, "Votes accepted at once from a single sender")
+	flag.IntVar(&limits.verifiers, "verifiers", runtime.NumCPU(), "Number of workers verifying votes")
+	flag.IntVar(&limits.verifyQueue, "verify-queue", 64, "Number of votes waiting for verification before new ones are rejected")
+	flag.Int64Var(&limits.maxBody, "max-body", 1<<20, "Maximum size of HTTP request body in bytes")
+	flag.DurationVar(&limits.timeout, "request-timeout", 15*time.Second, "Timeout of reading request headers and handling a vote")
+	logFormat := flag.String("log-format", string(logger.Logfmt), "Log format, logfmt or json")
+	logLevel := flag.String("log-level", "info", "Log level of all subsystems, one of debug, info, warn or error")
+	subsystemLevels := flag.String("log-levels", "", "Comma separated log levels of single subsystems, e.g. websocket=debug,repository=warn")
 
 	flag.Parse()
+	format, err := logger.ParseFormat(*logFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	levels, err := logger.ParseLevels(*logLevel, *subsystemLevels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root := logger.New(os.Stderr, format, levels, logger.NodeID("alfa"))
+	repository.SetLogger(root.Subsystem("repository"))
+	masterWallet, err := wallet.Import(keyfiles.KeyFiles{
+		PublicKeyFile:  *publicKey,
+		PrivateKeyFile: *privateKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load master wallet %s", err)
+	}
+	spec, err := loadSpec(*genesisSpec, *masterWallet, *nodeKeysDir, *clientKeysDir, *electionKeyFile, *closes, *ringVoting, *recasting)
+	if err != nil {
+		log.Fatal(err)
+	}
+	schedule := election.Schedule{}
+	if spec.Election.Closes != nil {
+		schedule.Closes = *spec.Election.Closes
+	}
 	if *newOption {
 		switch _, err := os.Stat(dbFileName); {
 		case err == nil:
@@ -85,135 +150,488 @@ func main() {
 		log.Fatal(err)
 	}
 	defer db.Close()
-	masterWallet, err := wallet.Import(keyfiles.KeyFiles{
-		PublicKeyFile:  *publicKey,
-		PrivateKeyFile: *privateKey,
-	})
+	if err := repository.IndexBlockchain(db); err != nil {
+		log.Fatalf("Failed to index blockchain %s", err)
+	}
+	if *newOption {
+		genesisBlock, err := alfa.Initialize(*masterWallet, *spec, repository.ProjectGenesisRoot(), repository.AddBlock(db))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := ioutil.WriteFile(*genesisFile, []byte(hex.EncodeToString(genesisBlock.Header.Hash)), 0644); err != nil {
+			log.Fatalf("Failed to write genesis hash %s", err)
+		}
+	}
+	switch electionSchedule, err := repository.GetElectionSchedule(db)(); {
+	case err != nil:
+		log.Fatalf("Failed to load election schedule %s", err)
+	case electionSchedule != nil:
+		schedule = *electionSchedule
+	}
+	switch recastSchedule, err := repository.GetRecastSchedule(db)(); {
+	case err != nil:
+		log.Fatalf("Failed to load recast schedule %s", err)
+	case recastSchedule != nil:
+		schedule = *recastSchedule
+	}
+	blockchain.PrintBlockchain(repository.GetTip(db), repository.GetBlock(db))
+	hub := websocket.NewHub(root.Subsystem("websocket"))
+	feed := websocket.NewFeed()
+	go feed.Run(handlers.LiveEvents(partiesHandler(db), root.Subsystem("events")))
+	broadcast := feed.Tee(hub.Broadcast)
+	metrics.ChainGauges(repository.GetHeight(db), repository.GetTransactions(db), repository.CountUTXOs(db))
+	metrics.HubGauges(hub.Peers, hub.QueueDepth)
+	metrics.FeedGauges(feed.QueueDepth)
+	clock, err := blockchain.GetClock(repository.GetBlockByHeight(db))()
 	if err != nil {
-		log.Fatalf("Failed to load master wallet %s", err)
+		log.Fatalf("Failed to load slot clock %s", err)
 	}
-	clientKeyFiles, err := getKeyFiles(*clientKeysDir)
+	getParameters := blockchain.GetParameters(repository.GetBlockByHeight(db), repository.GetUpgrades(db))
+	parameters, err := getParameters()
 	if err != nil {
-		log.Fatalf("Failed to load client key files directory %s", err)
+		log.Fatalf("Failed to load chain parameters %s", err)
 	}
-	nodeKeyFiles, err := getKeyFiles(*nodeKeysDir)
+	if *checkpoints == 0 {
+		*checkpoints = parameters.CheckpointInterval()
+	}
+	forges := blockchain.NewForgeTracker()
+	slots := blockchain.NewSlotTracker()
+	startForgerChooser(db, *masterWallet, hub, broadcast, schedule, *clock, getParameters, slots, *checkpoints, forges.Record, root.Subsystem("runner"))
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go runSocketServer(&wg, db, hub, feed, broadcast, getParameters, slots, *masterWallet, root)
+	go runAPIServer(&wg, db, hub, feed, broadcast, getParameters, forges, slots, *masterWallet, schedule, limits, levels, root)
+	wg.Wait()
+}
+
+func loadSpec(path string, authority wallet.Wallet, nodeKeysDir, clientKeysDir, electionKeyFile, closes string, ringVoting, recasting bool) (*genesis.Spec, error) {
+	if path != "" {
+		return genesis.Load(path)
+	}
+	options := genesis.Options{
+		Time:   time.Now(),
+		Ring:   ringVoting,
+		Recast: recasting,
+	}
+	if electionKeyFile != "" {
+		key, err := loadElectionKey(electionKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to load election key")
+		}
+		options.ElectionKey = key
+	}
+	if closes != "" {
+		closingTime, err := time.Parse(time.RFC3339, closes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid closing time %s", closes)
+		}
+		options.Closes = &closingTime
+	}
+	parties, err := importParties(nodeKeysDir)
 	if err != nil {
-		log.Fatalf("Failed to load node key files directory %s", err)
+		return nil, err
 	}
-	clientWallets, err := wallet.ImportMultiple(clientKeyFiles)
+	clientKeyFiles, err := getKeyFiles(clientKeysDir)
 	if err != nil {
-		log.Fatalf("Failed to import client wallets %s", err)
+		return nil, errors.Wrap(err, "Failed to load client key files directory")
 	}
-	nodeWallets, err := wallet.ImportMultiple(nodeKeyFiles)
+	clientWallets, err := wallet.ImportMultiple(clientKeyFiles)
 	if err != nil {
-		log.Fatalf("Failed to import node wallets %s", err)
+		return nil, errors.Wrap(err, "Failed to import client wallets")
+	}
+	spec := genesis.FromWallets(authority, parties, clientWallets, options)
+	if err := spec.Validate(); err != nil {
+		return nil, err
 	}
+	return &spec, nil
+}
 
-	if *newOption {
-		if err := alfa.Initialize(
-			*masterWallet,
-			nodeWallets,
-			clientWallets,
-			repository.AddBlock(db),
-			repository.SaveParty(db)); err != nil {
-			log.Fatal(err)
+func importParties(keyDirectory string) (map[string]wallet.Wallet, error) {
+	keyFiles, err := getKeyFiles(keyDirectory)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load node key files directory")
+	}
+	parties := map[string]wallet.Wallet{}
+	for _, k := range keyFiles {
+		w, err := wallet.Import(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to import node wallet %s", k.PublicKeyFile)
 		}
+		name := strings.TrimSuffix(filepath.Base(k.PrivateKeyFile), filepath.Ext(k.PrivateKeyFile))
+		parties[fmt.Sprintf("Party %s", name)] = *w
 	}
-	blockchain.PrintBlockchain(repository.GetTip(db), repository.GetBlock(db))
-	hub := websocket.NewHub()
-	startForgerChooser(db, *masterWallet, hub)
-	wg := sync.WaitGroup{}
-	wg.Add(2)
-	go runSocketServer(&wg, db, hub, *masterWallet)
-	go runAPIServer(&wg, db, hub)
-	wg.Wait()
+	return parties, nil
 }
 
-func startForgerChooser(db *bolt.DB, masterWallet wallet.Wallet, hub *websocket.Hub) {
+func loadElectionKey(path string) (*elgamal.ElectionKey, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read election key file %s", path)
+	}
+	var key elgamal.ElectionKey
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal election key %s", raw)
+	}
+	return &key, nil
+}
+
+func partiesHandler(db *bolt.DB) api.Handler {
+	return handlers.GetParties(
+		repository.GetParties(db),
+		repository.GetUTXOsByPublicKey(db),
+		repository.GetElectionKey(db),
+		repository.GetEncryptedTally(db),
+		repository.GetTally(db),
+	)
+}
+
+func startForgerChooser(db *bolt.DB, masterWallet wallet.Wallet, hub *websocket.Hub, broadcast websocket.BroadcastFn, schedule election.Schedule, clock blockchain.Clock, getParameters blockchain.GetParametersFn, slots *blockchain.SlotTracker, checkpoints time.Duration, record blockchain.RecordForgeFn, log logger.Logger) {
 	getTip := repository.GetTip(db)
 	getBlock := repository.GetBlock(db)
+	isReturnStakeTransaction := transaction.IsReturnStakeTransaction(masterWallet.PublicKeyHash())
 	c := cron.New()
 	c.Schedule(
-		cron.Every(30*time.Second),
+		clock,
 		alfa.Runner(
-			hub.RegisteredNodes,
-			hub.RandomUnicast,
+			clock,
+			hub.Forgers(blockchain.ExcludeSlashed(repository.IsSlashed(db))),
+			hub.Unicast,
 			getTip,
 			getBlock,
-		),
+			repository.GetTransactions(db),
+			isReturnStakeTransaction,
+			slots,
+			repository.RecordMissedSlot(db),
+			record,
+			log.With(logger.F("runner", "forger")),
+		).Logged(log.With(logger.F("runner", "forger"))),
 	)
 	c.Schedule(
 		cron.Every(time.Minute),
 		alfa.Cleaner(
 			repository.GetTransactions(db),
-			transaction.IsReturnStakeTransaction(masterWallet.PublicKeyHash()),
+			isReturnStakeTransaction,
 			getTip,
 			getBlock,
+			blockchain.GetClock(repository.GetBlockByHeight(db)),
+			getParameters,
+			repository.ProjectUTXORoot(db),
+			blockchain.NewBlock(masterWallet),
 			repository.AddBlock(db),
-			hub.Broadcast,
-		),
+			broadcast,
+			log.With(logger.F("runner", "cleaner")),
+		).Logged(log.With(logger.F("runner", "cleaner"))),
+	)
+	c.Schedule(
+		cron.Every(time.Minute),
+		alfa.Releaser(
+			election.IsClosed(schedule),
+			repository.ReleaseRecasts(db, transaction.NewRecastReleaseTransaction(masterWallet)),
+			broadcast,
+		).Logged(log.With(logger.F("runner", "releaser"))),
+	)
+	c.Schedule(
+		cron.Every(checkpoints),
+		alfa.Checkpointer(
+			repository.ChainState(db),
+			repository.GetCheckpoint(db),
+			repository.SaveCheckpoint(db),
+			masterWallet,
+			broadcast,
+			log.With(logger.F("runner", "checkpointer")),
+		).Logged(log.With(logger.F("runner", "checkpointer"))),
 	)
 	c.Start()
 }
 
-func runSocketServer(wg *sync.WaitGroup, db *bolt.DB, hub *websocket.Hub, w wallet.Wallet) {
+func runSocketServer(wg *sync.WaitGroup, db *bolt.DB, hub *websocket.Hub, feed *websocket.Feed, broadcast websocket.BroadcastFn, getParameters blockchain.GetParametersFn, slots *blockchain.SlotTracker, w wallet.Wallet, log logger.Logger) {
 	defer wg.Done()
+	handlerLog := log.Subsystem("handlers")
 	getTip := repository.GetTip(db)
 	getBlock := repository.GetBlock(db)
-	findBlock := blockchain.FindBlock(getTip, getBlock)
-	authorizer := blockchain.BlockchainAuthorizer(findBlock)
+	authorizer := blockchain.BlockchainAuthorizer(repository.IsEligibleVoter(db))
 	isStakeTransaction := transaction.IsStakeTransaction(w.PublicKeyHash())
+	slash := repository.Slash(db, transaction.NewSlashingTransaction(w))
+	getClock := blockchain.GetClock(repository.GetBlockByHeight(db))
+	verifySlot := blockchain.VerifySlot(getClock, getBlock)
+	verifyEquivocation := blockchain.VerifyEquivocation(repository.GetBlockHeight(db))
+	verifyTransactions := transaction.VerifyTransactions(
+		repository.GetTransactionUTXO(db),
+		repository.IsRevoked(db),
+		wallet.VerifySignature,
+	).
+		And(transaction.VerifyRevocation(w.PublicKeyHash())).
+		And(transaction.VerifyAuthorityTransactions(w.PublicKeyHash())).
+		And(transaction.VerifyBallotTokens(w.PublicKey, repository.GetTokenSpender(db))).
+		And(transaction.VerifyEncryptedBallots(repository.GetElectionKey(db))).
+		And(transaction.VerifyRingVotes(repository.GetRing(db), repository.GetKeyImageSpender(db))).
+		And(transaction.VerifyRecasts(
+			repository.GetRecastSchedule(db),
+			repository.GetTransactionUTXO(db),
+			repository.GetRecast(db),
+			repository.GetLatestRecast(db),
+			w.PublicKeyHash(),
+		)).
+		And(blockchain.VerifyUpgrades(getParameters, repository.GetHeight(db)))
+	verifyTransactions = verifyTransactions.And(transaction.VerifySlashings(blockchain.VerifySlashingEvidence(
+		repository.GetBlockHeight(db),
+		verifyEquivocation,
+	)))
 	router := websocket.Router{
 		websocket.GetBlockchainHeightMessage: handlers.GetHeightHandler(getTip, getBlock),
-		websocket.GetMissingBlocksMessage:    handlers.GetMissingBlocks(getTip, getBlock),
+		websocket.GetHeadersMessage:          handlers.GetHeaders(repository.GetHeaders(db), handlerLog),
 		websocket.GetBlockMessage:            handlers.GetBlock(getBlock),
-		websocket.RegisterMessage:            handlers.Register(hub).Authorized(authorizer),
+		websocket.GetCheckpointMessage:       handlers.GetCheckpoint(repository.GetCheckpoint(db)),
+		websocket.GetSnapshotMessage:         handlers.GetSnapshot(repository.ExportSnapshot(db)),
+		websocket.RegisterMessage:            handlers.Register(hub).Authorized(blockchain.SlashedAuthorizer(repository.IsSlashed(db))).Authorized(authorizer),
 		websocket.BlockForgedMessage: handlers.BlockForged(
 			getTip,
 			getBlock,
-			blockchain.VerfiyBlock(
-				transaction.VerifyTransactions(
-					repository.GetTransactionUTXO(db),
-					wallet.VerifySignature,
-				),
-				isStakeTransaction,
-			),
+			repository.GetBlockHeight(db),
+			blockchain.VerfiyBlock(verifyTransactions, isStakeTransaction, verifySlot).
+				And(blockchain.VerifyRecastWindow(blockchain.IsRecastClosed(getClock, repository.GetRecastSchedule(db)))).
+				And(blockchain.VerifyElectionWindow(blockchain.IsElectionClosed(getClock, repository.GetElectionSchedule(db)))).
+				And(blockchain.VerifyUniqueTokens()),
+			blockchain.VerifyProtocol(getParameters, repository.GetBlockHeight(db)),
 			repository.AddNewBlock(db),
 			isStakeTransaction,
 			repository.SaveTransaction(db),
 			transaction.NewReturnStakeTransaction(w),
-			hub.Broadcast,
-		),
+			slash,
+			blockchain.ProveDoubleSpend(
+				repository.GetTransactionRecord(db),
+				repository.GetAddressHistory(db),
+				getBlock,
+				repository.GetBlockHeight(db),
+			),
+			repository.RecordForgedHeader(db),
+			slots.Claim,
+			slots.Release,
+			broadcast,
+			feed.Publish,
+			handlerLog,
+		).Authorized(websocket.SignatureAuthorizer()),
+		websocket.EquivocationMessage: handlers.Equivocation(w.PublicKeyHash(), verifyEquivocation, slash, broadcast, handlerLog),
 	}
 	mux := http.NewServeMux()
-	mux.Handle("/", websocket.PingPongConnection(router, hub, wallet.NewSigner(w)))
+	mux.Handle("/", websocket.PingPongConnection(router, hub, wallet.NewSigner(w)).Logged(log.Subsystem("websocket")))
 	http.ListenAndServe(":10000", mux)
 }
 
-func runAPIServer(wg *sync.WaitGroup, db *bolt.DB, hub *websocket.Hub) {
+func runAPIServer(wg *sync.WaitGroup, db *bolt.DB, hub *websocket.Hub, feed *websocket.Feed, broadcast websocket.BroadcastFn, getParameters blockchain.GetParametersFn, forges *blockchain.ForgeTracker, slots *blockchain.SlotTracker, w wallet.Wallet, schedule election.Schedule, limits apiLimits, levels *logger.Levels, log logger.Logger) {
+	apiLog := log.Subsystem("api")
 	getTip := repository.GetTip(db)
 	getBlock := repository.GetBlock(db)
-	findBlock := blockchain.FindBlock(getTip, getBlock)
+	getElectionKey := repository.GetElectionKey(db)
+	isClosed := election.IsClosed(schedule)
+	getPhase := election.GetPhase(schedule, repository.GetTally(db))
 	httpRouter := mux.NewRouter()
 	httpRouter.
-		HandleFunc("/vote",
-			api.NewHandleFunc(
-				handlers.Vote(
-					findBlock,
-					repository.CastVote(db),
-					hub.Broadcast,
+		Handle("/vote",
+			api.Timeout(
+				api.NewHandleFunc(
+					handlers.Vote(
+						repository.IsEligibleVoter(db),
+						repository.CastVote(db),
+						repository.CastAnonymousVote(db),
+						repository.CastEncryptedVote(db),
+						repository.CastRingVote(db),
+						repository.CastRecastVote(db),
+						repository.GetRing(db),
+						repository.GetRecastSchedule(db),
+						getElectionKey,
+						repository.GetParties(db),
+						isClosed,
+						w.PublicKey,
+						broadcast,
+						log.Subsystem("handlers"),
+					).
+						Pooled(limit.NewPool(limits.verifiers, limits.verifyQueue)).
+						Limited(limit.NewLimiter(limits.voteSenderRate, limits.voteSenderBurst).Allow, handlers.VoteSender).
+						Limited(limit.NewLimiter(limits.voteIPRate, limits.voteIPBurst).Allow, api.RemoteAddr).
+						Observed(metrics.ObserveVote),
+					apiLog,
 				),
+				limits.timeout,
 			),
 		).Methods("POST")
-	httpRouter.HandleFunc("/parties",
+	httpRouter.Handle("/metrics", metrics.Handler(log.Subsystem("metrics"))).Methods("GET")
+	httpRouter.HandleFunc("/healthz", api.NewHandleFunc(api.Healthz(), apiLog)).Methods("GET")
+	httpRouter.HandleFunc("/readyz",
+		api.NewHandleFunc(api.Readyz(api.Check{Name: "blockchain", Run: handlers.ChainCheck(repository.GetHeight(db))}), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/status",
 		api.NewHandleFunc(
-			handlers.GetParties(
-				repository.GetParties(db),
+			handlers.GetStatus(
+				getTip,
+				getBlock,
+				repository.GetHeight(db),
+				hub.Peers,
+				forges.Last,
 				repository.GetUTXOsByPublicKey(db),
+				w.PublicKeyHash(),
+				getPhase,
+			),
+			apiLog,
+		),
+	).Methods("GET")
+	httpRouter.HandleFunc("/log/levels", api.NewHandleFunc(api.GetLogLevels(levels), apiLog)).Methods("GET")
+	httpRouter.HandleFunc("/log/levels/{subsystem}", api.NewHandleFunc(api.SetLogLevel(levels), apiLog)).Methods("PUT")
+	httpRouter.HandleFunc("/openapi.json",
+		api.NewHandleFunc(api.Specification(), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/parties",
+		api.NewHandleFunc(partiesHandler(db), apiLog),
+	).Methods("GET")
+	httpRouter.Handle("/events",
+		websocket.ServerSentEvents(feed, handlers.EventsSnapshot(partiesHandler(db))).Logged(apiLog),
+	).Methods("GET")
+	httpRouter.Handle("/events/ws",
+		websocket.EventSocket(feed, handlers.EventsSnapshot(partiesHandler(db))).Logged(apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/ring",
+		api.NewHandleFunc(handlers.GetRing(repository.GetRing(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/recasts/{address}",
+		api.NewHandleFunc(handlers.GetRecast(repository.GetRecastSchedule(db), repository.NextRecastSequence(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/election",
+		api.NewHandleFunc(handlers.GetElection(schedule, getElectionKey, getPhase), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/tally/encrypted",
+		api.NewHandleFunc(handlers.GetEncryptedTally(getElectionKey, repository.GetEncryptedTally(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/tally/decryptions",
+		api.NewHandleFunc(
+			handlers.SubmitDecryption(
+				getElectionKey,
+				isClosed,
+				repository.HasPendingBallots(db),
+				repository.GetEncryptedTally(db),
+				repository.GetTally(db),
+				repository.SaveDecryption(db),
+				repository.SaveTally(db),
+			),
+			apiLog,
+		),
+	).Methods("POST")
+	httpRouter.HandleFunc("/tally",
+		api.NewHandleFunc(handlers.GetTally(repository.GetTally(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/tokens/commitment",
+		api.NewHandleFunc(handlers.TokenCommitment(repository.NewTokenCommitment(db)), apiLog),
+	).Methods("POST")
+	httpRouter.HandleFunc("/tokens",
+		api.NewHandleFunc(
+			handlers.IssueToken(
+				repository.IssueBallotToken(db, transaction.SignBlinded(w)),
+				broadcast,
+			),
+			apiLog,
+		),
+	).Methods("POST")
+	httpRouter.HandleFunc("/revocations",
+		api.NewHandleFunc(
+			handlers.Revoke(
+				w.PublicKey,
+				repository.RevokeVoter(db, transaction.NewRevocationTransaction(w)),
+				broadcast,
+			),
+			apiLog,
+		),
+	).Methods("POST")
+	httpRouter.HandleFunc("/revocations",
+		api.NewHandleFunc(handlers.GetRevocations(repository.GetRevocations(db)), apiLog),
+	).Methods("GET")
+	updateParty := repository.UpdateParty(db, transaction.NewPartyTransaction(w))
+	adminLog := log.Subsystem("handlers")
+	useAdminRequest := repository.UseAdminRequest(db)
+	httpRouter.HandleFunc("/admin/parties",
+		api.NewHandleFunc(handlers.RegisterParty(w.PublicKey, useAdminRequest, isClosed, updateParty, broadcast, adminLog), apiLog),
+	).Methods("POST")
+	httpRouter.HandleFunc("/admin/parties/{address}",
+		api.NewHandleFunc(handlers.UpdateParty(w.PublicKey, useAdminRequest, isClosed, updateParty, broadcast, adminLog), apiLog),
+	).Methods("PUT")
+	httpRouter.HandleFunc("/admin/parties/{address}/deactivation",
+		api.NewHandleFunc(handlers.DeactivateParty(w.PublicKey, useAdminRequest, isClosed, updateParty, broadcast, adminLog), apiLog),
+	).Methods("POST")
+	httpRouter.HandleFunc("/admin/voters",
+		api.NewHandleFunc(
+			handlers.RegisterVoter(
+				w.PublicKey,
+				useAdminRequest,
+				isClosed,
+				repository.GetRing(db),
+				repository.RegisterVoter(db, transaction.NewVoterRegistrationTransaction(w)),
+				broadcast,
+				adminLog,
+			),
+			apiLog,
+		),
+	).Methods("POST")
+	httpRouter.HandleFunc("/admin/upgrades",
+		api.NewHandleFunc(
+			handlers.ScheduleUpgrade(
+				w.PublicKey,
+				useAdminRequest,
+				getParameters,
+				repository.GetHeight(db),
+				repository.ScheduleUpgrade(db, transaction.NewUpgradeTransaction(w)),
+				broadcast,
+				adminLog,
 			),
+			apiLog,
 		),
+	).Methods("POST")
+	httpRouter.HandleFunc("/revocations/{address}",
+		api.NewHandleFunc(handlers.GetRevocation(repository.GetRevocation(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/slashings",
+		api.NewHandleFunc(handlers.GetSlashings(repository.GetSlashings(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/slashings/{address}",
+		api.NewHandleFunc(handlers.GetSlashing(repository.GetSlashing(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/slots/current",
+		api.NewHandleFunc(handlers.GetCurrentSlot(slots.Current), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/slots/missed",
+		api.NewHandleFunc(handlers.GetMissedSlots(repository.GetMissedSlots(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/checkpoint",
+		api.NewHandleFunc(handlers.GetLatestCheckpoint(repository.GetCheckpoint(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/head",
+		api.NewHandleFunc(handlers.GetHead(getTip, getBlock, repository.GetHeight(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/blocks",
+		api.NewHandleFunc(handlers.GetBlocks(repository.GetHeight(db), getBlock, repository.GetBlockByHeight(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/blocks/{height:[0-9]{1,18}}",
+		api.NewHandleFunc(handlers.GetBlockByHeight(repository.GetBlockByHeight(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/blocks/{hash:[0-9a-fA-F]{64}}",
+		api.NewHandleFunc(handlers.GetBlockByHash(getBlock, repository.GetBlockHeight(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/transactions/pending",
+		api.NewHandleFunc(handlers.GetPendingTransactions(repository.GetTransactions(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/transactions/{id:[0-9a-fA-F]{64}}",
+		api.NewHandleFunc(handlers.GetTransaction(repository.GetTransactionRecord(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/addresses/{address}/utxos",
+		api.NewHandleFunc(handlers.GetAddressUTXOs(repository.GetUTXOsByPublicKey(db)), apiLog),
+	).Methods("GET")
+	httpRouter.HandleFunc("/addresses/{address}/transactions",
+		api.NewHandleFunc(handlers.GetAddressHistory(repository.GetAddressHistory(db)), apiLog),
 	).Methods("GET")
 	serverMux := http.NewServeMux()
 	serverMux.Handle("/", httpRouter)
-	http.ListenAndServe(":8000", serverMux)
+	server := http.Server{
+		Addr:              ":8000",
+		Handler:           api.LimitBody(serverMux, limits.maxBody),
+		ReadHeaderTimeout: limits.timeout,
+		IdleTimeout:       2 * time.Minute,
+	}
+	server.ListenAndServe()
 }
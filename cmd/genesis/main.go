@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
+	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/repository"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func getKeyFiles(keyDirectory string) (keyfiles.KeyFilesList, error) {
+	files, err := ioutil.ReadDir(keyDirectory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read key file directory %s", keyDirectory)
+	}
+
+	fileGroups := map[string]keyfiles.KeyFiles{}
+	for _, f := range files {
+		if strings.Contains(f.Name(), "address") {
+			continue
+		}
+		name := strings.Replace(f.Name(), "_pub", "", 1)
+		group := fileGroups[name]
+		if strings.Contains(f.Name(), "pub") {
+			group.PublicKeyFile = fmt.Sprintf("%s/%s", keyDirectory, f.Name())
+		} else {
+			group.PrivateKeyFile = fmt.Sprintf("%s/%s", keyDirectory, f.Name())
+		}
+		fileGroups[name] = group
+	}
+
+	result := keyfiles.KeyFilesList{}
+	for _, keyFiles := range fileGroups {
+		result = append(result, keyFiles)
+	}
+	return result, nil
+}
+
+func importParties(keyDirectory string) (map[string]wallet.Wallet, error) {
+	keyFiles, err := getKeyFiles(keyDirectory)
+	if err != nil {
+		return nil, err
+	}
+	parties := map[string]wallet.Wallet{}
+	for _, k := range keyFiles {
+		w, err := wallet.Import(k)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to import node wallet %s", k.PublicKeyFile)
+		}
+		name := strings.TrimSuffix(filepath.Base(k.PrivateKeyFile), filepath.Ext(k.PrivateKeyFile))
+		parties[fmt.Sprintf("Party %s", name)] = *w
+	}
+	return parties, nil
+}
+
+func loadElectionKey(path string) (*elgamal.ElectionKey, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read election key file %s", path)
+	}
+	var key elgamal.ElectionKey
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal election key %s", raw)
+	}
+	return &key, nil
+}
+
+func main() {
+	specFile := flag.String("spec", "genesis.json", "Genesis spec file path")
+	initOption := flag.Bool("init", false, "Should write a new genesis spec from key directories instead of building the genesis block")
+	hashFile := flag.String("genesis-hash", "", "File the hex encoded genesis hash is written to")
+	privateKey := flag.String("private", "alfa/key.pem", "Alfa node private key file path")
+	publicKey := flag.String("public", "alfa/key_pub.pem", "Alfa node public key file path")
+	clientKeysDir := flag.String("clients", "clients", "Client key pair files directory")
+	nodeKeysDir := flag.String("nodes", "nodes", "Nodes key pair files directory")
+	genesisTime := flag.String("time", "", "Genesis time in RFC3339 format. Current time is used if not provided")
+	electionKeyFile := flag.String("election", "", "Election key file path. Ballots are encrypted if provided")
+	closes := flag.String("closes", "", "Time when election closes in RFC3339 format")
+	ringVoting := flag.Bool("ring", false, "Votes are signed with linkable ring signatures over eligible voters")
+	recasting := flag.Bool("recast", false, "Voters can recast their vote until the election closes")
+	flag.Parse()
+
+	if *initOption {
+		options := genesis.Options{
+			Time:   time.Now(),
+			Ring:   *ringVoting,
+			Recast: *recasting,
+		}
+		if *genesisTime != "" {
+			t, err := time.Parse(time.RFC3339, *genesisTime)
+			if err != nil {
+				log.Fatalf("Invalid genesis time %s", err)
+			}
+			options.Time = t
+		}
+		if *closes != "" {
+			t, err := time.Parse(time.RFC3339, *closes)
+			if err != nil {
+				log.Fatalf("Invalid closing time %s", err)
+			}
+			options.Closes = &t
+		}
+		if *electionKeyFile != "" {
+			key, err := loadElectionKey(*electionKeyFile)
+			if err != nil {
+				log.Fatalf("Failed to load election key %s", err)
+			}
+			options.ElectionKey = key
+		}
+		authority, err := wallet.Import(keyfiles.KeyFiles{
+			PublicKeyFile:  *publicKey,
+			PrivateKeyFile: *privateKey,
+		})
+		if err != nil {
+			log.Fatalf("Failed to load alfa wallet %s", err)
+		}
+		parties, err := importParties(*nodeKeysDir)
+		if err != nil {
+			log.Fatalf("Failed to import node wallets %s", err)
+		}
+		clientKeyFiles, err := getKeyFiles(*clientKeysDir)
+		if err != nil {
+			log.Fatalf("Failed to load client key files directory %s", err)
+		}
+		clientWallets, err := wallet.ImportMultiple(clientKeyFiles)
+		if err != nil {
+			log.Fatalf("Failed to import client wallets %s", err)
+		}
+		spec := genesis.FromWallets(*authority, parties, clientWallets, options)
+		if err := spec.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		if err := spec.Write(*specFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	spec, err := genesis.Load(*specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	block, err := genesis.Build(*spec, repository.ProjectGenesisRoot())
+	if err != nil {
+		log.Fatalf("Failed to build genesis block %s", err)
+	}
+	hash := hex.EncodeToString(block.Header.Hash)
+	fmt.Println(hash)
+	if *hashFile != "" {
+		if err := ioutil.WriteFile(*hashFile, []byte(hash), 0644); err != nil {
+			log.Fatalf("Failed to write genesis hash %s", err)
+		}
+	}
+}
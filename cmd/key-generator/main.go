@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 )
 
@@ -32,6 +35,9 @@ func main() {
 	nodesKeysDir := flag.String("nodes", "nodes", "Directory where to create node key pairs")
 	numOfClients := flag.Int("clientsNumber", 50, "Number of client key pairs to generate")
 	numOfNodes := flag.Int("nodesNumber", 5, "Number of node key pairs to generate")
+	electionDir := flag.String("election", "election", "Directory where to create election key and trustee shares")
+	numOfTrustees := flag.Int("trustees", 0, "Number of trustees sharing the election key. Election key is not generated if zero")
+	threshold := flag.Int("threshold", 0, "Number of trustees needed to decrypt the tally")
 	flag.Parse()
 
 	switch _, err := os.Stat(*clientKeysDir); {
@@ -66,4 +72,37 @@ func main() {
 	if err := alfaWallet.Export(fmt.Sprintf("%s/key", *alfaKeyDir)); err != nil {
 		log.Fatal(err)
 	}
+
+	if *numOfTrustees > 0 {
+		if err := exportElectionKey(*electionDir, *numOfTrustees, *threshold); err != nil {
+			log.Fatalf("Failed to generate election key %s", err)
+		}
+	}
+}
+
+func exportElectionKey(directory string, trustees, threshold int) error {
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return err
+	}
+	key, shares, err := elgamal.GenerateElectionKey(trustees, threshold)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(fmt.Sprintf("%s/key.json", directory), key); err != nil {
+		return err
+	}
+	for _, share := range shares {
+		if err := writeJSON(fmt.Sprintf("%s/trustee%d.json", directory, share.Index), share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(path string, data interface{}) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, raw, 0600)
 }
@@ -2,37 +2,71 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/nebser/crypto-vote/internal/apps/node"
 	"github.com/nebser/crypto-vote/internal/apps/node/handlers"
+	"github.com/nebser/crypto-vote/internal/pkg/api"
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/nebser/crypto-vote/internal/pkg/repository"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 
 	"github.com/boltdb/bolt"
+	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
 	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/operations"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	_websocket "github.com/nebser/crypto-vote/internal/pkg/websocket"
 )
 
+const alfaTimeout = 2 * time.Second
+
 func main() {
 	nodeID := flag.Int("id", 0, "ID of the node [required]")
 	newOption := flag.Bool("new", false, "Should initialize new blockchain")
 	privateKeyOption := flag.String("private", "", "Private key file path [default is nodes/key_id.pem]")
 	publicKeyOption := flag.String("public", "", "Private key file path [default is nodes/key_id_pub.pem]")
+	logFormat := flag.String("log-format", string(logger.Logfmt), "Log format, logfmt or json")
+	logLevel := flag.String("log-level", "info", "Log level of all subsystems, one of debug, info, warn or error")
+	subsystemLevels := flag.String("log-levels", "", "Comma separated log levels of single subsystems, e.g. websocket=debug,repository=warn")
+	alfaAPI := flag.String("alfa-api", client.DefaultBaseURL, "Base URL of the alfa node HTTP API")
+	readyLag := flag.Int("ready-lag", 1, "Maximum number of blocks the node can be behind the alfa node and still be ready")
+	fastSync := flag.Bool("fast-sync", false, "Should bootstrap an empty blockchain from a UTXO set snapshot")
+	snapshotPeer := flag.Int("snapshot-peer", 0, "ID of the node serving the snapshot, 0 is the alfa node")
+	syncPeers := flag.String("sync-peers", "0", "Comma separated IDs of the nodes blocks are downloaded from during sync, 0 is the alfa node")
+	syncConnections := flag.Int("sync-connections", 2, "Number of parallel block downloads from every sync peer")
+	genesisFile := flag.String("genesis-hash", "alfa/genesis_hash.txt", "File with the pinned hex encoded hash of the genesis block")
+	genesisSpec := flag.String("genesis", "", "Genesis spec file the pinned genesis hash is computed from instead of the genesis hash file")
 	flag.Parse()
 	if *nodeID <= 0 {
 		log.Fatal("NodeId must be provided and it must be greater than 0")
 	}
+	format, err := logger.ParseFormat(*logFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	levels, err := logger.ParseLevels(*logLevel, *subsystemLevels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root := logger.New(os.Stderr, format, levels, logger.NodeID(strconv.Itoa(*nodeID)))
+	repository.SetLogger(root.Subsystem("repository"))
+	handlerLog := root.Subsystem("handlers")
 	privateKey := *privateKeyOption
 	if privateKey == "" {
 		privateKey = fmt.Sprintf("nodes/n%d.pem", *nodeID)
@@ -52,6 +86,10 @@ func main() {
 		log.Fatalf("Failed to load public key %s", err)
 	}
 	encodedAlfaPkey := base64.StdEncoding.EncodeToString(alfaPKey)
+	genesisHash, err := loadGenesis(*genesisFile, *genesisSpec)
+	if err != nil {
+		log.Fatalf("Failed to load pinned genesis hash %s", err)
+	}
 	if *newOption {
 		switch _, err := os.Stat(dbFileName); {
 		case err == nil:
@@ -72,56 +110,131 @@ func main() {
 		log.Fatal(err)
 	}
 	defer db.Close()
-
-	u := url.URL{
-		Scheme: "ws",
-		Host:   "localhost:10000",
-		Path:   "/",
+	if err := repository.IndexBlockchain(db); err != nil {
+		log.Fatalf("Failed to index blockchain %s", err)
 	}
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+
+	conn, err := dialPeer(0)
 	if err != nil {
 		log.Fatalf("Failed to connect to server: %s", err)
 	}
+	syncLog := root.Subsystem("sync")
+	syncConns := []*websocket.Conn{}
+	peers := []*node.Peer{}
+	for _, id := range strings.Split(*syncPeers, ",") {
+		peerID, err := strconv.Atoi(strings.TrimSpace(id))
+		if err != nil {
+			log.Fatalf("Invalid sync peer %s", id)
+		}
+		for i := 0; i < *syncConnections; i++ {
+			peerConn, err := dialPeer(peerID)
+			if err != nil {
+				syncLog.Warn("Sync peer unreachable", logger.PeerID(id), logger.Err(err))
+				break
+			}
+			syncConns = append(syncConns, peerConn)
+			peers = append(peers, node.NewPeer(strconv.Itoa(peerID), operations.GetBlock(peerConn)))
+		}
+	}
+
+	var getSnapshot operations.GetSnapshotFn
+	if *fastSync {
+		snapshotConn := conn
+		if *snapshotPeer > 0 {
+			snapshotConn, err = dialPeer(*snapshotPeer)
+			if err != nil {
+				log.Fatalf("Failed to connect to snapshot peer: %s", err)
+			}
+			syncConns = append(syncConns, snapshotConn)
+		}
+		getSnapshot = operations.GetSnapshot(snapshotConn)
+	}
 
 	getTip := repository.GetTip(db)
 	getBlock := repository.GetBlock(db)
+	getClock := blockchain.GetClock(repository.GetBlockByHeight(db))
+	verifySlot := blockchain.VerifySlot(getClock, getBlock)
+	verifyEquivocation := blockchain.VerifyEquivocation(repository.GetBlockHeight(db))
+	isRecastClosed := blockchain.IsRecastClosed(getClock, repository.GetRecastSchedule(db))
+	isElectionClosed := blockchain.IsElectionClosed(getClock, repository.GetElectionSchedule(db))
+	verifyBlockRules := blockchain.VerifyRecastWindow(isRecastClosed).
+		And(blockchain.VerifyElectionWindow(isElectionClosed)).
+		And(blockchain.VerifyUniqueTokens())
+	getParameters := blockchain.GetParameters(repository.GetBlockByHeight(db), repository.GetUpgrades(db))
+	verifyTransactions := transaction.VerifyTransactions(
+		repository.GetTransactionUTXO(db),
+		repository.IsRevoked(db),
+		wallet.VerifySignature,
+	).
+		And(transaction.VerifyRevocation(hashedAlfaPKey)).
+		And(transaction.VerifyAuthorityTransactions(hashedAlfaPKey)).
+		And(transaction.VerifyBallotTokens(alfaPKey, repository.GetTokenSpender(db))).
+		And(transaction.VerifyEncryptedBallots(repository.GetElectionKey(db))).
+		And(transaction.VerifyRingVotes(repository.GetRing(db), repository.GetKeyImageSpender(db))).
+		And(transaction.VerifyRecasts(
+			repository.GetRecastSchedule(db),
+			repository.GetTransactionUTXO(db),
+			repository.GetRecast(db),
+			repository.GetLatestRecast(db),
+			hashedAlfaPKey,
+		)).
+		And(blockchain.VerifyUpgrades(getParameters, repository.GetHeight(db)))
+	verifyTransactions = verifyTransactions.And(transaction.VerifySlashings(blockchain.VerifySlashingEvidence(
+		repository.GetBlockHeight(db),
+		verifyEquivocation,
+	)))
 	if err := node.Initialize(
-		operations.GetHeight(conn),
-		operations.GetMissingBlocks(conn),
-		operations.GetBlock(conn),
+		operations.GetHeaders(conn),
+		peers,
+		operations.GetCheckpoint(conn),
+		getSnapshot,
+		alfaPKey,
+		genesisHash,
 		getTip,
 		getBlock,
-		repository.AddBlock(db),
+		repository.GetBlockByHeight(db),
+		repository.ChainState(db),
+		blockchain.VerifySyncedBlock(genesisHash, hashedAlfaPKey, verifyTransactions, transaction.IsStakeTransaction(hashedAlfaPKey), verifySlot, verifyBlockRules, getParameters),
+		repository.AddNewBlock(db),
+		repository.ImportSnapshot(db),
+		repository.SaveCheckpoint(db),
+		syncLog,
 	); err != nil {
 		log.Fatalf("Failed to initialize node %s", err)
 	}
+	for _, c := range syncConns {
+		c.Close()
+	}
 	blockchain.PrintBlockchain(getTip, getBlock)
 	nodes, err := operations.Register(conn, *masterWallet)(strconv.Itoa(*nodeID))
 	if err != nil {
 		log.Fatalf("Failed to register %s\n", err)
 	}
-	hub := _websocket.NewHub()
+	hub := _websocket.NewHub(root.Subsystem("websocket"))
+	metrics.ChainGauges(repository.GetHeight(db), repository.GetTransactions(db), repository.CountUTXOs(db))
+	metrics.HubGauges(hub.Peers, hub.QueueDepth)
 	signer := wallet.NewSigner(*masterWallet)
-	verifyTransactions := transaction.VerifyTransactions(repository.GetTransactionUTXO(db), wallet.VerifySignature)
+	forges := blockchain.NewForgeTracker()
+	alfaClient := client.New(*alfaAPI, alfaTimeout)
 	router := _websocket.Router{
 		_websocket.RegisterMessage: handlers.Register(hub).
+			Authorized(blockchain.SlashedAuthorizer(repository.IsSlashed(db))).
 			Authorized(
-				blockchain.BlockchainAuthorizer(
-					blockchain.FindBlock(
-						repository.GetTip(db),
-						repository.GetBlock(db),
-					),
-				),
+				blockchain.BlockchainAuthorizer(repository.IsEligibleVoter(db)),
 			),
 		_websocket.TransactionReceivedMessage: handlers.SaveTransaction(
 			repository.SaveTransaction(db),
 			wallet.VerifySignature,
+			handlerLog,
 		),
 		_websocket.ForgeBlockMessage: handlers.ForgeBlock(
 			repository.GetTip(db),
 			repository.GetBlock(db),
-			repository.ForgeBlock(db),
+			getClock,
+			repository.ForgeBlock(db, getParameters, blockchain.NewBlock(*masterWallet)),
 			repository.GetTransactions(db),
+			isRecastClosed,
+			isElectionClosed,
 			transaction.NewStakeTransaction(
 				repository.GetUTXOsByPublicKey(db),
 				signer,
@@ -130,6 +243,8 @@ func main() {
 			),
 			transaction.IsReturnStakeTransaction(hashedAlfaPKey),
 			hub.Broadcast,
+			forges.Record,
+			handlerLog,
 		).
 			Authorized(
 				_websocket.PublicKeyAuthorizer(
@@ -140,18 +255,69 @@ func main() {
 		_websocket.BlockForgedMessage: handlers.BlockForged(
 			repository.GetTip(db),
 			repository.GetBlock(db),
-			blockchain.VerfiyBlock(verifyTransactions, transaction.IsStakeTransaction(hashedAlfaPKey)),
-			blockchain.IsReturnStakeBlock(verifyTransactions, hashedAlfaPKey),
+			repository.GetBlockHeight(db),
+			blockchain.VerfiyBlock(verifyTransactions, transaction.IsStakeTransaction(hashedAlfaPKey), verifySlot).
+				And(verifyBlockRules),
+			blockchain.IsReturnStakeBlock(verifyTransactions, hashedAlfaPKey, verifySlot),
+			blockchain.VerifyCheckpoint(repository.GetCheckpoint(db), getBlock, repository.GetBlockHeight(db)),
+			blockchain.VerifyProtocol(getParameters, repository.GetBlockHeight(db)),
 			repository.AddNewBlock(db),
+			repository.RecordForgedHeader(db),
+			repository.SaveEquivocation(db),
+			hub.Broadcast,
+			handlerLog,
 		),
+		_websocket.EquivocationMessage: handlers.Equivocation(verifyEquivocation, repository.SaveEquivocation(db), hub.Broadcast, handlerLog),
+		_websocket.CheckpointMessage: handlers.Checkpoint(
+			alfaPKey,
+			repository.GetBlockByHeight(db),
+			repository.ChainState(db),
+			repository.SaveCheckpoint(db),
+			handlerLog,
+		),
+		_websocket.GetSnapshotMessage: handlers.GetSnapshot(repository.ExportSnapshot(db)),
+		_websocket.GetBlockMessage:    handlers.GetBlock(repository.GetBlock(db)),
 	}
 	go _websocket.MaintainConnection(conn, router, hub, "0", signer)
 	if err := connectToNodes(nodes, *masterWallet, router, hub, signer); err != nil {
 		log.Fatalf("Failed to connect to nodes %s", err)
 	}
-	log.Printf("Nodes %#v\n", nodes)
-	http.Handle("/", _websocket.PingPongConnection(router, hub, signer))
-	http.ListenAndServe(fmt.Sprintf("localhost:%d", 10000+*nodeID), nil)
+	root.Subsystem("websocket").Info("Connected to nodes", logger.F("nodes", strings.Join(nodes, ",")))
+	apiLog := root.Subsystem("api")
+	httpRouter := mux.NewRouter()
+	httpRouter.Handle("/", _websocket.PingPongConnection(router, hub, signer).Logged(root.Subsystem("websocket")))
+	httpRouter.Handle("/metrics", metrics.Handler(root.Subsystem("metrics"))).Methods("GET")
+	httpRouter.HandleFunc("/healthz", api.NewHandleFunc(api.Healthz(), apiLog)).Methods("GET")
+	httpRouter.HandleFunc("/readyz",
+		api.NewHandleFunc(
+			api.Readyz(
+				api.Check{Name: "alfa", Run: handlers.AlfaCheck(hub.IsRegistered)},
+				api.Check{Name: "sync", Run: handlers.SyncCheck(repository.GetHeight(db), alfaClient.Head, *readyLag)},
+			),
+			apiLog,
+		),
+	).Methods("GET")
+	httpRouter.HandleFunc("/status",
+		api.NewHandleFunc(
+			handlers.GetStatus(
+				strconv.Itoa(*nodeID),
+				getTip,
+				getBlock,
+				repository.GetHeight(db),
+				hub.IsRegistered,
+				hub.Peers,
+				alfaClient.Head,
+				alfaClient.Election,
+				forges.Last,
+				repository.GetUTXOsByPublicKey(db),
+				masterWallet.PublicKeyHash(),
+			),
+			apiLog,
+		),
+	).Methods("GET")
+	httpRouter.HandleFunc("/log/levels", api.NewHandleFunc(api.GetLogLevels(levels), apiLog)).Methods("GET")
+	httpRouter.HandleFunc("/log/levels/{subsystem}", api.NewHandleFunc(api.SetLogLevel(levels), apiLog)).Methods("PUT")
+	http.ListenAndServe(fmt.Sprintf("localhost:%d", 10000+*nodeID), httpRouter)
 }
 
 func connectToNodes(nodes []string, wallet wallet.Wallet, router _websocket.Router, hub *_websocket.Hub, signer wallet.Signer) error {
@@ -160,12 +326,7 @@ func connectToNodes(nodes []string, wallet wallet.Wallet, router _websocket.Rout
 		if err != nil {
 			return err
 		}
-		u := url.URL{
-			Scheme: "ws",
-			Host:   fmt.Sprintf("localhost:%d", 10000+i),
-			Path:   "/",
-		}
-		conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		conn, err := dialPeer(i)
 		if err != nil {
 			return err
 		}
@@ -177,3 +338,32 @@ func connectToNodes(nodes []string, wallet wallet.Wallet, router _websocket.Rout
 	}
 	return nil
 }
+
+func dialPeer(id int) (*websocket.Conn, error) {
+	u := url.URL{
+		Scheme: "ws",
+		Host:   fmt.Sprintf("localhost:%d", 10000+id),
+		Path:   "/",
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	return conn, err
+}
+
+func loadGenesis(path, specPath string) ([]byte, error) {
+	if specPath != "" {
+		spec, err := genesis.Load(specPath)
+		if err != nil {
+			return nil, err
+		}
+		block, err := genesis.Build(*spec, repository.ProjectGenesisRoot())
+		if err != nil {
+			return nil, err
+		}
+		return block.Header.Hash, nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(raw)))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+)
+
+type body struct {
+	Revoked     string `json:"revoked"`
+	Replacement string `json:"replacement"`
+	Verifier    string `json:"verifier"`
+	Signature   string `json:"signature"`
+}
+
+func (b body) Signable() ([]byte, error) {
+	data := struct {
+		Revoked     string `json:"revoked"`
+		Replacement string `json:"replacement"`
+	}{
+		Revoked:     b.Revoked,
+		Replacement: b.Replacement,
+	}
+	return json.Marshal(data)
+}
+
+func main() {
+	url := "http://localhost:8000/revocations"
+	privateKey := flag.String("private", "alfa/key.pem", "Private key file path of the authority")
+	publicKey := flag.String("public", "alfa/key_pub.pem", "Public key file path of the authority")
+	revoked := flag.String("revoked", "", "Address of the voter whose key is revoked [required]")
+	replacement := flag.String("replacement", "", "Address which receives the replacement ballot [required]")
+	flag.Parse()
+	if *revoked == "" || *replacement == "" {
+		log.Fatal("Both revoked and replacement addresses must be provided")
+	}
+	w, err := wallet.Import(keyfiles.KeyFiles{
+		PrivateKeyFile: *privateKey,
+		PublicKeyFile:  *publicKey,
+	})
+	if err != nil {
+		log.Fatalf("Failed to load authority wallet %s", err)
+	}
+	body := body{
+		Revoked:     *revoked,
+		Replacement: *replacement,
+		Verifier:    base64.StdEncoding.EncodeToString(w.PublicKey),
+	}
+	signature, err := wallet.Sign(body, w.PrivateKey)
+	if err != nil {
+		log.Fatalf("Failed to sign revocation %s", err)
+	}
+	body.Signature = base64.StdEncoding.EncodeToString(signature)
+	raw, err := json.Marshal(body)
+	if err != nil {
+		log.Fatalf("Failed to marshal body %s", err)
+	}
+	resp, err := http.Post(url, "application/json", bytes.NewReader(raw))
+	if err != nil {
+		log.Fatalf("Failed to revoke %s", err)
+	}
+	defer resp.Body.Close()
+	result, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("Failed to read response %s", err)
+	}
+	log.Printf("Received response %s", result)
+}
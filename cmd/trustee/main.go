@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+)
+
+func getJSON(url string, target interface{}) error {
+	response, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	raw, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("Request failed with status %d: %s", response.StatusCode, raw)
+	}
+	return json.Unmarshal(raw, target)
+}
+
+func main() {
+	baseURL := "http://localhost:8000"
+	shareFile := flag.String("share", "", "Trustee share file path [required]")
+	flag.Parse()
+	if *shareFile == "" {
+		log.Fatal("Share file must be provided")
+	}
+	rawShare, err := ioutil.ReadFile(*shareFile)
+	if err != nil {
+		log.Fatalf("Failed to read share file %s", err)
+	}
+	var share elgamal.Share
+	if err := json.Unmarshal(rawShare, &share); err != nil {
+		log.Fatalf("Failed to parse share file %s", err)
+	}
+	var info api.Election
+	if err := getJSON(baseURL+"/election", &info); err != nil {
+		log.Fatalf("Failed to retrieve election %s", err)
+	}
+	if info.ElectionKey == nil {
+		log.Fatal("Election does not accept encrypted ballots")
+	}
+	var encrypted election.EncryptedTally
+	if err := getJSON(baseURL+"/tally/encrypted", &encrypted); err != nil {
+		log.Fatalf("Failed to retrieve encrypted tally %s", err)
+	}
+	decryption := election.TrusteeDecryption{
+		Trustee:     share.Index,
+		Decryptions: map[string]elgamal.PartialDecryption{},
+	}
+	for party, c := range encrypted.Ciphertexts {
+		pd, err := elgamal.PartiallyDecrypt(*info.ElectionKey, share, c)
+		if err != nil {
+			log.Fatalf("Failed to decrypt tally of %s %s", party, err)
+		}
+		decryption.Decryptions[party] = *pd
+	}
+	body, err := json.Marshal(decryption)
+	if err != nil {
+		log.Fatalf("Failed to serialize decryption %s", err)
+	}
+	resp, err := http.Post(baseURL+"/tally/decryptions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Fatalf("Failed to submit decryption %s", err)
+	}
+	defer resp.Body.Close()
+	result, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("Failed to read response %s", err)
+	}
+	log.Printf("Received response %s", result)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type savedToken struct {
+	Token []byte `json:"token"`
+}
+
+func sign(w wallet.Wallet, payload wallet.Signable) (string, error) {
+	signature, err := wallet.Sign(payload, w.PrivateKey)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to sign %#v", payload)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+func obtainToken(c *client.Client, w wallet.Wallet, oneTime wallet.Wallet) ([]byte, error) {
+	alfaPKey, err := wallet.LoadPublicKey("alfa/key_pub.pem")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to load authority public key")
+	}
+	sender := base64.StdEncoding.EncodeToString(w.PublicKeyHash())
+	verifier := base64.StdEncoding.EncodeToString(w.PublicKey)
+	commitmentReq := api.TokenCommitmentRequest{Sender: sender, Verifier: verifier}
+	if commitmentReq.Signature, err = sign(w, commitmentReq); err != nil {
+		return nil, err
+	}
+	commitment, err := c.TokenCommitment(commitmentReq)
+	if err != nil {
+		return nil, err
+	}
+	factors, challenge, err := wallet.Blind(commitment, alfaPKey, oneTime.PublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to blind one-time key")
+	}
+	issueReq := api.IssueTokenRequest{
+		Sender:    sender,
+		Challenge: base64.StdEncoding.EncodeToString(challenge),
+		Verifier:  verifier,
+	}
+	if issueReq.Signature, err = sign(w, issueReq); err != nil {
+		return nil, err
+	}
+	transfer := api.Transfer{
+		Sender:    sender,
+		Recipient: base64.StdEncoding.EncodeToString(transaction.BallotPoolHash()),
+		Value:     transaction.VoteValue,
+	}
+	if issueReq.TransferSignature, err = sign(w, transfer); err != nil {
+		return nil, err
+	}
+	blindSignature, err := c.IssueToken(issueReq)
+	if err != nil {
+		return nil, err
+	}
+	token := wallet.Unblind(*factors, blindSignature)
+	if !wallet.VerifyBlindSignature(oneTime.PublicKey, token, alfaPKey) {
+		return nil, errors.New("Authority returned an invalid token")
+	}
+	return token, nil
+}
+
+func voteAnonymously(c *client.Client, w wallet.Wallet, recipient []byte, tokensDir string, id int) error {
+	prefix := fmt.Sprintf("%s/c%d_onetime", tokensDir, id)
+	tokenFile := fmt.Sprintf("%s/c%d_token.json", tokensDir, id)
+	if _, err := os.Stat(tokenFile); os.IsNotExist(err) {
+		if err := os.MkdirAll(tokensDir, os.ModePerm); err != nil {
+			return errors.Wrapf(err, "Failed to create directory %s", tokensDir)
+		}
+		oneTime, err := wallet.New()
+		if err != nil {
+			return errors.Wrap(err, "Failed to create one-time key")
+		}
+		token, err := obtainToken(c, w, *oneTime)
+		if err != nil {
+			return errors.Wrap(err, "Failed to obtain ballot token")
+		}
+		if err := oneTime.Export(prefix); err != nil {
+			return errors.Wrap(err, "Failed to save one-time key")
+		}
+		raw, err := json.Marshal(savedToken{Token: token})
+		if err != nil {
+			return errors.Wrap(err, "Failed to serialize token")
+		}
+		if err := ioutil.WriteFile(tokenFile, raw, 0600); err != nil {
+			return errors.Wrap(err, "Failed to save token")
+		}
+		log.Println("Ballot token obtained. Run the voter again once the token has been forged into a block to cast the vote")
+		return nil
+	}
+	raw, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read token")
+	}
+	var saved savedToken
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return errors.Wrap(err, "Failed to parse token")
+	}
+	oneTime, err := wallet.Import(keyfiles.KeyFiles{
+		PrivateKeyFile: prefix + ".pem",
+		PublicKeyFile:  prefix + "_pub.pem",
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to load one-time key")
+	}
+	body := api.Vote{
+		Sender:    base64.StdEncoding.EncodeToString(transaction.BallotPoolHash()),
+		Recipient: base64.StdEncoding.EncodeToString(recipient),
+		Verifier:  base64.StdEncoding.EncodeToString(oneTime.PublicKey),
+		Token:     base64.StdEncoding.EncodeToString(saved.Token),
+	}
+	signature, err := wallet.Sign(body, oneTime.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "Failed to sign vote")
+	}
+	body.Signature = base64.StdEncoding.EncodeToString(signature)
+	if err := c.Vote(body); err != nil {
+		return err
+	}
+	log.Println("Voted anonymously")
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/base64"
+	"log"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func voteEncrypted(c *client.Client, w wallet.Wallet, choice string) error {
+	info, err := c.Election()
+	if err != nil {
+		return err
+	}
+	if !info.Encrypted || info.ElectionKey == nil {
+		return errors.New("Election does not accept encrypted ballots")
+	}
+	parties, err := c.Parties()
+	if err != nil {
+		return errors.Wrap(err, "Failed to list parties")
+	}
+	addresses := make([]string, 0, len(parties))
+	for _, p := range parties {
+		addresses = append(addresses, p.Address)
+	}
+	ballot, err := elgamal.NewEncryptedBallot(*info.ElectionKey, w.PublicKeyHash(), addresses, choice)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encrypt ballot")
+	}
+	rawBallot, err := ballot.Serialized()
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize ballot")
+	}
+	body := api.Vote{
+		Sender:    base64.StdEncoding.EncodeToString(w.PublicKeyHash()),
+		Recipient: base64.StdEncoding.EncodeToString(transaction.BallotBoxHash()),
+		Verifier:  base64.StdEncoding.EncodeToString(w.PublicKey),
+		Ballot:    base64.StdEncoding.EncodeToString(rawBallot),
+	}
+	if body.Signature, err = sign(w, body); err != nil {
+		return err
+	}
+	if err := c.Vote(body); err != nil {
+		return err
+	}
+	log.Println("Encrypted ballot cast")
+	return nil
+}
@@ -1,45 +1,28 @@
 package main
 
 import (
-	"bytes"
 	"encoding/base64"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
 
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
 	"github.com/nebser/crypto-vote/internal/pkg/keyfiles"
-	"github.com/nebser/crypto-vote/internal/pkg/party"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
-type body struct {
-	Sender    string `json:"sender"`
-	Recipient string `json:"recipient"`
-	Verifier  string `json:"verifier"`
-	Signature string `json:"signature"`
-}
-
-func (b body) Signable() ([]byte, error) {
-	data := struct {
-		Sender    string `json:"sender"`
-		Recipient string `json:"recipient"`
-		Value     int    `json:"value"`
-	}{
-		Sender:    b.Sender,
-		Recipient: b.Recipient,
-		Value:     10,
-	}
-	return json.Marshal(data)
-}
-
 func main() {
-	url := "http://localhost:8000/vote"
 	id := flag.Int("id", -1, "ID of the client that's voting")
 	choice := flag.Int("choice", -1, "ID of the choice to vote for")
+	anonymous := flag.Bool("anonymous", false, "Vote with a blind-signed ballot token from a one-time address")
+	tokensDir := flag.String("tokens", "tokens", "Directory where ballot tokens and one-time keys are kept")
+	encrypted := flag.Bool("encrypted", false, "Cast an encrypted ballot with proofs of validity")
+	ring := flag.Bool("ring", false, "Sign the vote with a linkable ring signature over all eligible voters")
+	recast := flag.Bool("recast", false, "Cast a vote that replaces the previous one until the election closes")
+	apiURL := flag.String("api", client.DefaultBaseURL, "Base URL of the alfa node HTTP API")
+	timeout := flag.Duration("timeout", client.DefaultTimeout, "Timeout of HTTP API requests")
 	flag.Parse()
 	if *id == -1 {
 		log.Fatalf("ID flag must be greater or equal to zero")
@@ -63,7 +46,32 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	body := body{
+	c := client.New(*apiURL, *timeout)
+	if *anonymous {
+		if err := voteAnonymously(c, *w, hashedPartyPub, *tokensDir, *id); err != nil {
+			log.Fatalf("Failed to vote anonymously %s", err)
+		}
+		return
+	}
+	if *ring {
+		if err := voteWithRing(c, *w, hashedPartyPub); err != nil {
+			log.Fatalf("Failed to cast ring vote %s", err)
+		}
+		return
+	}
+	if *recast {
+		if err := voteRecast(c, *w, hashedPartyPub); err != nil {
+			log.Fatalf("Failed to recast vote %s", err)
+		}
+		return
+	}
+	if *encrypted {
+		if err := voteEncrypted(c, *w, wallet.AddressFromPublicKeyHash(hashedPartyPub)); err != nil {
+			log.Fatalf("Failed to cast encrypted vote %s", err)
+		}
+		return
+	}
+	body := api.Vote{
 		Sender:    base64.StdEncoding.EncodeToString(w.PublicKeyHash()),
 		Recipient: base64.StdEncoding.EncodeToString(hashedPartyPub),
 		Verifier:  base64.StdEncoding.EncodeToString(w.PublicKey),
@@ -73,39 +81,16 @@ func main() {
 		panic(err)
 	}
 	body.Signature = base64.StdEncoding.EncodeToString(signature)
-	raw, err := json.Marshal(body)
-	if err != nil {
-		panic(err)
+	switch err := c.Vote(body); {
+	case errors.Is(err, client.ErrUserAlreadyVoted):
+		log.Fatal("Voter already voted")
+	case err != nil:
+		log.Fatalf("Failed to vote %s", err)
 	}
-	reader := bytes.NewReader(raw)
-	resp, err := http.Post(url, "application/json", reader)
-	if err != nil {
-		panic(err)
-	}
-	defer resp.Body.Close()
-	result, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		panic(err)
-	}
-	log.Printf("Received response %s", result)
-	parties, err := listParties()
+	log.Println("Vote accepted")
+	parties, err := c.Parties()
 	if err != nil {
 		log.Fatalf("Failed to list parties %s", err)
 	}
 	log.Printf("Parties %#v", parties)
-
-}
-
-func listParties() (party.Parties, error) {
-	response, err := http.Get("http://localhost:8000/parties")
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to retrieve parties")
-	}
-	defer response.Body.Close()
-	raw, err := ioutil.ReadAll(response.Body)
-	var parties party.Parties
-	if err := json.Unmarshal(raw, &parties); err != nil {
-		return nil, errors.Wrapf(err, "Failed to unmarshal response %s", raw)
-	}
-	return parties, nil
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/base64"
+	"log"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func voteRecast(c *client.Client, w wallet.Wallet, party []byte) error {
+	sequence, err := c.RecastSequence(w.Address)
+	if err != nil {
+		return err
+	}
+	body := api.Vote{
+		Sender:    base64.StdEncoding.EncodeToString(w.PublicKeyHash()),
+		Recipient: base64.StdEncoding.EncodeToString(transaction.RecastEscrowHash()),
+		Verifier:  base64.StdEncoding.EncodeToString(w.PublicKey),
+		Party:     base64.StdEncoding.EncodeToString(party),
+		Sequence:  sequence,
+	}
+	signature, err := wallet.Sign(body, w.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "Failed to sign recast vote")
+	}
+	body.Signature = base64.StdEncoding.EncodeToString(signature)
+	if err := c.Vote(body); err != nil {
+		return err
+	}
+	log.Printf("Cast vote number %d", sequence+1)
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/base64"
+	"log"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/client"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func voteWithRing(c *client.Client, w wallet.Wallet, recipient []byte) error {
+	ring, err := c.Ring()
+	if err != nil {
+		return err
+	}
+	message, err := transaction.RingVoteMessage(recipient)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create ring vote message")
+	}
+	signature, err := wallet.SignRing(message, ring, w.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "Failed to sign ring vote")
+	}
+	body := api.Vote{
+		Sender:    base64.StdEncoding.EncodeToString(transaction.RingPoolHash()),
+		Recipient: base64.StdEncoding.EncodeToString(recipient),
+		Ring:      signature,
+	}
+	if err := c.Vote(body); err != nil {
+		return err
+	}
+	log.Println("Voted with ring signature")
+	return nil
+}
@@ -1,101 +1,160 @@
 package alfa
 
 import (
-	"fmt"
-	"log"
-
-	"github.com/nebser/crypto-vote/internal/pkg/party"
-	"github.com/nebser/crypto-vote/internal/pkg/transaction"
-	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"bytes"
+	"time"
 
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 )
 
-func Initialize(masterWallet wallet.Wallet, nodeWallets, clientWallets wallet.Wallets, addBlock blockchain.AddBlockFn, saveParty party.SavePartyFn) error {
-	genesisTransaction, err := transaction.NewBaseTransaction(masterWallet, masterWallet.Address, 100*transaction.VoteValue)
+func Initialize(masterWallet wallet.Wallet, spec genesis.Spec, projectRoot blockchain.ProjectUTXORootFn, addBlock blockchain.AddBlockFn) (*blockchain.Block, error) {
+	unsigned, err := genesis.Build(spec, projectRoot)
 	if err != nil {
-		return errors.Wrap(err, "Failed to generate genesis transaction")
+		return nil, errors.Wrap(err, "Failed to build genesis block")
 	}
-	genesisBlock, err := blockchain.NewBlock(nil, transaction.Transactions{*genesisTransaction})
-	if err != nil {
-		return errors.Wrap(err, "Failed to create genesis block")
+	if bytes.Compare(unsigned.Header.Forger, masterWallet.PublicKey) != 0 {
+		return nil, errors.Errorf("Genesis authority %x is not the alfa node", unsigned.Header.Forger)
 	}
-	tip, err := addBlock(*genesisBlock)
+	block, err := unsigned.Signed(masterWallet)
 	if err != nil {
-		errors.Wrap(err, "Failed to initialize blockchain")
-	}
-	baseTransactions := transaction.Transactions{}
-	for _, w := range append(nodeWallets, clientWallets...) {
-		t, err := transaction.NewBaseTransaction(masterWallet, w.Address, transaction.VoteValue)
-		if err != nil {
-			return errors.Wrapf(err, "Failed to create transaction to wallet %#v", w)
-		}
-		baseTransactions = append(baseTransactions, *t)
-	}
-	for i, wallet := range nodeWallets {
-		p := party.Party{
-			Name:    fmt.Sprintf("Party Number: %d", i),
-			Address: wallet.Address,
-		}
-		if err := saveParty(p); err != nil {
-			return errors.Wrapf(err, "Failed to save party %#v", p)
-		}
-	}
-	block, err := blockchain.NewBlock(tip, baseTransactions)
-	if err != nil {
-		return errors.Wrap(err, "Failed to create block of base transactions")
+		return nil, err
 	}
 	if _, err := addBlock(*block); err != nil {
-		return errors.Wrapf(err, "Failed to add block %#v", *block)
+		return nil, errors.Wrap(err, "Failed to initialize blockchain")
 	}
-	return nil
-
+	return block, nil
 }
 
 type RunnerFn func() error
 
-func (r RunnerFn) Run() {
-	log.Println("STARTED RUNNER")
-	if err := r(); err != nil {
-		log.Printf("Failed to run runner. Error %s", err)
-	}
-	log.Println("FINISHED RUNNER")
+func (r RunnerFn) Logged(log logger.Logger) cron.Job {
+	return cron.FuncJob(func() {
+		log.Debug("Runner started")
+		if err := r(); err != nil {
+			log.Warn("Runner failed", logger.Err(err))
+			return
+		}
+		log.Debug("Runner finished")
+	})
 }
 
-func Runner(registeredNodes websocket.RegisteredNodesFn, unicastRandomly websocket.RandomUnicastFn, getTip blockchain.GetTipFn, getBlock blockchain.GetBlockFn) RunnerFn {
-	return func() error {
-		if len(registeredNodes()) < 2 {
-			return errors.Errorf("Not enough nodes registered to perform block forging. Number of blocks %d\n", len(registeredNodes()))
+func Runner(
+	clock blockchain.Clock,
+	forgers websocket.ForgersFn,
+	unicast websocket.UnicastFn,
+	getTip blockchain.GetTipFn,
+	getBlock blockchain.GetBlockFn,
+	getTransactions transaction.GetTransactionsFn,
+	isReturnStakeTransaction transaction.IsReturnStakeTransactionFn,
+	slots *blockchain.SlotTracker,
+	recordMissed blockchain.RecordMissedSlotFn,
+	record blockchain.RecordForgeFn,
+	log logger.Logger,
+) RunnerFn {
+	recordIfMissed := func(assignment blockchain.SlotAssignment, role blockchain.SlotRole) bool {
+		if slots.IsFilled(assignment.Slot) {
+			return false
 		}
-		height, err := blockchain.GetHeight(getTip, getBlock)
+		pending, err := hasPendingTransactions(getTransactions, isReturnStakeTransaction)
 		if err != nil {
-			return errors.Errorf("Error occurred while trying to retrieve blockchain height %s", err)
+			log.Warn("Failed to check pending transactions", logger.Err(err))
+			return false
+		}
+		if !pending {
+			return false
+		}
+		missed := assignment.Missed(role)
+		metrics.MissedSlots.Inc(string(role))
+		log.Warn("Forger missed its slot", logger.F("slot", missed.Slot), logger.PeerID(missed.NodeID), logger.F("role", role))
+		if err := recordMissed(missed); err != nil {
+			log.Warn("Failed to record missed slot", logger.F("slot", missed.Slot), logger.Err(err))
 		}
-		pong := websocket.Pong{
+		return true
+	}
+	request := func(assignment blockchain.SlotAssignment, forger websocket.Forger, deadline time.Time) error {
+		return unicast(forger.NodeID, websocket.Pong{
 			Message: websocket.ForgeBlockMessage,
 			Body: websocket.ForgeBlockBody{
-				Height: height,
+				Height:   assignment.Height - 1,
+				Slot:     assignment.Slot,
+				Deadline: deadline.Unix(),
 			},
-		}
+		})
+	}
+	return func() error {
+		slot := clock.SlotAt(time.Now())
+		height, err := blockchain.GetHeight(getTip, getBlock)
 		if err != nil {
-			return errors.Errorf("Failed to sign forge block message %s", err)
+			return errors.Errorf("Error occurred while trying to retrieve blockchain height %s", err)
 		}
-		if err := unicastRandomly(pong); err != nil {
+		candidates := forgers()
+		if len(candidates) < 2 {
+			err := errors.Errorf("Not enough nodes registered to perform block forging. Number of blocks %d", len(candidates))
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeSkipped, nil, err))
+			return err
+		}
+		assignment := clock.Assign(slot, height+1, candidates)
+		slots.Assign(assignment)
+		time.AfterFunc(time.Until(assignment.Deadline), func() {
+			if !recordIfMissed(assignment, blockchain.DesignatedForger) {
+				return
+			}
+			if slots.Escalate(slot) == nil {
+				return
+			}
+			if err := request(assignment, assignment.Backup, assignment.End); err != nil {
+				record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
+				log.Warn("Failed to send forge block message to backup forger", logger.F("slot", slot), logger.Err(err))
+				return
+			}
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeRequested, nil, nil))
+			time.AfterFunc(time.Until(assignment.End), func() {
+				recordIfMissed(assignment, blockchain.BackupForger)
+			})
+		})
+		if err := request(assignment, assignment.Designated, assignment.Deadline); err != nil {
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
 			return errors.Errorf("Failed to send forge block message %s", err)
 		}
+		record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeRequested, nil, nil))
 		return nil
 	}
 }
 
+func hasPendingTransactions(getTransactions transaction.GetTransactionsFn, isReturnStakeTransaction transaction.IsReturnStakeTransactionFn) (bool, error) {
+	txs, err := getTransactions()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to retrieve transactions")
+	}
+	for _, tx := range txs {
+		if !isReturnStakeTransaction(tx) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func Cleaner(
 	getTransactions transaction.GetTransactionsFn,
 	isReturnStakeTransaction transaction.IsReturnStakeTransactionFn,
 	getTip blockchain.GetTipFn,
 	getBlock blockchain.GetBlockFn,
+	getClock blockchain.GetClockFn,
+	getParameters blockchain.GetParametersFn,
+	projectUTXORoot blockchain.ProjectUTXORootFn,
+	newBlock blockchain.NewBlockFn,
 	addBlock blockchain.AddBlockFn,
 	broadcast websocket.BroadcastFn,
+	log logger.Logger,
 ) RunnerFn {
 	return func() error {
 		txs, err := getTransactions()
@@ -103,22 +162,34 @@ func Cleaner(
 			return errors.Wrap(err, "Failed to retrieve transactions")
 		}
 		if len(txs) != 1 || !isReturnStakeTransaction(txs[0]) {
-			log.Println("Cleaner unnecessary")
+			log.Debug("Cleaner unnecessary", logger.F("pending", len(txs)))
 			return nil
 		}
-		log.Printf("Found transactions %d", len(txs))
-		log.Printf("Found transactions %s", txs)
 		height, err := blockchain.GetHeight(getTip, getBlock)
 		if err != nil {
 			return errors.Wrap(err, "Failed to retrieve blockchain height")
 		}
-		block, err := blockchain.NewBlock(getTip(), transaction.Transactions{txs[0]})
+		clock, err := getClock()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve slot clock")
+		}
+		parameters, err := getParameters()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve chain parameters")
+		}
+		root, err := projectUTXORoot(transaction.Transactions{txs[0]})
+		if err != nil {
+			return errors.Wrap(err, "Failed to project UTXO set root")
+		}
+		block, err := newBlock(parameters.Rules(height+1), getTip(), clock.SlotAt(time.Now()), root, transaction.Transactions{txs[0]})
 		if err != nil {
 			return errors.Wrap(err, "Failed to create new block")
 		}
 		if _, err := addBlock(*block); err != nil {
 			return errors.Wrapf(err, "Failed to add block to blockchain")
 		}
+		metrics.Blocks.Inc(metrics.Forged)
+		log.Info("Forged return stake block", logger.BlockHash(block.Header.Hash), logger.TxID(txs[0].ID), logger.F("height", height+1))
 		broadcast(websocket.Pong{
 			Message: websocket.BlockForgedMessage,
 			Body: websocket.BlockForgedBody{
@@ -129,3 +200,61 @@ func Cleaner(
 		return nil
 	}
 }
+
+func Checkpointer(
+	chainState blockchain.ChainStateFn,
+	getCheckpoint blockchain.GetCheckpointFn,
+	saveCheckpoint blockchain.SaveCheckpointFn,
+	authority wallet.Wallet,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) RunnerFn {
+	return func() error {
+		state, err := chainState()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve blockchain state")
+		}
+		latest, err := getCheckpoint()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve latest checkpoint")
+		}
+		if state == nil || (latest != nil && latest.Height >= state.Height) {
+			log.Debug("Checkpoint unnecessary")
+			return nil
+		}
+		checkpoint, err := state.Signed(authority)
+		if err != nil {
+			return err
+		}
+		if err := saveCheckpoint(*checkpoint); err != nil {
+			return errors.Wrapf(err, "Failed to save checkpoint at height %d", checkpoint.Height)
+		}
+		receivers := broadcast(websocket.Pong{
+			Message: websocket.CheckpointMessage,
+			Body:    *checkpoint,
+		})
+		log.Info("Checkpoint issued", logger.BlockHash(checkpoint.Hash), logger.F("height", checkpoint.Height), logger.F("receivers", receivers))
+		return nil
+	}
+}
+
+func Releaser(isClosed election.IsClosedFn, releaseRecasts transaction.ReleaseRecastsFn, broadcast websocket.BroadcastFn) RunnerFn {
+	return func() error {
+		if !isClosed() {
+			return nil
+		}
+		releases, err := releaseRecasts()
+		if err != nil {
+			return errors.Wrap(err, "Failed to release recast votes")
+		}
+		for _, release := range releases {
+			broadcast(websocket.Pong{
+				Message: websocket.TransactionReceivedMessage,
+				Body: websocket.SaveTransactionBody{
+					Transaction: release,
+				},
+			})
+		}
+		return nil
+	}
+}
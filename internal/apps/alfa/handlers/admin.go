@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/party"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxPartyNameLength = 128
+	maxPartyMetadata   = 32
+)
+
+func adminRequest(request api.Request, authorityKey []byte, useRequest api.UseAdminRequestFn, action api.AdminAction) (*api.AdminRequest, error) {
+	var body api.AdminRequest
+	if err := json.Unmarshal(request.Body, &body); err != nil {
+		return nil, failure.New(failure.InvalidData)
+	}
+	if body.Action != action {
+		return nil, failure.Newf(failure.InvalidData, "Request is signed for action %s instead of %s", body.Action, action)
+	}
+	if address, ok := request.Params["address"]; ok && address != body.Address {
+		return nil, failure.Newf(failure.InvalidData, "Request is signed for address %s instead of %s", body.Address, address)
+	}
+	rawPublicKey, err := base64.StdEncoding.DecodeString(body.Verifier)
+	if err != nil {
+		return nil, failure.Newf(failure.InvalidData, "Invalid public key provided")
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(body.Signature)
+	if err != nil {
+		return nil, failure.Newf(failure.InvalidData, "Invalid signature provided")
+	}
+	if bytes.Compare(rawPublicKey, authorityKey) != 0 {
+		return nil, failure.Newf(failure.Unauthorized, "Only the authority can manage parties and voters")
+	}
+	if !wallet.Verify(body, rawSignature, rawPublicKey) {
+		return nil, failure.Newf(failure.Unauthorized, "Signature does not match the payload")
+	}
+	if age := time.Since(time.Unix(body.Timestamp, 0)); age > api.AdminRequestValidity || age < -api.AdminRequestValidity {
+		return nil, failure.Newf(failure.Unauthorized, "Request was signed at %d which is outside of the accepted window", body.Timestamp)
+	}
+	if body.Nonce == "" {
+		return nil, failure.Newf(failure.InvalidData, "Request nonce must be provided")
+	}
+	if action == api.ScheduleUpgradeAction {
+		if body.Upgrade == nil {
+			return nil, failure.Newf(failure.InvalidData, "Upgrade must be provided")
+		}
+	} else if _, err := wallet.ParseAddress(body.Address); err != nil {
+		return nil, failure.Newf(failure.InvalidData, "Invalid address provided")
+	}
+	switch {
+	case len(body.Name) > maxPartyNameLength:
+		return nil, failure.Newf(failure.InvalidData, "Party name can't be longer than %d characters", maxPartyNameLength)
+	case len(body.Metadata) > maxPartyMetadata:
+		return nil, failure.Newf(failure.InvalidData, "Party can't have more than %d metadata entries", maxPartyMetadata)
+	}
+	id, err := body.ID()
+	if err != nil {
+		return nil, err
+	}
+	switch fresh, err := useRequest(id, body.Expires()); {
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to record admin request %x", id)
+	case !fresh:
+		return nil, failure.Newf(failure.Unauthorized, "Request was already used")
+	}
+	return &body, nil
+}
+
+func changeParty(
+	body api.AdminRequest,
+	isClosed election.IsClosedFn,
+	updateParty transaction.UpdatePartyFn,
+	change party.ChangeFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) (api.Response, error) {
+	if isClosed() {
+		return api.Response{}, failure.New(failure.ElectionClosed)
+	}
+	tr, err := updateParty(body.Address, change)
+	switch {
+	case errors.Is(err, party.ErrPartyExists):
+		return api.Response{}, failure.New(failure.PartyExists)
+	case errors.Is(err, party.ErrPartyNotFound):
+		return api.Response{}, failure.Newf(failure.NotFound, "Party %s does not exist", body.Address)
+	case errors.Is(err, party.ErrPartyInactive):
+		return api.Response{}, failure.New(failure.PartyInactive)
+	case errors.Is(err, party.ErrPartyPending):
+		return api.Response{}, failure.New(failure.PartyPending)
+	case err != nil:
+		return api.Response{}, errors.Wrapf(err, "Failed to change party %s", body.Address)
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Info("Party changed", logger.TxID(tr.ID), logger.F("action", body.Action), logger.F("party", body.Address), logger.F("receivers", receivers))
+	return api.Response{
+		Status: http.StatusOK,
+		Body:   newTransactionView(tr),
+	}, nil
+}
+
+func RegisterParty(
+	authorityKey []byte,
+	useRequest api.UseAdminRequestFn,
+	isClosed election.IsClosedFn,
+	updateParty transaction.UpdatePartyFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		body, err := adminRequest(request, authorityKey, useRequest, api.RegisterPartyAction)
+		if err != nil {
+			return api.Response{}, err
+		}
+		if body.Name == "" {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Party name must be provided")
+		}
+		return changeParty(*body, isClosed, updateParty, func(current *party.Party) (party.Party, error) {
+			if current != nil {
+				return party.Party{}, party.ErrPartyExists
+			}
+			return party.Party{
+				Name:     body.Name,
+				Metadata: body.Metadata,
+				Active:   true,
+			}, nil
+		}, broadcast, log)
+	}
+}
+
+func UpdateParty(
+	authorityKey []byte,
+	useRequest api.UseAdminRequestFn,
+	isClosed election.IsClosedFn,
+	updateParty transaction.UpdatePartyFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		body, err := adminRequest(request, authorityKey, useRequest, api.UpdatePartyAction)
+		if err != nil {
+			return api.Response{}, err
+		}
+		if body.Name == "" && body.Metadata == nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Either name or metadata must be provided")
+		}
+		return changeParty(*body, isClosed, updateParty, func(current *party.Party) (party.Party, error) {
+			switch {
+			case current == nil:
+				return party.Party{}, party.ErrPartyNotFound
+			case !current.Active:
+				return party.Party{}, party.ErrPartyInactive
+			}
+			changed := *current
+			if body.Name != "" {
+				changed.Name = body.Name
+			}
+			if body.Metadata != nil {
+				changed.Metadata = body.Metadata
+			}
+			return changed, nil
+		}, broadcast, log)
+	}
+}
+
+func DeactivateParty(
+	authorityKey []byte,
+	useRequest api.UseAdminRequestFn,
+	isClosed election.IsClosedFn,
+	updateParty transaction.UpdatePartyFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		body, err := adminRequest(request, authorityKey, useRequest, api.DeactivatePartyAction)
+		if err != nil {
+			return api.Response{}, err
+		}
+		return changeParty(*body, isClosed, updateParty, func(current *party.Party) (party.Party, error) {
+			switch {
+			case current == nil:
+				return party.Party{}, party.ErrPartyNotFound
+			case !current.Active:
+				return party.Party{}, party.ErrPartyInactive
+			}
+			changed := *current
+			changed.Active = false
+			return changed, nil
+		}, broadcast, log)
+	}
+}
+
+func RegisterVoter(
+	authorityKey []byte,
+	useRequest api.UseAdminRequestFn,
+	isClosed election.IsClosedFn,
+	getRing transaction.GetRingFn,
+	registerVoter transaction.RegisterVoterFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		body, err := adminRequest(request, authorityKey, useRequest, api.RegisterVoterAction)
+		if err != nil {
+			return api.Response{}, err
+		}
+		if isClosed() {
+			return api.Response{}, failure.New(failure.ElectionClosed)
+		}
+		switch ring, err := getRing(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve ring")
+		case len(ring) > 0:
+			return api.Response{}, failure.Newf(failure.InvalidData, "Voters can't be registered after genesis when votes are signed with ring signatures")
+		}
+		tr, err := registerVoter(wallet.ExtractPublicKeyHash(body.Address))
+		switch {
+		case errors.Is(err, transaction.ErrVoterRegistered):
+			return api.Response{}, failure.New(failure.VoterRegistered)
+		case errors.Is(err, transaction.ErrVoterRevoked):
+			return api.Response{}, failure.New(failure.VoterRevoked)
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to register voter %s", body.Address)
+		}
+		receivers := broadcast(websocket.Pong{
+			Message: websocket.TransactionReceivedMessage,
+			Body: websocket.SaveTransactionBody{
+				Transaction: tr,
+			},
+		})
+		log.Info("Voter registered", logger.TxID(tr.ID), logger.F("voter", body.Address), logger.F("receivers", receivers))
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newTransactionView(tr),
+		}, nil
+	}
+}
+
+func ScheduleUpgrade(
+	authorityKey []byte,
+	useRequest api.UseAdminRequestFn,
+	getParameters blockchain.GetParametersFn,
+	getHeight blockchain.GetHeightFn,
+	scheduleUpgrade transaction.ScheduleUpgradeFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		body, err := adminRequest(request, authorityKey, useRequest, api.ScheduleUpgradeAction)
+		if err != nil {
+			return api.Response{}, err
+		}
+		parameters, err := getParameters()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve chain parameters")
+		}
+		height, err := getHeight()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve blockchain height")
+		}
+		if err := parameters.Scheduled(height, *body.Upgrade); err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "%s", err)
+		}
+		tr, err := scheduleUpgrade(*body.Upgrade)
+		switch {
+		case errors.Is(err, transaction.ErrUpgradePending):
+			return api.Response{}, failure.New(failure.UpgradePending)
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to schedule upgrade at height %d", body.Upgrade.Height)
+		}
+		receivers := broadcast(websocket.Pong{
+			Message: websocket.TransactionReceivedMessage,
+			Body: websocket.SaveTransactionBody{
+				Transaction: tr,
+			},
+		})
+		log.Info("Upgrade scheduled",
+			logger.TxID(tr.ID),
+			logger.F("height", body.Upgrade.Height),
+			logger.F("version", body.Upgrade.Version),
+			logger.F("receivers", receivers),
+		)
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newTransactionView(tr),
+		}, nil
+	}
+}
@@ -1,11 +1,14 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"log"
 
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
@@ -20,17 +23,26 @@ type blockForgedBody struct {
 func BlockForged(
 	getTip blockchain.GetTipFn,
 	getBlock blockchain.GetBlockFn,
+	getBlockHeight blockchain.GetBlockHeightFn,
 	verifyBlock blockchain.VerifyBlockFn,
+	verifyProtocol blockchain.VerifyProtocolFn,
 	addNewBlock blockchain.AddNewBlockFn,
 	isStakeTransaction transaction.IsStakeTransactionFn,
 	saveTransaction transaction.SaveTransaction,
 	newReturnStakeTransaction transaction.NewReturnStakeTransactionFn,
+	slash transaction.SlashFn,
+	proveDoubleSpend blockchain.ProveDoubleSpendFn,
+	recordHeader blockchain.RecordHeaderFn,
+	claimSlot blockchain.ClaimSlotFn,
+	releaseSlot blockchain.ReleaseSlotFn,
 	broadcast websocket.BroadcastFn,
+	publish websocket.BroadcastFn,
+	log logger.Logger,
 ) websocket.Handler {
 	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
 		var body blockForgedBody
 		if err := json.Unmarshal(ping.Body, &body); err != nil {
-			return nil, errors.Wrapf(err, "Failed to unmarsha block forged body %s", ping.Body)
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal block forged body %s", ping.Body)
 		}
 		height, err := blockchain.GetHeight(getTip, getBlock)
 		if err != nil {
@@ -39,19 +51,59 @@ func BlockForged(
 		if height+1 < body.Height {
 			return nil, errors.Errorf("Blockchain height is too low %d", height)
 		}
+		if !ping.Verified() {
+			return nil, failure.Newf(failure.Unauthorized, "Block %x is not signed by its sender", body.Block.Header.Hash)
+		}
 		sender, err := base64.StdEncoding.DecodeString(ping.Sender)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to decode sender %s", ping.Sender)
 		}
-		hashedSender, err := wallet.HashedPublicKey(sender)
+		if !body.Block.Header.Verified() || bytes.Compare(body.Block.Header.Forger, sender) != 0 {
+			return nil, failure.Newf(failure.InvalidData, "Block %x is not signed by its sender", body.Block.Header.Hash)
+		}
+		forger, err := body.Block.Header.ForgerHash()
 		if err != nil {
-			return nil, errors.Wrap(err, "Failed to extract hashed public key")
+			return nil, failure.Newf(failure.InvalidData, "%s", err)
 		}
 		if len(body.Block.Body.Transactions) == 0 || !isStakeTransaction(body.Block.Body.Transactions[0]) {
-			return websocket.NewErrorPong(websocket.NewInvalidDataError(websocket.BlockForgedMessage.String())), nil
+			return nil, failure.Newf(failure.InvalidData, "Invalid values passed for %s operation", websocket.BlockForgedMessage)
 		}
 		stakeTx := body.Block.Body.Transactions[0]
-		if !verifyBlock(body.Block, hashedSender) {
+		log := log.With(logger.BlockHash(body.Block.Header.Hash), logger.F("height", body.Height), logger.F("forger", ping.Sender))
+		evidence, err := json.Marshal(ping)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to serialize evidence of block %x", body.Block.Header.Hash)
+		}
+		parent, err := getBlockHeight(body.Block.Header.Prev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve height of block %x", body.Block.Header.Prev)
+		}
+		var proof *blockchain.Equivocation
+		if parent > 0 {
+			proof, err = recordHeader(forger, parent+1, body.Block.Header.Hash, evidence)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to record header of block %x", body.Block.Header.Hash)
+			}
+		}
+		if proof != nil {
+			log.Warn("Forger signed two different blocks at the same height")
+			metrics.Blocks.Inc(metrics.Rejected)
+			if err := slashEquivocation(*proof, slash, broadcast, log); err != nil {
+				return nil, err
+			}
+			return websocket.NewDisconnectPong(), nil
+		}
+		switch err := verifyProtocol(body.Block); {
+		case errors.Is(err, blockchain.ErrProtocolMismatch):
+			log.Warn("Forged block doesn't follow the protocol rules", logger.Err(err))
+			metrics.Blocks.Inc(metrics.Rejected)
+			return nil, failure.Newf(failure.ProtocolMismatch, "%s", err)
+		case err != nil:
+			return nil, errors.Wrapf(err, "Failed to verify protocol of block %x", body.Block.Header.Hash)
+		}
+		reject := func(reason string) (*websocket.Pong, error) {
+			metrics.Blocks.Inc(metrics.Rejected)
+			log.Warn(reason)
 			if err := saveTransaction(stakeTx); err != nil {
 				return nil, errors.Wrapf(err, "Failed to save stake transaction %s", stakeTx)
 			}
@@ -61,29 +113,53 @@ func BlockForged(
 					Transaction: stakeTx,
 				},
 			})
+			proof, err := proveDoubleSpend(evidence, body.Block)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to prove double spend of block %x", body.Block.Header.Hash)
+			}
+			if proof == nil {
+				log.Warn("Forged block can't be proven invalid to other nodes")
+				return nil, failure.Newf(failure.InvalidData, "Block %x is rejected: %s", body.Block.Header.Hash, reason)
+			}
+			if err := slashForger(*proof, body, forger, reason, slash, broadcast, log); err != nil {
+				return nil, err
+			}
 			return websocket.NewDisconnectPong(), nil
 		}
+		if !verifyBlock(body.Block) {
+			return reject("Forged block is not verified")
+		}
 		returnStakeTx, err := newReturnStakeTransaction(stakeTx)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to create return stake transaction out of %s", stakeTx)
 		}
+		switch err := claimSlot(body.Block.Header.Slot, ping.Sender); {
+		case errors.Is(err, blockchain.ErrSlotMissed):
+			log.Warn("Forged block missed deadline of its slot", logger.F("slot", body.Block.Header.Slot))
+			metrics.Blocks.Inc(metrics.Rejected)
+			return nil, failure.Newf(failure.SlotMissed, "Block %x missed deadline of slot %d", body.Block.Header.Hash, body.Block.Header.Slot)
+		case err != nil:
+			log.Warn("Forged block is not in the slot of its forger", logger.F("slot", body.Block.Header.Slot), logger.Err(err))
+			metrics.Blocks.Inc(metrics.Rejected)
+			return nil, failure.Newf(failure.SlotNotAssigned, "Block %x can't fill slot %d: %s", body.Block.Header.Hash, body.Block.Header.Slot, err)
+		}
 		switch err := addNewBlock(body.Block); {
 		case errors.Is(err, blockchain.ErrInvalidBlock):
-			if err := saveTransaction(stakeTx); err != nil {
-				return nil, errors.Wrapf(err, "Failed to save invalid stake transaction %s", stakeTx)
-			}
-			broadcast(websocket.Pong{
-				Message: websocket.TransactionReceivedMessage,
-				Body: websocket.SaveTransactionBody{
-					Transaction: stakeTx,
-				},
-			})
-			log.Println("Block is invalid")
-			return websocket.NewDisconnectPong(), nil
+			releaseSlot(body.Block.Header.Slot)
+			return reject("Forged block spends invalid outputs")
 		case err != nil:
+			releaseSlot(body.Block.Header.Slot)
 			return nil, errors.Wrap(err, "Failed to add new block to blockchain")
 		default:
-			log.Println("New block added")
+			log.Info("Forged block added")
+			metrics.Blocks.Inc(metrics.Accepted)
+			publish(websocket.Pong{
+				Message: websocket.BlockForgedMessage,
+				Body: websocket.BlockForgedBody{
+					Height: body.Height,
+					Block:  body.Block,
+				},
+			})
 			if err := saveTransaction(*returnStakeTx); err != nil {
 				return nil, errors.Wrapf(err, "Failed to save return stake transaction %s", stakeTx)
 			}
@@ -97,3 +173,42 @@ func BlockForged(
 		}
 	}
 }
+
+func slashForger(
+	proof blockchain.InvalidBlockEvidence,
+	body blockForgedBody,
+	forger []byte,
+	reason string,
+	slash transaction.SlashFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) error {
+	evidence, err := json.Marshal(proof)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize evidence of block %x", body.Block.Header.Hash)
+	}
+	tr, err := slash(transaction.Slashing{
+		Forger:             forger,
+		Offence:            transaction.InvalidBlockOffence,
+		Height:             body.Height,
+		BlockHash:          body.Block.Header.Hash,
+		StakeTransactionID: body.Block.Body.Transactions[0].ID,
+		Reason:             reason,
+		Evidence:           evidence,
+	})
+	switch {
+	case errors.Is(err, transaction.ErrForgerSlashed):
+		log.Info("Forger is already slashed")
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "Failed to slash forger of block %x", body.Block.Header.Hash)
+	}
+	broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Warn("Forger slashed", logger.TxID(tr.ID), logger.F("address", wallet.AddressFromPublicKeyHash(forger)))
+	return nil
+}
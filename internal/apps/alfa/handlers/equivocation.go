@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+func Equivocation(alfaKeyHash []byte, verifyEquivocation blockchain.VerifyEquivocationFn, slash transaction.SlashFn, broadcast websocket.BroadcastFn, log logger.Logger) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		var proof blockchain.Equivocation
+		if err := json.Unmarshal(ping.Body, &proof); err != nil {
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal equivocation %s", ping.Body)
+		}
+		if bytes.Compare(proof.Forger, alfaKeyHash) == 0 || !verifyEquivocation(proof) {
+			return nil, failure.Newf(failure.InvalidData, "Equivocation proof is not valid")
+		}
+		if err := slashEquivocation(proof, slash, broadcast, log); err != nil {
+			return nil, err
+		}
+		return websocket.NewNoActionPong(), nil
+	}
+}
+
+func slashEquivocation(proof blockchain.Equivocation, slash transaction.SlashFn, broadcast websocket.BroadcastFn, log logger.Logger) error {
+	s, err := proof.Slashing()
+	if err != nil {
+		return err
+	}
+	tr, err := slash(s)
+	switch {
+	case errors.Is(err, transaction.ErrForgerSlashed):
+		log.Debug("Equivocating forger is already slashed", logger.F("address", wallet.AddressFromPublicKeyHash(proof.Forger)))
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "Failed to slash equivocation of %x at height %d", proof.Forger, proof.Height)
+	}
+	broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Warn("Equivocating forger slashed",
+		logger.TxID(tr.ID),
+		logger.F("address", wallet.AddressFromPublicKeyHash(proof.Forger)),
+		logger.F("height", proof.Height),
+	)
+	return nil
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	BlockEvent       = "block"
+	TransactionEvent = "transaction"
+	PartiesEvent     = "parties"
+)
+
+func partiesEvent(getParties api.Handler) (*websocket.Event, error) {
+	response, err := getParties(api.Request{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve parties")
+	}
+	return &websocket.Event{
+		Name: PartiesEvent,
+		Data: response.Body,
+	}, nil
+}
+
+func LiveEvents(getParties api.Handler, log logger.Logger) websocket.ToEventsFn {
+	return func(pong websocket.Pong) []websocket.Event {
+		switch pong.Message {
+		case websocket.TransactionReceivedMessage:
+			body, ok := pong.Body.(websocket.SaveTransactionBody)
+			if !ok {
+				return nil
+			}
+			return []websocket.Event{
+				{
+					Name: TransactionEvent,
+					Data: newTransactionView(body.Transaction),
+				},
+			}
+		case websocket.BlockForgedMessage:
+			body, ok := pong.Body.(websocket.BlockForgedBody)
+			if !ok {
+				return nil
+			}
+			block, ok := body.Block.(blockchain.Block)
+			if !ok {
+				return nil
+			}
+			events := []websocket.Event{
+				{
+					Name: BlockEvent,
+					Data: newBlockView(block, body.Height),
+				},
+			}
+			parties, err := partiesEvent(getParties)
+			if err != nil {
+				log.Warn("Failed to create parties event", logger.Err(err))
+				return events
+			}
+			return append(events, *parties)
+		default:
+			return nil
+		}
+	}
+}
+
+func EventsSnapshot(getParties api.Handler) websocket.SnapshotFn {
+	return func() ([]websocket.Event, error) {
+		parties, err := partiesEvent(getParties)
+		if err != nil {
+			return nil, err
+		}
+		return []websocket.Event{*parties}, nil
+	}
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultBlocksLimit = 10
+	maxBlocksLimit     = 100
+)
+
+func newTransactionView(t transaction.Transaction) api.Transaction {
+	view := api.Transaction{
+		ID:        hex.EncodeToString(t.ID),
+		Type:      t.Type.String(),
+		Inputs:    make([]api.Input, 0, len(t.Inputs)),
+		Outputs:   make([]api.Output, 0, len(t.Outputs)),
+		Payload:   hex.EncodeToString(t.Payload),
+		Timestamp: t.Timestamp,
+	}
+	for _, in := range t.Inputs {
+		input := api.Input{
+			TransactionID: hex.EncodeToString(in.TransactionID),
+			Vout:          in.Vout,
+			PublicKeyHash: hex.EncodeToString(in.PublicKeyHash),
+			Address:       wallet.AddressFromPublicKeyHash(in.PublicKeyHash),
+			Signature:     hex.EncodeToString(in.Signature),
+			Verifier:      hex.EncodeToString(in.Verifier),
+			Token:         hex.EncodeToString(in.Token),
+		}
+		if in.Ring != nil {
+			input.KeyImage = hex.EncodeToString(in.Ring.KeyImage)
+		}
+		view.Inputs = append(view.Inputs, input)
+	}
+	for _, out := range t.Outputs {
+		view.Outputs = append(view.Outputs, api.Output{
+			Value:         out.Value,
+			PublicKeyHash: hex.EncodeToString(out.PublicKeyHash),
+			Address:       wallet.AddressFromPublicKeyHash(out.PublicKeyHash),
+		})
+	}
+	return view
+}
+
+func newRecordView(record blockchain.TransactionRecord) api.Transaction {
+	view := newTransactionView(record.Transaction)
+	position := record.Position
+	view.Block = hex.EncodeToString(record.Block)
+	view.Height = record.Height
+	view.Position = &position
+	return view
+}
+
+func newBlockView(block blockchain.Block, height int) api.Block {
+	view := api.Block{
+		Hash:              hex.EncodeToString(block.Header.Hash),
+		Prev:              hex.EncodeToString(block.Header.Prev),
+		Height:            height,
+		Version:           block.Header.Version,
+		MagicNumber:       block.Metadata.MagicNumber,
+		TransactionHash:   hex.EncodeToString(block.Header.TransactionHash),
+		Timestamp:         block.Header.Timestamp,
+		Slot:              block.Header.Slot,
+		UTXORoot:          hex.EncodeToString(block.Header.UTXORoot),
+		Signature:         hex.EncodeToString(block.Header.Signature),
+		TransactionsCount: block.Body.TransactionsCount,
+		Transactions:      make([]api.Transaction, 0, len(block.Body.Transactions)),
+	}
+	if forger, err := block.Header.ForgerHash(); err == nil {
+		view.Forger = wallet.AddressFromPublicKeyHash(forger)
+	}
+	for _, record := range block.Records(height) {
+		view.Transactions = append(view.Transactions, newRecordView(record))
+	}
+	return view
+}
+
+func newHead(getTip blockchain.GetTipFn, getBlock blockchain.GetBlockFn, getHeight blockchain.GetHeightFn) (*api.Head, error) {
+	height, err := getHeight()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve blockchain height")
+	}
+	tip, err := getBlock(getTip())
+	switch {
+	case err != nil:
+		return nil, errors.Wrap(err, "Failed to retrieve tip")
+	case tip == nil:
+		return nil, nil
+	}
+	return &api.Head{
+		Height:    height,
+		Hash:      hex.EncodeToString(tip.Header.Hash),
+		Timestamp: tip.Header.Timestamp,
+	}, nil
+}
+
+func GetHead(getTip blockchain.GetTipFn, getBlock blockchain.GetBlockFn, getHeight blockchain.GetHeightFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		head, err := newHead(getTip, getBlock, getHeight)
+		switch {
+		case err != nil:
+			return api.Response{}, err
+		case head == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Blockchain is empty")
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   *head,
+		}, nil
+	}
+}
+
+func GetBlocks(getHeight blockchain.GetHeightFn, getBlock blockchain.GetBlockFn, getBlockByHeight blockchain.GetBlockByHeightFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		height, err := getHeight()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve blockchain height")
+		}
+		start, err := queryInt(request, "start", height)
+		if err != nil || start < 1 {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid start height provided")
+		}
+		limit, err := queryInt(request, "limit", defaultBlocksLimit)
+		if err != nil || limit < 1 || limit > maxBlocksLimit {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Limit must be between 1 and %d", maxBlocksLimit)
+		}
+		if start > height {
+			start = height
+		}
+		response := api.BlockPage{Blocks: []api.Block{}}
+		block, err := getBlockByHeight(start)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve block at height %d", start)
+		}
+		for current := start; block != nil && len(response.Blocks) < limit; current-- {
+			response.Blocks = append(response.Blocks, newBlockView(*block, current))
+			if block.Header.Prev == nil {
+				break
+			}
+			if block, err = getBlock(block.Header.Prev); err != nil {
+				return api.Response{}, errors.Wrapf(err, "Failed to retrieve block at height %d", current-1)
+			}
+		}
+		if last := start - len(response.Blocks); last > 0 {
+			response.Next = last
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   response,
+		}, nil
+	}
+}
+
+func GetBlockByHash(getBlock blockchain.GetBlockFn, getBlockHeight blockchain.GetBlockHeightFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		hash, err := hex.DecodeString(request.Params["hash"])
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid block hash provided")
+		}
+		block, err := getBlock(hash)
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve block %x", hash)
+		case block == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Block %x does not exist", hash)
+		}
+		height, err := getBlockHeight(hash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve height of block %x", hash)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newBlockView(*block, height),
+		}, nil
+	}
+}
+
+func GetBlockByHeight(getBlockByHeight blockchain.GetBlockByHeightFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		height, err := strconv.Atoi(request.Params["height"])
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid block height provided")
+		}
+		block, err := getBlockByHeight(height)
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve block at height %d", height)
+		case block == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Block at height %d does not exist", height)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newBlockView(*block, height),
+		}, nil
+	}
+}
+
+func GetTransaction(getTransactionRecord blockchain.GetTransactionRecordFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		id, err := hex.DecodeString(request.Params["id"])
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid transaction id provided")
+		}
+		record, err := getTransactionRecord(id)
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve transaction %x", id)
+		case record == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Transaction %x does not exist", id)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newRecordView(*record),
+		}, nil
+	}
+}
+
+func GetAddressUTXOs(getUTXOsByPublicKey transaction.GetUTXOsByPublicKeyFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		address := request.Params["address"]
+		publicKeyHash, err := wallet.ParseAddress(address)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid address provided")
+		}
+		utxos, err := getUTXOsByPublicKey(publicKeyHash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve utxos of %s", address)
+		}
+		response := api.AddressUTXOs{
+			Address: address,
+			Balance: utxos.Sum(),
+			UTXOs:   make([]api.UTXO, 0, len(utxos)),
+		}
+		for _, u := range utxos {
+			response.UTXOs = append(response.UTXOs, api.UTXO{
+				TransactionID: hex.EncodeToString(u.TransactionID),
+				Vout:          u.Vout,
+				Value:         u.Value,
+			})
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   response,
+		}, nil
+	}
+}
+
+func GetAddressHistory(getAddressHistory blockchain.GetAddressHistoryFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		address := request.Params["address"]
+		publicKeyHash, err := wallet.ParseAddress(address)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid address provided")
+		}
+		records, err := getAddressHistory(publicKeyHash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve history of %s", address)
+		}
+		response := api.AddressHistory{
+			Address:      address,
+			Transactions: make([]api.Transaction, 0, len(records)),
+		}
+		for _, record := range records {
+			response.Transactions = append(response.Transactions, newRecordView(record))
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   response,
+		}, nil
+	}
+}
+
+func GetPendingTransactions(getTransactions transaction.GetTransactionsFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		transactions, err := getTransactions()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve pending transactions")
+		}
+		result := make([]api.Transaction, 0, len(transactions))
+		for _, t := range transactions {
+			result = append(result, newTransactionView(t))
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   result,
+		}, nil
+	}
+}
+
+func queryInt(request api.Request, name string, fallback int) (int, error) {
+	value := request.Query.Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
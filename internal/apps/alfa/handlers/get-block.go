@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
 )
@@ -20,14 +21,14 @@ func GetBlock(getBlock blockchain.GetBlockFn) websocket.Handler {
 	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
 		var p getBlockPayload
 		if err := json.Unmarshal(ping.Body, &p); err != nil {
-			return nil, errors.Wrapf(err, "Failed to unmarshal data %s into payload", ping.Body)
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal data %s into payload", ping.Body)
 		}
 		block, err := getBlock(p.Hash)
 		switch {
 		case err != nil:
 			return nil, errors.Wrapf(err, "Failed to retrieve block %s", p.Hash)
 		case block == nil:
-			return websocket.NewErrorPong(websocket.NewBlockNotFoundError(p.Hash)), nil
+			return nil, failure.Newf(failure.BlockNotFound, "Block %x not found", p.Hash)
 		default:
 			return websocket.NewResponsePong(
 				getBlockResponse{
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type getCheckpointResponse struct {
+	Checkpoint *blockchain.Checkpoint `json:"checkpoint"`
+}
+
+type checkpointResponse struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	UTXORoot  string `json:"utxoRoot"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+}
+
+func GetCheckpoint(getCheckpoint blockchain.GetCheckpointFn) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		checkpoint, err := getCheckpoint()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve latest checkpoint")
+		}
+		return websocket.NewResponsePong(getCheckpointResponse{Checkpoint: checkpoint}), nil
+	}
+}
+
+func GetLatestCheckpoint(getCheckpoint blockchain.GetCheckpointFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		checkpoint, err := getCheckpoint()
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve latest checkpoint")
+		case checkpoint == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "No checkpoint is issued yet")
+		default:
+			return api.Response{
+				Status: http.StatusOK,
+				Body: checkpointResponse{
+					Height:    checkpoint.Height,
+					Hash:      hex.EncodeToString(checkpoint.Hash),
+					UTXORoot:  hex.EncodeToString(checkpoint.UTXORoot),
+					Timestamp: checkpoint.Timestamp,
+					Signature: hex.EncodeToString(checkpoint.Signature),
+				},
+			}, nil
+		}
+	}
+}
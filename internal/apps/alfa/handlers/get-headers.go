@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type getHeadersPayload struct {
+	From  int `json:"from"`
+	Count int `json:"count"`
+}
+
+type getHeadersResponse struct {
+	Headers []blockchain.Header `json:"headers"`
+}
+
+func GetHeaders(getHeaders blockchain.GetHeadersFn, log logger.Logger) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		var payload getHeadersPayload
+		if err := json.Unmarshal(ping.Body, &payload); err != nil || payload.From < 1 || payload.Count < 1 {
+			return nil, failure.Newf(failure.InvalidData, "Invalid values passed for %s operation", websocket.GetHeadersMessage)
+		}
+		if payload.Count > blockchain.MaxHeadersPerRequest {
+			payload.Count = blockchain.MaxHeadersPerRequest
+		}
+		headers, err := getHeaders(payload.From, payload.Count)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve headers from height %d", payload.From)
+		}
+		log.Debug("Sending headers", logger.F("from", payload.From), logger.F("headers", len(headers)))
+		return websocket.NewResponsePong(getHeadersResponse{Headers: headers}), nil
+	}
+}
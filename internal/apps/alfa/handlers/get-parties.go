@@ -5,26 +5,51 @@ import (
 	"sort"
 
 	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
 	"github.com/nebser/crypto-vote/internal/pkg/party"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
-func GetParties(getParties party.GetPartiesFn, getUTXOsByPublicKey transaction.GetUTXOsByPublicKeyFn) api.Handler {
+func GetParties(
+	getParties party.GetPartiesFn,
+	getUTXOsByPublicKey transaction.GetUTXOsByPublicKeyFn,
+	getElectionKey election.GetElectionKeyFn,
+	getEncryptedTally election.GetEncryptedTallyFn,
+	getTally election.GetTallyFn,
+) api.Handler {
 	return func(request api.Request) (api.Response, error) {
 		parties, err := getParties()
 		if err != nil {
 			return api.Response{}, errors.Wrapf(err, "Failed to retrieve parties %s", err)
 		}
+		electionKey, err := getElectionKey()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election key")
+		}
+		var enrich func(party.Party) (party.Party, error)
+		if electionKey == nil {
+			enrich = func(p party.Party) (party.Party, error) {
+				utxos, err := getUTXOsByPublicKey(wallet.ExtractPublicKeyHash(p.Address))
+				if err != nil {
+					return party.Party{}, errors.Wrapf(err, "Failed to enrich party with balance %#v", p)
+				}
+				p.Balance = utxos.Sum()
+				return p, nil
+			}
+		} else {
+			enrich, err = encryptedEnricher(getEncryptedTally, getTally)
+			if err != nil {
+				return api.Response{}, err
+			}
+		}
 		result := make(party.Parties, 0, cap(parties))
 		for _, p := range parties {
-			utxos, err := getUTXOsByPublicKey(wallet.ExtractPublicKeyHash(p.Address))
+			enriched, err := enrich(p)
 			if err != nil {
-				return api.Response{}, errors.Wrapf(err, "Failed to enrich party with balance %#v", p)
+				return api.Response{}, err
 			}
-			enriched := p
-			enriched.Balance = utxos.Sum()
 			result = append(result, enriched)
 		}
 		sort.Sort(sort.Reverse(result))
@@ -34,3 +59,26 @@ func GetParties(getParties party.GetPartiesFn, getUTXOsByPublicKey transaction.G
 		}, nil
 	}
 }
+
+func encryptedEnricher(getEncryptedTally election.GetEncryptedTallyFn, getTally election.GetTallyFn) (func(party.Party) (party.Party, error), error) {
+	tally, err := getTally()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve tally")
+	}
+	if tally != nil {
+		return func(p party.Party) (party.Party, error) {
+			p.Balance = tally.Votes[p.Address] * transaction.VoteValue
+			return p, nil
+		}, nil
+	}
+	encrypted, err := getEncryptedTally()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to retrieve encrypted tally")
+	}
+	return func(p party.Party) (party.Party, error) {
+		if c, ok := encrypted.Ciphertexts[p.Address]; ok {
+			p.EncryptedVotes = &c
+		}
+		return p, nil
+	}, nil
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func GetRevocations(getRevocations transaction.GetRevocationsFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		revocations, err := getRevocations()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve revocations")
+		}
+		result := make([]revocationResponse, 0, len(revocations))
+		for _, r := range revocations {
+			result = append(result, newRevocationResponse(r))
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   result,
+		}, nil
+	}
+}
+
+func GetRevocation(getRevocation transaction.GetRevocationFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		address := request.Params["address"]
+		publicKeyHash, err := wallet.ParseAddress(address)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid address provided")
+		}
+		r, err := getRevocation(publicKeyHash)
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve revocation of %s", address)
+		case r == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Address %s is not revoked", address)
+		default:
+			return api.Response{
+				Status: http.StatusOK,
+				Body:   newRevocationResponse(*r),
+			}, nil
+		}
+	}
+}
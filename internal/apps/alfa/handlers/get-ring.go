@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func GetRing(getRing transaction.GetRingFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		switch ring, err := getRing(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve voter ring")
+		case len(ring) == 0:
+			return api.Response{}, failure.Newf(failure.NotFound, "Election does not accept ring votes")
+		default:
+			return api.Response{
+				Status: http.StatusOK,
+				Body:   ring,
+			}, nil
+		}
+	}
+}
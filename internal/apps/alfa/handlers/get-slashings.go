@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type slashingResponse struct {
+	Forger             string          `json:"forger"`
+	Offence            string          `json:"offence"`
+	Height             int             `json:"height"`
+	BlockHash          []byte          `json:"blockHash"`
+	StakeTransactionID []byte          `json:"stakeTransactionId"`
+	Reason             string          `json:"reason"`
+	Evidence           json.RawMessage `json:"evidence"`
+	TransactionID      []byte          `json:"transactionId"`
+	Timestamp          int64           `json:"timestamp"`
+}
+
+func newSlashingResponse(s transaction.Slashing) slashingResponse {
+	return slashingResponse{
+		Forger:             wallet.AddressFromPublicKeyHash(s.Forger),
+		Offence:            string(s.Offence),
+		Height:             s.Height,
+		BlockHash:          s.BlockHash,
+		StakeTransactionID: s.StakeTransactionID,
+		Reason:             s.Reason,
+		Evidence:           s.Evidence,
+		TransactionID:      s.TransactionID,
+		Timestamp:          s.Timestamp,
+	}
+}
+
+func GetSlashings(getSlashings transaction.GetSlashingsFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		slashings, err := getSlashings()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve slashings")
+		}
+		result := make([]slashingResponse, 0, len(slashings))
+		for _, s := range slashings {
+			result = append(result, newSlashingResponse(s))
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   result,
+		}, nil
+	}
+}
+
+func GetSlashing(getSlashing transaction.GetSlashingFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		address := request.Params["address"]
+		publicKeyHash, err := wallet.ParseAddress(address)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid address provided")
+		}
+		s, err := getSlashing(publicKeyHash)
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve slashing of %s", address)
+		case s == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Party %s is not slashed", address)
+		default:
+			return api.Response{
+				Status: http.StatusOK,
+				Body:   newSlashingResponse(*s),
+			}, nil
+		}
+	}
+}
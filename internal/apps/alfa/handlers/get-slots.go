@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type slotForgerResponse struct {
+	NodeID  string `json:"nodeId"`
+	Address string `json:"address"`
+}
+
+type slotResponse struct {
+	Slot       int                `json:"slot"`
+	Epoch      int                `json:"epoch"`
+	Height     int                `json:"height"`
+	Designated slotForgerResponse `json:"designated"`
+	Backup     slotForgerResponse `json:"backup"`
+	Deadline   time.Time          `json:"deadline"`
+	End        time.Time          `json:"end"`
+	Escalated  bool               `json:"escalated"`
+	Filled     bool               `json:"filled"`
+}
+
+type missedSlotResponse struct {
+	Slot    int       `json:"slot"`
+	Epoch   int       `json:"epoch"`
+	Height  int       `json:"height"`
+	NodeID  string    `json:"nodeId"`
+	Address string    `json:"address"`
+	Role    string    `json:"role"`
+	Time    time.Time `json:"time"`
+}
+
+func senderAddress(sender string) (string, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(sender)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to decode sender %s", sender)
+	}
+	hashed, err := wallet.HashedPublicKey(publicKey)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to hash public key of sender %s", sender)
+	}
+	return wallet.AddressFromPublicKeyHash(hashed), nil
+}
+
+func newSlotForgerResponse(forger websocket.Forger) (slotForgerResponse, error) {
+	address, err := senderAddress(forger.Sender)
+	if err != nil {
+		return slotForgerResponse{}, err
+	}
+	return slotForgerResponse{NodeID: forger.NodeID, Address: address}, nil
+}
+
+func GetCurrentSlot(current blockchain.CurrentSlotFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		assignment := current()
+		if assignment == nil {
+			return api.Response{}, failure.Newf(failure.NotFound, "No slot is assigned yet")
+		}
+		designated, err := newSlotForgerResponse(assignment.Designated)
+		if err != nil {
+			return api.Response{}, err
+		}
+		backup, err := newSlotForgerResponse(assignment.Backup)
+		if err != nil {
+			return api.Response{}, err
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body: slotResponse{
+				Slot:       assignment.Slot,
+				Epoch:      assignment.Epoch,
+				Height:     assignment.Height,
+				Designated: designated,
+				Backup:     backup,
+				Deadline:   assignment.Deadline.UTC(),
+				End:        assignment.End.UTC(),
+				Escalated:  assignment.Escalated,
+				Filled:     assignment.Filled,
+			},
+		}, nil
+	}
+}
+
+func GetMissedSlots(getMissedSlots blockchain.GetMissedSlotsFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		missed, err := getMissedSlots()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve missed slots")
+		}
+		result := make([]missedSlotResponse, 0, len(missed))
+		for _, m := range missed {
+			address, err := senderAddress(m.Sender)
+			if err != nil {
+				return api.Response{}, err
+			}
+			result = append(result, missedSlotResponse{
+				Slot:    m.Slot,
+				Epoch:   m.Epoch,
+				Height:  m.Height,
+				NodeID:  m.NodeID,
+				Address: address,
+				Role:    string(m.Role),
+				Time:    m.Time,
+			})
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   result,
+		}, nil
+	}
+}
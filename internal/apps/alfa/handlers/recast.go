@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func GetRecast(getSchedule transaction.GetRecastScheduleFn, nextRecastSequence transaction.NextRecastSequenceFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		switch schedule, err := getSchedule(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve recast schedule")
+		case schedule == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Election does not accept recastable votes")
+		}
+		address := request.Params["address"]
+		publicKeyHash, err := wallet.ParseAddress(address)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid address provided")
+		}
+		sequence, err := nextRecastSequence(publicKeyHash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve recast sequence of %s", address)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   api.RecastSequence{Sequence: sequence},
+		}, nil
+	}
+}
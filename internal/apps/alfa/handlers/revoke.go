@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type revokeBody struct {
+	Revoked     string `json:"revoked"`
+	Replacement string `json:"replacement"`
+	Verifier    string `json:"verifier"`
+	Signature   string `json:"signature"`
+}
+
+func (r revokeBody) Signable() ([]byte, error) {
+	data := struct {
+		Revoked     string `json:"revoked"`
+		Replacement string `json:"replacement"`
+	}{
+		Revoked:     r.Revoked,
+		Replacement: r.Replacement,
+	}
+	return json.Marshal(data)
+}
+
+type revocationResponse struct {
+	Revoked       string `json:"revoked"`
+	Replacement   string `json:"replacement"`
+	TransactionID []byte `json:"transactionId"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+func newRevocationResponse(r transaction.Revocation) revocationResponse {
+	return revocationResponse{
+		Revoked:       wallet.AddressFromPublicKeyHash(r.Revoked),
+		Replacement:   wallet.AddressFromPublicKeyHash(r.Replacement),
+		TransactionID: r.TransactionID,
+		Timestamp:     r.Timestamp,
+	}
+}
+
+func Revoke(authorityKey []byte, revoke transaction.RevokeFn, broadcast websocket.BroadcastFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		var body revokeBody
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			return api.Response{}, failure.New(failure.InvalidData)
+		}
+		rawPublicKey, err := base64.StdEncoding.DecodeString(body.Verifier)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid public key provided")
+		}
+		rawSignature, err := base64.StdEncoding.DecodeString(body.Signature)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid signature provided")
+		}
+		if bytes.Compare(rawPublicKey, authorityKey) != 0 {
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Only the authority can revoke voters")
+		}
+		if !wallet.Verify(body, rawSignature, rawPublicKey) {
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Signature does not match the payload")
+		}
+		revoked, err := wallet.ParseAddress(body.Revoked)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid revoked address provided")
+		}
+		replacement, err := wallet.ParseAddress(body.Replacement)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid replacement address provided")
+		}
+		if bytes.Compare(revoked, replacement) == 0 {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Replacement address must differ from the revoked one")
+		}
+		tr, err := revoke(revoked, replacement)
+		switch {
+		case errors.Is(err, transaction.ErrVoterRevoked):
+			return api.Response{}, failure.New(failure.VoterRevoked)
+		case errors.Is(err, transaction.ErrBallotSpent):
+			return api.Response{}, failure.New(failure.BallotSpent)
+		case errors.Is(err, transaction.ErrInvalidReplacement):
+			return api.Response{}, failure.New(failure.InvalidReplacement)
+		case errors.Is(err, transaction.ErrAmbiguousBallot):
+			return api.Response{}, failure.New(failure.AmbiguousBallot)
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to revoke %s", body.Revoked)
+		}
+		broadcast(websocket.Pong{
+			Message: websocket.TransactionReceivedMessage,
+			Body: websocket.SaveTransactionBody{
+				Transaction: tr,
+			},
+		})
+		r, _ := tr.Revocation()
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   newRevocationResponse(r),
+		}, nil
+	}
+}
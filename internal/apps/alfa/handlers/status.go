@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+func ChainCheck(getHeight blockchain.GetHeightFn) api.CheckFn {
+	return func() error {
+		switch height, err := getHeight(); {
+		case err != nil:
+			return errors.Wrap(err, "Failed to retrieve blockchain height")
+		case height == 0:
+			return errors.New("Blockchain is empty")
+		}
+		return nil
+	}
+}
+
+func GetStatus(
+	getTip blockchain.GetTipFn,
+	getBlock blockchain.GetBlockFn,
+	getHeight blockchain.GetHeightFn,
+	peers websocket.PeersFn,
+	lastForge blockchain.LastForgeFn,
+	getUTXOs transaction.GetUTXOsByPublicKeyFn,
+	publicKeyHash []byte,
+	getPhase election.GetPhaseFn,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		status := api.Status{
+			Node:      "alfa",
+			Peers:     peers(),
+			LastForge: lastForge(),
+		}
+		switch head, err := newHead(getTip, getBlock, getHeight); {
+		case err != nil:
+			return api.Response{}, err
+		case head != nil:
+			status.Tip = *head
+		}
+		utxos, err := getUTXOs(publicKeyHash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve utxos of %x", publicKeyHash)
+		}
+		status.Stake = api.Stake{
+			Address: wallet.AddressFromPublicKeyHash(publicKeyHash),
+			Balance: utxos.Sum(),
+		}
+		phase, err := getPhase()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election phase")
+		}
+		status.Phase = &phase
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   status,
+		}, nil
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/pkg/errors"
+)
+
+type decryptionResponse struct {
+	Received  int  `json:"received"`
+	Threshold int  `json:"threshold"`
+	Published bool `json:"published"`
+}
+
+func GetElection(schedule election.Schedule, getElectionKey election.GetElectionKeyFn, getPhase election.GetPhaseFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		electionKey, err := getElectionKey()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election key")
+		}
+		phase, err := getPhase()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election phase")
+		}
+		response := api.Election{
+			Phase:       phase,
+			Encrypted:   electionKey != nil,
+			ElectionKey: electionKey,
+		}
+		if !schedule.Closes.IsZero() {
+			response.Closes = schedule.Closes.Format(time.RFC3339)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   response,
+		}, nil
+	}
+}
+
+func GetEncryptedTally(getElectionKey election.GetElectionKeyFn, getEncryptedTally election.GetEncryptedTallyFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		switch electionKey, err := getElectionKey(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election key")
+		case electionKey == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Election does not use encrypted ballots")
+		}
+		encrypted, err := getEncryptedTally()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve encrypted tally")
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   encrypted,
+		}, nil
+	}
+}
+
+func GetTally(getTally election.GetTallyFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		switch tally, err := getTally(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve tally")
+		case tally == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Tally is not published yet")
+		default:
+			return api.Response{
+				Status: http.StatusOK,
+				Body:   tally,
+			}, nil
+		}
+	}
+}
+
+func SubmitDecryption(
+	getElectionKey election.GetElectionKeyFn,
+	isClosed election.IsClosedFn,
+	hasPendingBallots election.HasPendingBallotsFn,
+	getEncryptedTally election.GetEncryptedTallyFn,
+	getTally election.GetTallyFn,
+	saveDecryption election.SaveDecryptionFn,
+	saveTally election.SaveTallyFn,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		var body election.TrusteeDecryption
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			return api.Response{}, failure.New(failure.InvalidData)
+		}
+		electionKey, err := getElectionKey()
+		switch {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election key")
+		case electionKey == nil:
+			return api.Response{}, failure.Newf(failure.NotFound, "Election does not use encrypted ballots")
+		case !isClosed():
+			return api.Response{}, failure.New(failure.ElectionOpen)
+		}
+		if _, ok := electionKey.Trustee(body.Trustee); !ok {
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Trustee %d does not exist", body.Trustee)
+		}
+		switch pending, err := hasPendingBallots(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to check pending ballots")
+		case pending:
+			return api.Response{}, failure.New(failure.BallotsPending)
+		}
+		switch tally, err := getTally(); {
+		case err != nil:
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve tally")
+		case tally != nil:
+			return api.Response{}, failure.New(failure.TallyPublished)
+		}
+		encrypted, err := getEncryptedTally()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve encrypted tally")
+		}
+		if !body.Verify(*electionKey, encrypted) {
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Partial decryptions do not match the encrypted tally")
+		}
+		decryptions, err := saveDecryption(body)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to save decryption of trustee %d", body.Trustee)
+		}
+		response := decryptionResponse{
+			Received:  len(decryptions),
+			Threshold: electionKey.Threshold,
+		}
+		if len(decryptions) >= electionKey.Threshold {
+			tally, err := election.Decrypt(*electionKey, encrypted, decryptions)
+			if err != nil {
+				return api.Response{}, errors.Wrap(err, "Failed to decrypt tally")
+			}
+			if err := saveTally(*tally); err != nil {
+				return api.Response{}, errors.Wrap(err, "Failed to save tally")
+			}
+			response.Published = true
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   response,
+		}, nil
+	}
+}
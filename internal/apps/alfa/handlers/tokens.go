@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+func authenticateVoter(sender, verifier, signature string, data wallet.Signable) ([]byte, []byte, error) {
+	rawPublicKey, err := base64.StdEncoding.DecodeString(verifier)
+	if err != nil {
+		return nil, nil, failure.Newf(failure.InvalidData, "Invalid public key provided")
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, nil, failure.Newf(failure.InvalidData, "Invalid signature provided")
+	}
+	if !wallet.Verify(data, rawSignature, rawPublicKey) {
+		return nil, nil, failure.Newf(failure.Unauthorized, "Signature does not match the payload")
+	}
+	rawSender, err := base64.StdEncoding.DecodeString(sender)
+	if err != nil {
+		return nil, nil, failure.Newf(failure.InvalidData, "Invalid sender provided")
+	}
+	hashedPublicKey, err := wallet.HashedPublicKey(rawPublicKey)
+	if err != nil || bytes.Compare(hashedPublicKey, rawSender) != 0 {
+		return nil, nil, failure.Newf(failure.Unauthorized, "Public key does not belong to the sender")
+	}
+	return rawSender, rawPublicKey, nil
+}
+
+func TokenCommitment(newTokenCommitment transaction.NewTokenCommitmentFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		var body api.TokenCommitmentRequest
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			return api.Response{}, failure.New(failure.InvalidData)
+		}
+		sender, _, err := authenticateVoter(body.Sender, body.Verifier, body.Signature, body)
+		if err != nil {
+			return api.Response{}, err
+		}
+		commitment, err := newTokenCommitment(sender)
+		switch {
+		case errors.Is(err, transaction.ErrInsufficientVotes):
+			return api.Response{}, failure.New(failure.UserAlreadyVoted)
+		case errors.Is(err, transaction.ErrVoterRevoked):
+			return api.Response{}, failure.New(failure.VoterRevoked)
+		case errors.Is(err, transaction.ErrTokenSessionOpen):
+			return api.Response{}, failure.New(failure.TokenSessionOpen)
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to create token commitment for %s", body.Sender)
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   api.TokenCommitmentResponse{Commitment: commitment},
+		}, nil
+	}
+}
+
+func IssueToken(issueBallotToken transaction.IssueBallotTokenFn, broadcast websocket.BroadcastFn) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		var body api.IssueTokenRequest
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			return api.Response{}, failure.New(failure.InvalidData)
+		}
+		sender, publicKey, err := authenticateVoter(body.Sender, body.Verifier, body.Signature, body)
+		if err != nil {
+			return api.Response{}, err
+		}
+		challenge, err := base64.StdEncoding.DecodeString(body.Challenge)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid challenge provided")
+		}
+		transferSignature, err := base64.StdEncoding.DecodeString(body.TransferSignature)
+		if err != nil {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid transfer signature provided")
+		}
+		transfer := api.Transfer{
+			Sender:    body.Sender,
+			Recipient: base64.StdEncoding.EncodeToString(transaction.BallotPoolHash()),
+			Value:     transaction.VoteValue,
+		}
+		if !wallet.Verify(transfer, transferSignature, publicKey) {
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Transfer signature does not match the ballot pool transfer")
+		}
+		tr, blindSignature, err := issueBallotToken(sender, transferSignature, publicKey, challenge)
+		switch {
+		case errors.Is(err, transaction.ErrNoTokenSession):
+			return api.Response{}, failure.New(failure.TokenNotRequested)
+		case errors.Is(err, transaction.ErrInsufficientVotes):
+			return api.Response{}, failure.New(failure.UserAlreadyVoted)
+		case errors.Is(err, transaction.ErrVoterRevoked):
+			return api.Response{}, failure.New(failure.VoterRevoked)
+		case err != nil:
+			return api.Response{}, errors.Wrapf(err, "Failed to issue ballot token for %s", body.Sender)
+		}
+		broadcast(websocket.Pong{
+			Message: websocket.TransactionReceivedMessage,
+			Body: websocket.SaveTransactionBody{
+				Transaction: tr,
+			},
+		})
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   api.IssueTokenResponse{BlindSignature: blindSignature},
+		}, nil
+	}
+}
@@ -1,98 +1,354 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"fmt"
-	"log"
 	"net/http"
 
 	"github.com/nebser/crypto-vote/internal/pkg/api"
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/party"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
 )
 
-type voteBody struct {
-	Sender    string `json:"sender"`
-	Recipient string `json:"recipient"`
-	Verifier  string `json:"verifier"`
-	Signature string `json:"signature"`
-}
-
-func (v voteBody) Signable() ([]byte, error) {
-	data := struct {
-		Sender    string `json:"sender"`
-		Recipient string `json:"recipient"`
-		Value     int    `json:"value"`
-	}{
-		Sender:    v.Sender,
-		Recipient: v.Recipient,
-		Value:     transaction.VoteValue,
-	}
-	return json.Marshal(data)
-}
-
-func Vote(findBlock blockchain.FindBlockFn, castVote transaction.CastVote, broadcast websocket.BroadcastFn) api.Handler {
+func Vote(
+	isEligible blockchain.IsEligibleFn,
+	castVote transaction.CastVote,
+	castAnonymousVote transaction.CastAnonymousVoteFn,
+	castEncryptedVote transaction.CastEncryptedVoteFn,
+	castRingVote transaction.CastRingVoteFn,
+	castRecastVote transaction.CastRecastVoteFn,
+	getRing transaction.GetRingFn,
+	getRecastSchedule transaction.GetRecastScheduleFn,
+	getElectionKey election.GetElectionKeyFn,
+	getParties party.GetPartiesFn,
+	isClosed election.IsClosedFn,
+	authorityKey []byte,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) api.Handler {
 	return func(request api.Request) (api.Response, error) {
-		var body voteBody
+		var body api.Vote
 		if err := json.Unmarshal(request.Body, &body); err != nil {
-			return api.InvalidDataErrorResponse(""), nil
+			return api.Response{}, failure.New(failure.InvalidData)
+		}
+		if err := checkRecipient(getParties, body.Recipient); err != nil {
+			return api.Response{}, err
+		}
+		if body.Ring != nil {
+			if isClosed() {
+				return api.Response{}, failure.New(failure.ElectionClosed)
+			}
+			return voteWithRing(body, getRing, castRingVote, broadcast, log)
 		}
 		rawPublicKey, err := base64.StdEncoding.DecodeString(body.Verifier)
 		if err != nil {
-			return api.InvalidDataErrorResponse("Invalid public key provided"), nil
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid public key provided")
 		}
 		rawSignature, err := base64.StdEncoding.DecodeString(body.Signature)
 		if err != nil {
-			return api.InvalidDataErrorResponse("Invalid signature provided"), nil
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid signature provided")
 		}
 		if !wallet.Verify(body, rawSignature, rawPublicKey) {
-			return api.UnauthorizedErrorResponse("Signature does not match the payload"), nil
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Signature does not match the payload")
 		}
 		sender, err := base64.StdEncoding.DecodeString(body.Sender)
 		if err != nil {
-			return api.InvalidDataErrorResponse("Invalid sender provided"), nil
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid sender provided")
 		}
 		receiver, err := base64.StdEncoding.DecodeString(body.Recipient)
 		if err != nil {
-			return api.InvalidDataErrorResponse("Invalid recipient provided"), nil
+			return api.Response{}, failure.Newf(failure.InvalidData, "Invalid recipient provided")
 		}
-
-		criteria := func(b blockchain.Block) bool {
-			if _, ok := b.Body.Transactions.FindTransactionTo(sender); ok {
-				return true
-			}
-			return false
+		if isClosed() {
+			return api.Response{}, failure.New(failure.ElectionClosed)
+		}
+		electionKey, err := getElectionKey()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve election key")
+		}
+		switch {
+		case electionKey != nil && (body.Ballot == "" || body.Token != ""):
+			return api.Response{}, failure.Newf(failure.InvalidData, "Election accepts only encrypted ballots")
+		case electionKey == nil && body.Ballot != "":
+			return api.Response{}, failure.Newf(failure.InvalidData, "Election does not accept encrypted ballots")
+		}
+		recastSchedule, err := getRecastSchedule()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve recast schedule")
 		}
-		switch _, ok, err := findBlock(criteria); {
+		switch {
+		case recastSchedule != nil && (body.Party == "" || body.Token != ""):
+			return api.Response{}, failure.Newf(failure.InvalidData, "Election accepts only recastable votes")
+		case recastSchedule == nil && body.Party != "":
+			return api.Response{}, failure.Newf(failure.InvalidData, "Election does not accept recastable votes")
+		case body.Token != "":
+			return voteAnonymously(body, receiver, rawSignature, rawPublicKey, castAnonymousVote, authorityKey, broadcast, log)
+		}
+
+		switch ok, err := isEligible(sender); {
 		case err != nil:
-			return api.Response{}, errors.Errorf("Failed to find block. Error: %s", err)
+			return api.Response{}, errors.Errorf("Failed to check eligibility. Error: %s", err)
 		case !ok:
-			return api.UnauthorizedErrorResponse(fmt.Sprintf("Recipient %s does not exist", body.Recipient)), nil
-		default:
-			log.Println("Authorized successfully")
+			return api.Response{}, failure.Newf(failure.Unauthorized, "Recipient %s does not exist", body.Recipient)
+		}
+		if recastSchedule != nil {
+			return voteRecast(body, receiver, rawSignature, rawPublicKey, getParties, castRecastVote, broadcast, log)
+		}
+		if electionKey != nil {
+			return voteEncrypted(body, sender, receiver, rawSignature, rawPublicKey, *electionKey, getParties, castEncryptedVote, broadcast, log)
 		}
 		tr, err := castVote(sender, receiver, rawSignature, rawPublicKey)
 		switch {
 		case err != nil && errors.Is(err, transaction.ErrInsufficientVotes):
-			return api.UserAlreadyVoted(), nil
+			return api.Response{}, failure.New(failure.UserAlreadyVoted)
+		case err != nil && errors.Is(err, transaction.ErrVoterRevoked):
+			return api.Response{}, failure.New(failure.VoterRevoked)
 		case err != nil:
-			log.Printf("Error occurred while voting %s", err)
-			return api.Response{}, nil
+			return api.Response{}, errors.Wrap(err, "Failed to cast vote")
 		}
-		log.Println("VOTED SUCCESSFULLY")
-		broadcast(websocket.Pong{
+		receivers := broadcast(websocket.Pong{
 			Message: websocket.TransactionReceivedMessage,
 			Body: websocket.SaveTransactionBody{
 				Transaction: tr,
 			},
 		})
-		log.Println("BROADCASTED SUCCESSFULLY")
+		log.Info("Vote accepted", logger.TxID(tr.ID), logger.F("receivers", receivers))
 		return api.Response{
 			Status: http.StatusOK,
 		}, nil
 	}
 }
+
+func checkRecipient(getParties party.GetPartiesFn, recipient string) error {
+	receiver, err := base64.StdEncoding.DecodeString(recipient)
+	if err != nil || len(receiver) == 0 {
+		return nil
+	}
+	parties, err := getParties()
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve parties")
+	}
+	if p, ok := parties.Find(wallet.AddressFromPublicKeyHash(receiver)); ok && !p.Active {
+		return failure.New(failure.PartyInactive)
+	}
+	return nil
+}
+
+func VoteSender(request api.Request) string {
+	var body api.Vote
+	if err := json.Unmarshal(request.Body, &body); err != nil {
+		return ""
+	}
+	return body.Voter()
+}
+
+func voteAnonymously(
+	body api.Vote,
+	receiver, signature, oneTimeKey []byte,
+	castAnonymousVote transaction.CastAnonymousVoteFn,
+	authorityKey []byte,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) (api.Response, error) {
+	if body.Sender != base64.StdEncoding.EncodeToString(transaction.BallotPoolHash()) {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Anonymous votes must be sent from the ballot pool")
+	}
+	token, err := base64.StdEncoding.DecodeString(body.Token)
+	if err != nil {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Invalid token provided")
+	}
+	if !wallet.VerifyBlindSignature(oneTimeKey, token, authorityKey) {
+		return api.Response{}, failure.Newf(failure.Unauthorized, "Token is not issued by the authority")
+	}
+	tr, err := castAnonymousVote(receiver, signature, oneTimeKey, token)
+	switch {
+	case errors.Is(err, transaction.ErrTokenSpent):
+		return api.Response{}, failure.New(failure.TokenAlreadyUsed)
+	case errors.Is(err, transaction.ErrBallotPoolEmpty):
+		return api.Response{}, failure.New(failure.BallotPoolEmpty)
+	case err != nil:
+		return api.Response{}, errors.Wrap(err, "Failed to cast anonymous vote")
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Info("Vote accepted", logger.TxID(tr.ID), logger.F("receivers", receivers))
+	return api.Response{
+		Status: http.StatusOK,
+	}, nil
+}
+
+func voteEncrypted(
+	body api.Vote,
+	sender, receiver, signature, verifier []byte,
+	electionKey elgamal.ElectionKey,
+	getParties party.GetPartiesFn,
+	castEncryptedVote transaction.CastEncryptedVoteFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) (api.Response, error) {
+	if bytes.Compare(receiver, transaction.BallotBoxHash()) != 0 {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Encrypted ballots must be sent to the ballot box")
+	}
+	rawBallot, err := base64.StdEncoding.DecodeString(body.Ballot)
+	if err != nil {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Invalid ballot provided")
+	}
+	ballot, err := elgamal.ParseBallot(rawBallot)
+	if err != nil {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Invalid ballot provided")
+	}
+	if !ballot.Verify(electionKey, sender) {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Encrypted ballot proofs are not valid")
+	}
+	parties, err := getParties()
+	if err != nil {
+		return api.Response{}, errors.Wrap(err, "Failed to retrieve parties")
+	}
+	registered := map[string]bool{}
+	for _, p := range parties.Active() {
+		registered[p.Address] = true
+	}
+	choices := ballot.Parties()
+	if len(choices) != len(registered) {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Ballot must contain a choice for every party")
+	}
+	for _, choice := range choices {
+		if !registered[choice] {
+			return api.Response{}, failure.Newf(failure.InvalidData, "Party %s does not exist", choice)
+		}
+	}
+	tr, err := castEncryptedVote(sender, signature, verifier, rawBallot)
+	switch {
+	case errors.Is(err, transaction.ErrInsufficientVotes):
+		return api.Response{}, failure.New(failure.UserAlreadyVoted)
+	case errors.Is(err, transaction.ErrVoterRevoked):
+		return api.Response{}, failure.New(failure.VoterRevoked)
+	case err != nil:
+		return api.Response{}, errors.Wrap(err, "Failed to cast encrypted vote")
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Info("Vote accepted", logger.TxID(tr.ID), logger.F("receivers", receivers))
+	return api.Response{
+		Status: http.StatusOK,
+	}, nil
+}
+
+func voteWithRing(
+	body api.Vote,
+	getRing transaction.GetRingFn,
+	castRingVote transaction.CastRingVoteFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) (api.Response, error) {
+	if body.Sender != base64.StdEncoding.EncodeToString(transaction.RingPoolHash()) {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Ring votes must be sent from the ring ballot pool")
+	}
+	receiver, err := base64.StdEncoding.DecodeString(body.Recipient)
+	if err != nil {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Invalid recipient provided")
+	}
+	ring, err := getRing()
+	switch {
+	case err != nil:
+		return api.Response{}, errors.Wrap(err, "Failed to retrieve voter ring")
+	case len(ring) == 0:
+		return api.Response{}, failure.Newf(failure.InvalidData, "Election does not accept ring votes")
+	}
+	message, err := transaction.RingVoteMessage(receiver)
+	if err != nil {
+		return api.Response{}, errors.Wrap(err, "Failed to create ring vote message")
+	}
+	if !wallet.VerifyRing(message, ring, *body.Ring) {
+		return api.Response{}, failure.Newf(failure.Unauthorized, "Ring signature does not match the payload")
+	}
+	tr, err := castRingVote(receiver, *body.Ring)
+	switch {
+	case errors.Is(err, transaction.ErrKeyImageUsed):
+		return api.Response{}, failure.New(failure.UserAlreadyVoted)
+	case errors.Is(err, transaction.ErrBallotPoolEmpty):
+		return api.Response{}, failure.New(failure.BallotPoolEmpty)
+	case err != nil:
+		return api.Response{}, errors.Wrap(err, "Failed to cast ring vote")
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Info("Vote accepted", logger.TxID(tr.ID), logger.F("receivers", receivers))
+	return api.Response{
+		Status: http.StatusOK,
+	}, nil
+}
+
+func voteRecast(
+	body api.Vote,
+	receiver, signature, verifier []byte,
+	getParties party.GetPartiesFn,
+	castRecastVote transaction.CastRecastVoteFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) (api.Response, error) {
+	if bytes.Compare(receiver, transaction.RecastEscrowHash()) != 0 {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Recastable votes must be sent to the recast escrow")
+	}
+	recast, err := body.Recast()
+	if err != nil {
+		return api.Response{}, failure.Newf(failure.InvalidData, "Invalid party provided")
+	}
+	parties, err := getParties()
+	if err != nil {
+		return api.Response{}, errors.Wrap(err, "Failed to retrieve parties")
+	}
+	address := wallet.AddressFromPublicKeyHash(recast.Party)
+	switch p, ok := parties.Find(address); {
+	case !ok:
+		return api.Response{}, failure.Newf(failure.InvalidData, "Party %s does not exist", address)
+	case !p.Active:
+		return api.Response{}, failure.New(failure.PartyInactive)
+	}
+	tr, err := castRecastVote(*recast, signature, verifier)
+	switch {
+	case errors.Is(err, transaction.ErrInsufficientVotes):
+		return api.Response{}, failure.New(failure.UserAlreadyVoted)
+	case errors.Is(err, transaction.ErrVoterRevoked):
+		return api.Response{}, failure.New(failure.VoterRevoked)
+	case errors.Is(err, transaction.ErrRecastPending):
+		return api.Response{}, failure.New(failure.RecastPending)
+	case errors.Is(err, transaction.ErrInvalidRecastSequence):
+		return api.Response{}, failure.New(failure.InvalidRecastSequence)
+	case errors.Is(err, election.ErrElectionClosed):
+		return api.Response{}, failure.New(failure.ElectionClosed)
+	case err != nil:
+		return api.Response{}, errors.Wrap(err, "Failed to cast recast vote")
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.TransactionReceivedMessage,
+		Body: websocket.SaveTransactionBody{
+			Transaction: tr,
+		},
+	})
+	log.Info("Vote accepted", logger.TxID(tr.ID), logger.F("receivers", receivers))
+	return api.Response{
+		Status: http.StatusOK,
+	}, nil
+}
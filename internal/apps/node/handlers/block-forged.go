@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"log"
 
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
-	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
 )
@@ -16,11 +18,24 @@ type blockForgedBody struct {
 	Block  blockchain.Block `json:"block"`
 }
 
-func BlockForged(getTip blockchain.GetTipFn, getBlock blockchain.GetBlockFn, verifyBlock blockchain.VerifyBlockFn, isReturnStakeBlock blockchain.IsReturnStakeBlockFn, addNewBlock blockchain.AddNewBlockFn) websocket.Handler {
+func BlockForged(
+	getTip blockchain.GetTipFn,
+	getBlock blockchain.GetBlockFn,
+	getBlockHeight blockchain.GetBlockHeightFn,
+	verifyBlock blockchain.VerifyBlockFn,
+	isReturnStakeBlock blockchain.IsReturnStakeBlockFn,
+	verifyCheckpoint blockchain.VerifyBlockFn,
+	verifyProtocol blockchain.VerifyProtocolFn,
+	addNewBlock blockchain.AddNewBlockFn,
+	recordHeader blockchain.RecordHeaderFn,
+	saveEquivocation blockchain.SaveEquivocationFn,
+	broadcast websocket.BroadcastFn,
+	log logger.Logger,
+) websocket.Handler {
 	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
 		var body blockForgedBody
 		if err := json.Unmarshal(ping.Body, &body); err != nil {
-			return nil, errors.Wrapf(err, "Failed to unmarsha block forged body %s", ping.Body)
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal block forged body %s", ping.Body)
 		}
 		height, err := blockchain.GetHeight(getTip, getBlock)
 		if err != nil {
@@ -33,22 +48,61 @@ func BlockForged(getTip blockchain.GetTipFn, getBlock blockchain.GetBlockFn, ver
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to decode sender %s", ping.Sender)
 		}
-		hashedSender, err := wallet.HashedPublicKey(sender)
+		parent, err := getBlockHeight(body.Block.Header.Prev)
 		if err != nil {
-			return nil, errors.Wrap(err, "Failed to extract hashed public key")
+			return nil, errors.Wrapf(err, "Failed to retrieve height of block %x", body.Block.Header.Prev)
 		}
-		if !isReturnStakeBlock(body.Block, hashedSender) && !verifyBlock(body.Block, hashedSender) {
-			log.Println("Block is not verified 2")
+		log := log.With(logger.BlockHash(body.Block.Header.Hash), logger.F("height", body.Height), logger.F("forger", ping.Sender))
+		if ping.Verified() && parent > 0 && body.Block.Header.Verified() && bytes.Compare(body.Block.Header.Forger, sender) == 0 {
+			forger, err := body.Block.Header.ForgerHash()
+			if err != nil {
+				return nil, failure.Newf(failure.InvalidData, "%s", err)
+			}
+			evidence, err := json.Marshal(ping)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to serialize evidence of block %x", body.Block.Header.Hash)
+			}
+			proof, err := recordHeader(forger, parent+1, body.Block.Header.Hash, evidence)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Failed to record header of block %x", body.Block.Header.Hash)
+			}
+			if proof != nil {
+				log.Warn("Forger signed two different blocks at the same height")
+				metrics.Blocks.Inc(metrics.Rejected)
+				if err := gossipEquivocation(*proof, saveEquivocation, broadcast, log); err != nil {
+					return nil, err
+				}
+				return websocket.NewDisconnectPong(), nil
+			}
+		}
+		if !verifyCheckpoint(body.Block) {
+			log.Warn("Forged block reorganizes blockchain below the latest checkpoint")
+			metrics.Blocks.Inc(metrics.Rejected)
+			return nil, failure.Newf(failure.CheckpointConflict, "Block %x is not a descendant of the latest checkpoint", body.Block.Header.Hash)
+		}
+		switch err := verifyProtocol(body.Block); {
+		case errors.Is(err, blockchain.ErrProtocolMismatch):
+			log.Warn("Forged block doesn't follow the protocol rules", logger.Err(err))
+			metrics.Blocks.Inc(metrics.Rejected)
+			return nil, failure.Newf(failure.ProtocolMismatch, "%s", err)
+		case err != nil:
+			return nil, errors.Wrapf(err, "Failed to verify protocol of block %x", body.Block.Header.Hash)
+		}
+		if !isReturnStakeBlock(body.Block) && !verifyBlock(body.Block) {
+			log.Warn("Forged block is not verified")
+			metrics.Blocks.Inc(metrics.Rejected)
 			return websocket.NewDisconnectPong(), nil
 		}
 		switch err := addNewBlock(body.Block); {
 		case errors.Is(err, blockchain.ErrInvalidBlock):
-			log.Println("Block is invalid")
+			log.Warn("Forged block spends invalid outputs")
+			metrics.Blocks.Inc(metrics.Rejected)
 			return websocket.NewDisconnectPong(), nil
 		case err != nil:
 			return nil, errors.Wrap(err, "Failed to add new block to blockchain")
 		default:
-			log.Println("New block added")
+			log.Info("Forged block added")
+			metrics.Blocks.Inc(metrics.Accepted)
 			return websocket.NewNoActionPong(), nil
 		}
 	}
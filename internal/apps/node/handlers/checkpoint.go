@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+func Checkpoint(
+	alfaPublicKey []byte,
+	getBlockByHeight blockchain.GetBlockByHeightFn,
+	chainState blockchain.ChainStateFn,
+	saveCheckpoint blockchain.SaveCheckpointFn,
+	log logger.Logger,
+) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		var checkpoint blockchain.Checkpoint
+		if err := json.Unmarshal(ping.Body, &checkpoint); err != nil {
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal checkpoint %s", ping.Body)
+		}
+		if !checkpoint.Verified(alfaPublicKey) {
+			return nil, failure.Newf(failure.InvalidData, "Checkpoint is not signed by the alfa node")
+		}
+		log := log.With(logger.BlockHash(checkpoint.Hash), logger.F("height", checkpoint.Height))
+		block, err := getBlockByHeight(checkpoint.Height)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve block at height %d", checkpoint.Height)
+		}
+		if block != nil && bytes.Compare(block.Header.Hash, checkpoint.Hash) != 0 {
+			log.Error("Local blockchain conflicts with checkpoint", logger.F("local", block.Header.Hash))
+			return nil, failure.Newf(failure.CheckpointConflict, "Block at height %d is %x instead of %x", checkpoint.Height, block.Header.Hash, checkpoint.Hash)
+		}
+		state, err := chainState()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve blockchain state")
+		}
+		if state != nil && state.Height == checkpoint.Height {
+			if err := checkpoint.Matches(*state); err != nil {
+				log.Error("Local UTXO set conflicts with checkpoint", logger.Err(err))
+				return nil, failure.Newf(failure.CheckpointConflict, "%s", err)
+			}
+		}
+		if err := saveCheckpoint(checkpoint); err != nil {
+			return nil, errors.Wrapf(err, "Failed to save checkpoint at height %d", checkpoint.Height)
+		}
+		log.Info("Checkpoint saved")
+		return websocket.NewNoActionPong(), nil
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+func Equivocation(verifyEquivocation blockchain.VerifyEquivocationFn, saveEquivocation blockchain.SaveEquivocationFn, broadcast websocket.BroadcastFn, log logger.Logger) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		var proof blockchain.Equivocation
+		if err := json.Unmarshal(ping.Body, &proof); err != nil {
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal equivocation %s", ping.Body)
+		}
+		if !verifyEquivocation(proof) {
+			return nil, failure.Newf(failure.InvalidData, "Equivocation proof is not valid")
+		}
+		if err := gossipEquivocation(proof, saveEquivocation, broadcast, log); err != nil {
+			return nil, err
+		}
+		return websocket.NewNoActionPong(), nil
+	}
+}
+
+func gossipEquivocation(proof blockchain.Equivocation, saveEquivocation blockchain.SaveEquivocationFn, broadcast websocket.BroadcastFn, log logger.Logger) error {
+	saved, err := saveEquivocation(proof)
+	switch {
+	case err != nil:
+		return errors.Wrapf(err, "Failed to save equivocation of %x at height %d", proof.Forger, proof.Height)
+	case !saved:
+		return nil
+	}
+	receivers := broadcast(websocket.Pong{
+		Message: websocket.EquivocationMessage,
+		Body:    proof,
+	})
+	log.Warn("Equivocation gossiped",
+		logger.F("address", wallet.AddressFromPublicKeyHash(proof.Forger)),
+		logger.F("height", proof.Height),
+		logger.F("receivers", receivers),
+	)
+	return nil
+}
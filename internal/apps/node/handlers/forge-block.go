@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"time"
 
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
@@ -13,61 +16,101 @@ import (
 func ForgeBlock(
 	getTip blockchain.GetTipFn,
 	getBlock blockchain.GetBlockFn,
+	getClock blockchain.GetClockFn,
 	forgeBlock blockchain.ForgeBlockFn,
 	getTransactions transaction.GetTransactionsFn,
+	isRecastClosed blockchain.IsClosedAtSlotFn,
+	isElectionClosed blockchain.IsClosedAtSlotFn,
 	newStakeTransaction transaction.NewStakeTransactionFn,
 	isReturnStakeTransaction transaction.IsReturnStakeTransactionFn,
 	broadcast websocket.BroadcastFn,
+	record blockchain.RecordForgeFn,
+	log logger.Logger,
 ) websocket.Handler {
 	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
 		var body websocket.ForgeBlockBody
 		if err := json.Unmarshal(ping.Body, &body); err != nil {
-			return nil, errors.Wrapf(err, "Failed to unmarshal forge block message body %s", ping.Body)
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal forge block message body %s", ping.Body)
 		}
 		height, err := blockchain.GetHeight(getTip, getBlock)
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to retrieve block height")
 		}
 		if height < body.Height {
-			return nil, errors.Errorf("Cannot forge block because blockchain height is not high enough(%d)", height)
+			err := errors.Errorf("Cannot forge block because blockchain height is not high enough(%d)", height)
+			record(blockchain.NewForgeAttempt(body.Height+1, blockchain.ForgeFailed, nil, err))
+			return nil, err
+		}
+		clock, err := getClock()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve slot clock")
+		}
+		if now := time.Now(); clock.SlotAt(now) != body.Slot || (body.Deadline > 0 && now.Unix() > body.Deadline) {
+			err := errors.Errorf("Deadline of slot %d has passed", body.Slot)
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
+			return nil, err
 		}
 		stake, err := newStakeTransaction()
 		if err != nil {
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
 			return nil, errors.Wrapf(err, "Failed to create stake transaction")
 		}
-		log.Printf("Stake transaction %s", *stake)
-		transactions, err := getTransactions()
-		switch {
-		case err != nil:
+		log := log.With(logger.F("height", height+1), logger.F("slot", body.Slot), logger.TxID(stake.ID))
+		pending, err := getTransactions()
+		if err != nil {
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
 			return nil, errors.Wrap(err, "Failed to retrieve transactions")
+		}
+		recastClosed, err := isRecastClosed(body.Slot)
+		if err != nil {
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
+			return nil, errors.Wrapf(err, "Failed to check recast window of slot %d", body.Slot)
+		}
+		electionClosed, err := isElectionClosed(body.Slot)
+		if err != nil {
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
+			return nil, errors.Wrapf(err, "Failed to check election window of slot %d", body.Slot)
+		}
+		transactions := transaction.Transactions{}
+		for _, t := range pending {
+			if t.InRecastWindow(recastClosed) && t.InElectionWindow(electionClosed) {
+				transactions = append(transactions, t)
+			}
+		}
+		switch {
 		case len(transactions) == 0:
-			log.Println("No transactions to use for forging")
+			log.Debug("No transactions to use for forging")
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeSkipped, nil, nil))
 			return websocket.NewNoActionPong(), nil
 		case len(transactions) == 1 && isReturnStakeTransaction(transactions[0]):
-			log.Println("Only return stake transaction found")
+			log.Debug("Only return stake transaction found")
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeSkipped, nil, nil))
 			return websocket.NewNoActionPong(), nil
 		}
-		block, err := forgeBlock(append(transaction.Transactions{*stake}, transactions...))
+		block, err := forgeBlock(body.Slot, append(transaction.Transactions{*stake}, transactions...))
 		switch {
 		case err != nil:
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeFailed, nil, err))
 			return nil, errors.Wrap(err, "Failed to forge block")
 		case block == nil:
-			log.Printf("Block is not forged because there are no transactions")
+			log.Debug("Block is not forged because there are no valid transactions")
+			record(blockchain.NewForgeAttempt(height+1, blockchain.ForgeSkipped, nil, nil))
 			return websocket.NewNoActionPong(), nil
 		}
-		log.Println("Forged block")
+		metrics.Blocks.Inc(metrics.Forged)
+		record(blockchain.NewForgeAttempt(height+1, blockchain.Forged, block.Header.Hash, nil))
 		newBlock, err := getBlock(getTip())
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to return block")
 		}
-		broadcast(websocket.Pong{
+		receivers := broadcast(websocket.Pong{
 			Message: websocket.BlockForgedMessage,
 			Body: websocket.BlockForgedBody{
 				Height: height + 1,
 				Block:  *newBlock,
 			},
 		})
-		log.Println("Sent forged block")
+		log.Info("Forged block", logger.BlockHash(newBlock.Header.Hash), logger.F("transactions", len(newBlock.Body.Transactions)), logger.F("receivers", receivers))
 		return websocket.NewNoActionPong(), nil
 	}
 }
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type getSnapshotResponse struct {
+	Snapshot blockchain.Snapshot `json:"snapshot"`
+}
+
+func GetSnapshot(exportSnapshot blockchain.ExportSnapshotFn) websocket.Handler {
+	return func(ping websocket.Ping, _ string) (*websocket.Pong, error) {
+		snapshot, err := exportSnapshot()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to export snapshot")
+		}
+		return websocket.NewResponsePong(getSnapshotResponse{Snapshot: *snapshot}), nil
+	}
+}
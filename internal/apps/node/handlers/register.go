@@ -3,8 +3,8 @@ package handlers
 import (
 	"encoding/json"
 
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
-	"github.com/pkg/errors"
 )
 
 type registerPayload struct {
@@ -19,9 +19,9 @@ func Register(hub *websocket.Hub) websocket.Handler {
 	return func(ping websocket.Ping, internalID string) (*websocket.Pong, error) {
 		var p registerPayload
 		if err := json.Unmarshal(ping.Body, &p); err != nil {
-			return nil, errors.Wrapf(err, "Failed to unmarshal data %s into payload", ping.Body)
+			return nil, failure.Newf(failure.InvalidData, "Failed to unmarshal data %s into payload", ping.Body)
 		}
-		nodes := hub.RegisterAtomically(internalID, p.NodeID)
+		nodes := hub.RegisterAtomically(internalID, p.NodeID, ping.Sender)
 		return websocket.NewResponsePong(
 			registerResponse{
 				Nodes: nodes,
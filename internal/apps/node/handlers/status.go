@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+const alfaID = "0"
+
+func AlfaCheck(isRegistered websocket.IsRegisteredFn) api.CheckFn {
+	return func() error {
+		if !isRegistered(alfaID) {
+			return errors.New("Not connected to the alfa node")
+		}
+		return nil
+	}
+}
+
+func SyncCheck(getHeight blockchain.GetHeightFn, getAlfaHead api.GetHeadFn, maxLag int) api.CheckFn {
+	return func() error {
+		height, err := getHeight()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve blockchain height")
+		}
+		head, err := getAlfaHead()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve alfa node head")
+		}
+		if lag := head.Height - height; lag > maxLag {
+			return errors.Errorf("Blockchain is %d blocks behind the alfa node", lag)
+		}
+		return nil
+	}
+}
+
+func GetStatus(
+	nodeID string,
+	getTip blockchain.GetTipFn,
+	getBlock blockchain.GetBlockFn,
+	getHeight blockchain.GetHeightFn,
+	isRegistered websocket.IsRegisteredFn,
+	peers websocket.PeersFn,
+	getAlfaHead api.GetHeadFn,
+	getElection api.GetElectionFn,
+	lastForge blockchain.LastForgeFn,
+	getUTXOs transaction.GetUTXOsByPublicKeyFn,
+	publicKeyHash []byte,
+) api.Handler {
+	return func(request api.Request) (api.Response, error) {
+		height, err := getHeight()
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve blockchain height")
+		}
+		tip, err := getBlock(getTip())
+		if err != nil {
+			return api.Response{}, errors.Wrap(err, "Failed to retrieve tip")
+		}
+		status := api.Status{
+			Node:      nodeID,
+			Sync:      &api.Sync{Connected: isRegistered(alfaID)},
+			Peers:     peers(),
+			LastForge: lastForge(),
+		}
+		if tip != nil {
+			status.Tip = api.Head{
+				Height:    height,
+				Hash:      hex.EncodeToString(tip.Header.Hash),
+				Timestamp: tip.Header.Timestamp,
+			}
+		}
+		if head, err := getAlfaHead(); err != nil {
+			status.Sync.Error = err.Error()
+		} else {
+			lag := head.Height - height
+			status.Sync.AlfaHeight = &head.Height
+			status.Sync.Lag = &lag
+		}
+		utxos, err := getUTXOs(publicKeyHash)
+		if err != nil {
+			return api.Response{}, errors.Wrapf(err, "Failed to retrieve utxos of %x", publicKeyHash)
+		}
+		status.Stake = api.Stake{
+			Address:  wallet.AddressFromPublicKeyHash(publicKeyHash),
+			Balance:  utxos.Sum(),
+			Eligible: utxos.CanStake(),
+		}
+		if election, err := getElection(); err == nil {
+			status.Phase = &election.Phase
+		}
+		return api.Response{
+			Status: http.StatusOK,
+			Body:   status,
+		}, nil
+	}
+}
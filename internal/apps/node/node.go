@@ -1,50 +1,133 @@
 package node
 
 import (
+	"bytes"
+
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/nebser/crypto-vote/internal/pkg/operations"
 	"github.com/pkg/errors"
 )
 
 func Initialize(
-	getHeight operations.GetHeightFn,
-	getMissingBlocks operations.GetMissingBlocksFn,
-	getBlock operations.GetBlockFn,
+	getHeaders blockchain.GetHeadersFn,
+	peers []*Peer,
+	getCheckpoint operations.GetCheckpointFn,
+	getSnapshot operations.GetSnapshotFn,
+	alfaPublicKey []byte,
+	genesisHash []byte,
 	getTip blockchain.GetTipFn,
 	getBlockchainBlock blockchain.GetBlockFn,
-	addBlock blockchain.AddBlockFn,
+	getBlockByHeight blockchain.GetBlockByHeightFn,
+	chainState blockchain.ChainStateFn,
+	verifyBlock blockchain.VerifySyncedBlockFn,
+	addNewBlock blockchain.AddNewBlockFn,
+	importSnapshot blockchain.ImportSnapshotFn,
+	saveCheckpoint blockchain.SaveCheckpointFn,
+	log logger.Logger,
 ) error {
-	blockchainHeight, err := getHeight()
+	checkpoint, err := getCheckpoint()
 	if err != nil {
-		return errors.Wrap(err, "Couldn't obtain blockchain height")
+		return errors.Wrap(err, "Couldn't obtain latest checkpoint")
 	}
-	localHeight, err := blockchain.GetHeight(getTip, getBlockchainBlock)
-	if err != nil {
-		return errors.Wrap(err, "Couldn't obtain local blockchain height")
+	if checkpoint != nil && !checkpoint.Verified(alfaPublicKey) {
+		return errors.Errorf("Checkpoint at height %d is not signed by the alfa node", checkpoint.Height)
 	}
-	if localHeight == blockchainHeight {
+	if getSnapshot != nil && getTip() == nil {
+		if err := fastSync(getSnapshot, genesisHash, checkpoint, importSnapshot); err != nil {
+			return err
+		}
+	}
+	if err := synchronize(getHeaders, peers, genesisHash, checkpoint, getTip, getBlockchainBlock, getBlockByHeight, chainState, verifyBlock, addNewBlock, log); err != nil {
+		return err
+	}
+	if checkpoint == nil {
 		return nil
 	}
-	tip := getTip()
-	blockHashes, err := getMissingBlocks(tip)
+	if err := saveCheckpoint(*checkpoint); err != nil {
+		return errors.Wrapf(err, "Failed to save checkpoint at height %d", checkpoint.Height)
+	}
+	return nil
+}
+
+func fastSync(getSnapshot operations.GetSnapshotFn, genesisHash []byte, checkpoint *blockchain.Checkpoint, importSnapshot blockchain.ImportSnapshotFn) error {
+	snapshot, err := getSnapshot()
 	if err != nil {
-		return errors.Wrapf(err, "Failed to retrieve missing blocks since tip %x", tip)
+		return errors.Wrap(err, "Couldn't obtain snapshot")
 	}
-	if len(blockHashes) == 0 {
-		return nil
+	if err := blockchain.VerifySnapshot(genesisHash, snapshot, checkpoint); err != nil {
+		return err
+	}
+	if err := importSnapshot(snapshot); err != nil {
+		return errors.Wrapf(err, "Failed to import snapshot at height %d", snapshot.Height())
 	}
-	blocks := blockchain.Blocks{}
-	for _, hash := range blockHashes {
-		block, err := getBlock(hash)
+	return nil
+}
+
+func synchronize(
+	getHeaders blockchain.GetHeadersFn,
+	peers []*Peer,
+	genesisHash []byte,
+	checkpoint *blockchain.Checkpoint,
+	getTip blockchain.GetTipFn,
+	getBlockchainBlock blockchain.GetBlockFn,
+	getBlockByHeight blockchain.GetBlockByHeightFn,
+	chainState blockchain.ChainStateFn,
+	verifyBlock blockchain.VerifySyncedBlockFn,
+	addNewBlock blockchain.AddNewBlockFn,
+	log logger.Logger,
+) error {
+	localHeight, err := blockchain.GetHeight(getTip, getBlockchainBlock)
+	if err != nil {
+		return errors.Wrap(err, "Couldn't obtain local blockchain height")
+	}
+	if localHeight > 0 {
+		genesis, err := getBlockByHeight(1)
+		if err != nil {
+			return errors.Wrap(err, "Couldn't obtain local genesis block")
+		}
+		if genesis == nil || bytes.Compare(genesis.Header.Hash, genesisHash) != 0 {
+			return errors.Wrapf(blockchain.ErrGenesisMismatch, "Local genesis block is not %x", genesisHash)
+		}
+	}
+	if checkpoint != nil && localHeight >= checkpoint.Height {
+		local, err := getBlockByHeight(checkpoint.Height)
 		if err != nil {
-			return errors.Wrapf(err, "Failed to obtain block %x", hash)
+			return errors.Wrapf(err, "Couldn't obtain local block at checkpoint height %d", checkpoint.Height)
+		}
+		if local == nil || bytes.Compare(local.Header.Hash, checkpoint.Hash) != 0 {
+			return errors.Wrapf(blockchain.ErrCheckpointConflict, "Local block at height %d is not %x", checkpoint.Height, checkpoint.Hash)
 		}
-		blocks = append(blocks, block)
 	}
-	for _, block := range blocks {
-		if _, err := addBlock(block); err != nil {
-			return errors.Wrap(err, "Failed to add block during initialization")
+	for {
+		headers, err := downloadHeaders(getHeaders, genesisHash, localHeight+1, getTip(), checkpoint)
+		if err != nil {
+			return err
 		}
+		if len(headers) == 0 {
+			return nil
+		}
+		log.Info("Headers verified", logger.F("from", localHeight+1), logger.F("to", localHeight+len(headers)))
+		apply := func(i int, block blockchain.Block) error {
+			height := localHeight + i + 1
+			if err := verifyBlock(height, block); err != nil {
+				return err
+			}
+			if err := addNewBlock(block); err != nil {
+				return errors.Wrapf(err, "Failed to add block %x at height %d", block.Header.Hash, height)
+			}
+			if checkpoint == nil || height != checkpoint.Height {
+				return nil
+			}
+			state, err := chainState()
+			if err != nil {
+				return errors.Wrap(err, "Couldn't obtain blockchain state")
+			}
+			return checkpoint.Matches(*state)
+		}
+		if err := downloadBlocks(peers, localHeight+1, headers, apply, log); err != nil {
+			return err
+		}
+		localHeight += len(headers)
 	}
-	return nil
 }
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
+	"github.com/nebser/crypto-vote/internal/pkg/operations"
+	"github.com/pkg/errors"
+)
+
+const (
+	DownloadWindow   = 64
+	DownloadRetries  = 3
+	RetryBackoff     = 500 * time.Millisecond
+	ProgressInterval = 50
+)
+
+type Peer struct {
+	ID       string
+	getBlock operations.GetBlockFn
+	mu       sync.Mutex
+}
+
+func NewPeer(id string, getBlock operations.GetBlockFn) *Peer {
+	return &Peer{ID: id, getBlock: getBlock}
+}
+
+func (p *Peer) block(header blockchain.Header) (blockchain.Block, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	block, err := p.getBlock(header.Hash)
+	if err != nil {
+		return blockchain.Block{}, errors.Wrapf(err, "Failed to obtain block %x from peer %s", header.Hash, p.ID)
+	}
+	if bytes.Compare(block.Header.Hash, header.Hash) != 0 || bytes.Compare(block.Body.Transactions.Hash(), header.TransactionHash) != 0 {
+		return blockchain.Block{}, errors.Errorf("Block %x from peer %s doesn't match its header", header.Hash, p.ID)
+	}
+	block.Header = header
+	return block, nil
+}
+
+func downloadHeaders(getHeaders blockchain.GetHeadersFn, genesisHash []byte, height int, prev []byte, checkpoint *blockchain.Checkpoint) ([]blockchain.Header, error) {
+	result := []blockchain.Header{}
+	for {
+		headers, err := getHeaders(height, blockchain.MaxHeadersPerRequest)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve headers from height %d", height)
+		}
+		if err := blockchain.VerifyHeaderChain(genesisHash, prev, height, headers, checkpoint); err != nil {
+			return nil, err
+		}
+		result = append(result, headers...)
+		if len(headers) < blockchain.MaxHeadersPerRequest {
+			return result, nil
+		}
+		height += len(headers)
+		prev = headers[len(headers)-1].Hash
+	}
+}
+
+func fetchBlock(peers []*Peer, first int, header blockchain.Header, log logger.Logger) (blockchain.Block, error) {
+	var err error
+	for attempt := 0; attempt < DownloadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * RetryBackoff)
+		}
+		peer := peers[(first+attempt)%len(peers)]
+		var block blockchain.Block
+		block, err = peer.block(header)
+		if err == nil {
+			metrics.SyncBlocks.Inc(peer.ID, metrics.Accepted)
+			return block, nil
+		}
+		metrics.SyncBlocks.Inc(peer.ID, metrics.Rejected)
+		log.Warn("Block download failed", logger.PeerID(peer.ID), logger.BlockHash(header.Hash), logger.F("attempt", attempt+1), logger.Err(err))
+	}
+	return blockchain.Block{}, errors.Wrapf(err, "Failed to download block %x after %d attempts", header.Hash, DownloadRetries)
+}
+
+type download struct {
+	index int
+	block blockchain.Block
+	err   error
+}
+
+func downloadBlocks(peers []*Peer, height int, headers []blockchain.Header, apply func(int, blockchain.Block) error, log logger.Logger) error {
+	if len(peers) == 0 {
+		return errors.New("No peers to download blocks from")
+	}
+	done := make(chan struct{})
+	defer close(done)
+	window := make(chan struct{}, DownloadWindow)
+	jobs := make(chan int)
+	results := make(chan download)
+	go func() {
+		defer close(jobs)
+		for i := range headers {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := range peers {
+		go func(w int) {
+			for i := range jobs {
+				block, err := fetchBlock(peers, w, headers[i], log)
+				select {
+				case results <- download{index: i, block: block, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}(w)
+	}
+	pending := map[int]blockchain.Block{}
+	for next := 0; next < len(headers); {
+		result := <-results
+		if result.err != nil {
+			return result.err
+		}
+		pending[result.index] = result.block
+		for block, ok := pending[next]; ok; block, ok = pending[next] {
+			if err := apply(next, block); err != nil {
+				return err
+			}
+			delete(pending, next)
+			next++
+			<-window
+			if next%ProgressInterval == 0 || next == len(headers) {
+				log.Info("Sync progress",
+					logger.F("height", height+next-1),
+					logger.F("target", height+len(headers)-1),
+					logger.F("percent", next*100/len(headers)),
+				)
+			}
+		}
+	}
+	return nil
+}
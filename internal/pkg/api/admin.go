@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type AdminAction string
+
+const (
+	RegisterPartyAction   AdminAction = "register-party"
+	UpdatePartyAction     AdminAction = "update-party"
+	DeactivatePartyAction AdminAction = "deactivate-party"
+	RegisterVoterAction   AdminAction = "register-voter"
+	ScheduleUpgradeAction AdminAction = "schedule-upgrade"
+)
+
+const AdminRequestValidity = 5 * time.Minute
+
+const adminNonceLength = 16
+
+type UseAdminRequestFn func(id []byte, expires time.Time) (bool, error)
+
+type AdminRequest struct {
+	Action    AdminAction          `json:"action"`
+	Address   string               `json:"address"`
+	Name      string               `json:"name,omitempty"`
+	Metadata  map[string]string    `json:"metadata,omitempty"`
+	Upgrade   *transaction.Upgrade `json:"upgrade,omitempty"`
+	Timestamp int64                `json:"timestamp"`
+	Nonce     string               `json:"nonce"`
+	Verifier  string               `json:"verifier"`
+	Signature string               `json:"signature"`
+}
+
+func (r AdminRequest) Signable() ([]byte, error) {
+	data := struct {
+		Action    AdminAction          `json:"action"`
+		Address   string               `json:"address"`
+		Name      string               `json:"name,omitempty"`
+		Metadata  map[string]string    `json:"metadata,omitempty"`
+		Upgrade   *transaction.Upgrade `json:"upgrade,omitempty"`
+		Timestamp int64                `json:"timestamp"`
+		Nonce     string               `json:"nonce"`
+	}{
+		Action:    r.Action,
+		Address:   r.Address,
+		Name:      r.Name,
+		Metadata:  r.Metadata,
+		Upgrade:   r.Upgrade,
+		Timestamp: r.Timestamp,
+		Nonce:     r.Nonce,
+	}
+	return json.Marshal(data)
+}
+
+func (r AdminRequest) ID() ([]byte, error) {
+	signable, err := r.Signable()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to serialize admin request %s", r.Action)
+	}
+	id := sha256.Sum256(signable)
+	return id[:], nil
+}
+
+func (r AdminRequest) Expires() time.Time {
+	return time.Unix(r.Timestamp, 0).Add(AdminRequestValidity)
+}
+
+func (r AdminRequest) Signed(authority wallet.Wallet) (AdminRequest, error) {
+	r.Timestamp = time.Now().Unix()
+	nonce := make([]byte, adminNonceLength)
+	if _, err := rand.Read(nonce); err != nil {
+		return AdminRequest{}, errors.Wrapf(err, "Failed to generate nonce of admin request %s", r.Action)
+	}
+	r.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	r.Verifier = base64.StdEncoding.EncodeToString(authority.PublicKey)
+	signature, err := wallet.Sign(r, authority.PrivateKey)
+	if err != nil {
+		return AdminRequest{}, errors.Wrapf(err, "Failed to sign admin request %s", r.Action)
+	}
+	r.Signature = base64.StdEncoding.EncodeToString(signature)
+	return r, nil
+}
@@ -0,0 +1,74 @@
+package api
+
+type Head struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type Block struct {
+	Hash              string        `json:"hash"`
+	Prev              string        `json:"prev,omitempty"`
+	Height            int           `json:"height"`
+	Version           int           `json:"version"`
+	MagicNumber       int           `json:"magicNumber"`
+	TransactionHash   string        `json:"transactionHash"`
+	Timestamp         int64         `json:"timestamp"`
+	Slot              int           `json:"slot"`
+	UTXORoot          string        `json:"utxoRoot"`
+	Forger            string        `json:"forger,omitempty"`
+	Signature         string        `json:"signature,omitempty"`
+	TransactionsCount int           `json:"transactionsCount"`
+	Transactions      []Transaction `json:"transactions"`
+}
+
+type BlockPage struct {
+	Blocks []Block `json:"blocks"`
+	Next   int     `json:"next,omitempty"`
+}
+
+type Transaction struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Inputs    []Input  `json:"inputs"`
+	Outputs   []Output `json:"outputs"`
+	Payload   string   `json:"payload,omitempty"`
+	Timestamp int64    `json:"timestamp"`
+	Block     string   `json:"block,omitempty"`
+	Height    int      `json:"height,omitempty"`
+	Position  *int     `json:"position,omitempty"`
+}
+
+type Input struct {
+	TransactionID string `json:"transactionId,omitempty"`
+	Vout          int    `json:"vout"`
+	PublicKeyHash string `json:"publicKeyHash"`
+	Address       string `json:"address"`
+	Signature     string `json:"signature,omitempty"`
+	Verifier      string `json:"verifier,omitempty"`
+	Token         string `json:"token,omitempty"`
+	KeyImage      string `json:"keyImage,omitempty"`
+}
+
+type Output struct {
+	Value         int    `json:"value"`
+	PublicKeyHash string `json:"publicKeyHash"`
+	Address       string `json:"address"`
+}
+
+type UTXO struct {
+	TransactionID string `json:"transactionId"`
+	Vout          int    `json:"vout"`
+	Value         int    `json:"value"`
+}
+
+type AddressUTXOs struct {
+	Address string `json:"address"`
+	Balance int    `json:"balance"`
+	UTXOs   []UTXO `json:"utxos"`
+}
+
+type AddressHistory struct {
+	Address      string        `json:"address"`
+	Transactions []Transaction `json:"transactions"`
+}
@@ -3,13 +3,27 @@ package api
 import (
 	"encoding/json"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/pkg/errors"
 )
 
+const CorrelationIDHeader = "X-Correlation-ID"
+
 type Request struct {
-	Headers http.Header
-	Body    []byte
+	Headers       http.Header
+	Params        map[string]string
+	Query         url.Values
+	Body          []byte
+	RemoteAddr    string
+	CorrelationID string
 }
 
 type Response struct {
@@ -19,28 +33,79 @@ type Response struct {
 
 type Handler func(Request) (Response, error)
 
-func NewHandleFunc(h Handler) http.HandlerFunc {
+func (h Handler) Observed(observe func(error)) Handler {
+	return func(r Request) (Response, error) {
+		response, err := h(r)
+		observe(err)
+		return response, err
+	}
+}
+
+func correlationID(r *http.Request) string {
+	if id := r.Header.Get(CorrelationIDHeader); id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
+func remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func writeError(w http.ResponseWriter, e failure.Error) {
+	if e.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(e.RetryAfter))
+	}
+	writeResponse(w, ErrorResponse(e))
+}
+
+func writeResponse(w http.ResponseWriter, res Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.Status)
+	json.NewEncoder(w).Encode(res.Body)
+}
+
+func NewHandleFunc(h Handler, log logger.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		id := correlationID(r)
+		log := log.With(logger.CorrelationID(id), logger.F("method", r.Method), logger.F("path", r.URL.Path))
+		w.Header().Set(CorrelationIDHeader, id)
 		body, err := ioutil.ReadAll(r.Body)
-		if err != nil {
-			res := InternalServerErrorResponse()
-			w.WriteHeader(res.Status)
-			json.NewEncoder(w).Encode(res.Body)
+		switch {
+		case errors.Is(err, ErrBodyTooLarge):
+			writeError(w, failure.New(failure.RequestTooLarge).WithCorrelationID(id))
+			return
+		case err != nil:
+			log.Error("Failed to read request body", logger.Err(err))
+			writeError(w, failure.New(failure.Internal).WithCorrelationID(id))
 			return
 		}
 		request := Request{
-			Headers: r.Header,
-			Body:    body,
+			Headers:       r.Header,
+			Params:        mux.Vars(r),
+			Query:         r.URL.Query(),
+			Body:          body,
+			RemoteAddr:    remoteAddr(r),
+			CorrelationID: id,
 		}
 		result, err := h(request)
 		if err != nil {
-			log.Printf("Unexpected error occurred %s", err)
-			res := InternalServerErrorResponse()
-			w.WriteHeader(res.Status)
-			json.NewEncoder(w).Encode(res.Body)
+			e, ok := failure.From(err)
+			if !ok {
+				log.Error("Unexpected error occurred", logger.Err(err))
+			} else {
+				log.Debug("Request rejected", logger.F("code", e.Code), logger.F("status", e.Status))
+			}
+			writeError(w, e.WithCorrelationID(id))
 			return
 		}
-		w.WriteHeader(result.Status)
-		json.NewEncoder(w).Encode(result.Body)
+		if e, ok := result.Body.(failure.Envelope); ok {
+			result.Body = e.Error.WithCorrelationID(id).Envelope()
+		}
+		writeResponse(w, result)
 	}
 }
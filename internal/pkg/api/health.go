@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+)
+
+type Health struct {
+	Status string `json:"status"`
+}
+
+type CheckFn func() error
+
+type Check struct {
+	Name string
+	Run  CheckFn
+}
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Readiness struct {
+	Ready  bool          `json:"ready"`
+	Checks []CheckResult `json:"checks"`
+}
+
+type Sync struct {
+	Connected  bool   `json:"connected"`
+	AlfaHeight *int   `json:"alfaHeight,omitempty"`
+	Lag        *int   `json:"lag,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Stake struct {
+	Address  string `json:"address"`
+	Balance  int    `json:"balance"`
+	Eligible bool   `json:"eligible"`
+}
+
+type Status struct {
+	Node      string                   `json:"node"`
+	Tip       Head                     `json:"tip"`
+	Sync      *Sync                    `json:"sync,omitempty"`
+	Peers     int                      `json:"peers"`
+	LastForge *blockchain.ForgeAttempt `json:"lastForge,omitempty"`
+	Stake     Stake                    `json:"stake"`
+	Phase     *election.Phase          `json:"phase,omitempty"`
+}
+
+type GetHeadFn func() (*Head, error)
+
+type GetElectionFn func() (*Election, error)
+
+func Healthz() Handler {
+	return func(Request) (Response, error) {
+		return Response{
+			Status: http.StatusOK,
+			Body:   Health{Status: "ok"},
+		}, nil
+	}
+}
+
+func Readyz(checks ...Check) Handler {
+	return func(Request) (Response, error) {
+		readiness := Readiness{
+			Ready:  true,
+			Checks: make([]CheckResult, 0, len(checks)),
+		}
+		for _, check := range checks {
+			result := CheckResult{Name: check.Name, Passed: true}
+			if err := check.Run(); err != nil {
+				result.Passed = false
+				result.Error = err.Error()
+				readiness.Ready = false
+			}
+			readiness.Checks = append(readiness.Checks, result)
+		}
+		status := http.StatusOK
+		if !readiness.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		return Response{
+			Status: status,
+			Body:   readiness,
+		}, nil
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/limit"
+	"github.com/pkg/errors"
+)
+
+var ErrBodyTooLarge = errors.New("Request body is too large")
+
+type KeyFn func(Request) string
+
+func RemoteAddr(r Request) string {
+	return r.RemoteAddr
+}
+
+func (h Handler) Limited(allow limit.AllowFn, key KeyFn) Handler {
+	return func(r Request) (Response, error) {
+		if ok, wait := allow(key(r)); !ok {
+			return Response{}, failure.New(failure.TooManyRequests).WithRetryAfter(wait)
+		}
+		return h(r)
+	}
+}
+
+func (h Handler) Pooled(p *limit.Pool) Handler {
+	return func(r Request) (Response, error) {
+		var (
+			response Response
+			err      error
+		)
+		done := make(chan struct{})
+		if !p.Submit(func() {
+			response, err = h(r)
+			close(done)
+		}) {
+			return Response{}, failure.New(failure.ServerBusy).WithRetryAfter(time.Second)
+		}
+		<-done
+		return response, err
+	}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return 0, ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func LimitBody(next http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if max > 0 && r.Body != nil {
+			r.Body = &limitedBody{ReadCloser: r.Body, remaining: max}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func Timeout(next http.Handler, d time.Duration) http.Handler {
+	if d <= 0 {
+		return next
+	}
+	body, _ := json.Marshal(failure.New(failure.RequestTimeout).Envelope())
+	return http.TimeoutHandler(next, d, string(body))
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+)
+
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+func GetLogLevels(levels *logger.Levels) Handler {
+	return func(Request) (Response, error) {
+		return Response{
+			Status: http.StatusOK,
+			Body:   levels.All(),
+		}, nil
+	}
+}
+
+func SetLogLevel(levels *logger.Levels) Handler {
+	return func(request Request) (Response, error) {
+		var body LogLevel
+		if err := json.Unmarshal(request.Body, &body); err != nil {
+			return Response{}, failure.New(failure.InvalidData)
+		}
+		level, err := logger.ParseLevel(body.Level)
+		if err != nil {
+			return Response{}, failure.Newf(failure.InvalidData, "Unknown log level %s", body.Level)
+		}
+		levels.Set(request.Params["subsystem"], level)
+		return Response{
+			Status: http.StatusOK,
+			Body:   levels.All(),
+		}, nil
+	}
+}
@@ -0,0 +1,750 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const specification = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "crypto-vote alfa node API",
+    "version": "1.0.0",
+    "description": "Voting, results and read-only block explorer API of the alfa node. Binary values in request bodies are base64 encoded, hashes, ids and keys in explorer responses are hex encoded."
+  },
+  "servers": [{"url": "http://localhost:8000"}],
+  "paths": {
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "responses": {"200": {"description": "OpenAPI document", "content": {"application/json": {}}}}
+      }
+    },
+    "/vote": {
+      "post": {
+        "summary": "Cast a vote",
+        "description": "The body is signed by the voter over sender, recipient, value and the payload hash of a ballot or recast, except for ring votes which carry a ring signature instead.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Vote"}}}},
+        "responses": {
+          "200": {"description": "Vote accepted"},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "413": {"$ref": "#/components/responses/Error"},
+          "429": {"$ref": "#/components/responses/Error"},
+          "503": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/parties": {
+      "get": {
+        "summary": "Parties with their current balances",
+        "responses": {"200": {"description": "Parties sorted by balance", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Party"}}}}}}
+      }
+    },
+    "/election": {
+      "get": {
+        "summary": "Election phase, closing time and election key",
+        "responses": {"200": {"description": "Election", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Election"}}}}}
+      }
+    },
+    "/ring": {
+      "get": {
+        "summary": "Public keys of all eligible voters in ring mode",
+        "responses": {
+          "200": {"description": "Ring", "content": {"application/json": {"schema": {"type": "array", "items": {"type": "string", "format": "byte"}}}}},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/recasts/{address}": {
+      "get": {
+        "summary": "Sequence number of the next recast vote of a voter",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "responses": {
+          "200": {"description": "Next sequence", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RecastSequence"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/tokens/commitment": {
+      "post": {
+        "summary": "Request a blind signature commitment for a ballot token",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TokenCommitmentRequest"}}}},
+        "responses": {
+          "200": {"description": "Commitment", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TokenCommitmentResponse"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/tokens": {
+      "post": {
+        "summary": "Issue a blind-signed ballot token",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/IssueTokenRequest"}}}},
+        "responses": {
+          "200": {"description": "Blind signature", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/IssueTokenResponse"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/revocations": {
+      "get": {
+        "summary": "All revoked voter keys",
+        "responses": {"200": {"description": "Revocations", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Revocation"}}}}}}
+      },
+      "post": {
+        "summary": "Revoke a voter key and move its ballot to a replacement address",
+        "description": "Signed by the authority key.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RevocationRequest"}}}},
+        "responses": {
+          "200": {"description": "Revocation", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Revocation"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/revocations/{address}": {
+      "get": {
+        "summary": "Revocation of a voter key",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "responses": {
+          "200": {"description": "Revocation", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Revocation"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/parties": {
+      "post": {
+        "summary": "Register a new party",
+        "description": "Signed by the authority key. The party is created when the transaction is forged.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AdminRequest"}}}},
+        "responses": {
+          "200": {"description": "Pending party transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/parties/{address}": {
+      "put": {
+        "summary": "Rename a party or replace its metadata",
+        "description": "Signed by the authority key.",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AdminRequest"}}}},
+        "responses": {
+          "200": {"description": "Pending party transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/parties/{address}/deactivation": {
+      "post": {
+        "summary": "Deactivate a party so it no longer accepts votes",
+        "description": "Signed by the authority key. Votes already cast for the party are kept.",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AdminRequest"}}}},
+        "responses": {
+          "200": {"description": "Pending party transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/voters": {
+      "post": {
+        "summary": "Register an eligible voter after genesis",
+        "description": "Signed by the authority key. The voter receives a ballot when the transaction is forged.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AdminRequest"}}}},
+        "responses": {
+          "200": {"description": "Pending registration transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "403": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/admin/upgrades": {
+      "post": {
+        "summary": "Schedule a protocol upgrade from a future height",
+        "description": "Signed by the authority key. The upgrade is recorded on chain when the transaction is forged and applies to blocks from its height on.",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AdminRequest"}}}},
+        "responses": {
+          "200": {"description": "Pending upgrade transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "401": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/slashings": {
+      "get": {
+        "summary": "All slashed forgers with evidence of their invalid blocks",
+        "responses": {"200": {"description": "Slashings", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Slashing"}}}}}}
+      }
+    },
+    "/slashings/{address}": {
+      "get": {
+        "summary": "Slashing of a forger",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "responses": {
+          "200": {"description": "Slashing", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Slashing"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/checkpoint": {
+      "get": {
+        "summary": "Latest checkpoint signed by the alfa node",
+        "responses": {
+          "200": {"description": "Checkpoint", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Checkpoint"}}}},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/slots/current": {
+      "get": {
+        "summary": "Current slot with its designated and backup forger",
+        "responses": {
+          "200": {"description": "Current slot", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Slot"}}}},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/slots/missed": {
+      "get": {
+        "summary": "Slots in which the designated or backup forger did not forge a block",
+        "responses": {"200": {"description": "Missed slots", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/MissedSlot"}}}}}}
+      }
+    },
+    "/tally/encrypted": {
+      "get": {
+        "summary": "Encrypted sums per party",
+        "responses": {
+          "200": {"description": "Encrypted tally", "content": {"application/json": {"schema": {"type": "object"}}}},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/tally/decryptions": {
+      "post": {
+        "summary": "Submit partial decryptions of a trustee",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"type": "object"}}}},
+        "responses": {
+          "200": {"description": "Decryption progress", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/DecryptionProgress"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/tally": {
+      "get": {
+        "summary": "Published tally with all partial decryptions",
+        "responses": {
+          "200": {"description": "Tally", "content": {"application/json": {"schema": {"type": "object"}}}},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/healthz": {
+      "get": {
+        "summary": "Liveness of the node",
+        "responses": {"200": {"description": "Node is alive", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Health"}}}}}
+      }
+    },
+    "/readyz": {
+      "get": {
+        "summary": "Readiness of the node",
+        "responses": {
+          "200": {"description": "Node is ready", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Readiness"}}}},
+          "503": {"description": "Node is not ready", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Readiness"}}}}
+        }
+      }
+    },
+    "/status": {
+      "get": {
+        "summary": "Tip, peers, last forge attempt, stake balance and election phase of the node",
+        "responses": {"200": {"description": "Status", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}}}
+      }
+    },
+    "/head": {
+      "get": {
+        "summary": "Last block",
+        "responses": {"200": {"description": "Head", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Head"}}}}}
+      }
+    },
+    "/blocks": {
+      "get": {
+        "summary": "Blocks going backwards from a height",
+        "parameters": [
+          {"name": "start", "in": "query", "schema": {"type": "integer", "minimum": 1}, "description": "Height of the first block, the last block by default"},
+          {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100, "default": 10}}
+        ],
+        "responses": {
+          "200": {"description": "Page of blocks", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/BlockPage"}}}},
+          "400": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/blocks/{id}": {
+      "get": {
+        "summary": "Block by height or by hex encoded hash",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "Block", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Block"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/transactions/pending": {
+      "get": {
+        "summary": "Transactions that are not included in a block yet",
+        "responses": {"200": {"description": "Transactions", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Transaction"}}}}}}
+      }
+    },
+    "/transactions/{id}": {
+      "get": {
+        "summary": "Transaction with its block, height and position",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "Transaction", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Transaction"}}}},
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/addresses/{address}/utxos": {
+      "get": {
+        "summary": "Unspent outputs and balance of an address",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "responses": {
+          "200": {"description": "Unspent outputs", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AddressUTXOs"}}}},
+          "400": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/addresses/{address}/transactions": {
+      "get": {
+        "summary": "Transactions of an address, newest first",
+        "parameters": [{"$ref": "#/components/parameters/Address"}],
+        "responses": {
+          "200": {"description": "History", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AddressHistory"}}}},
+          "400": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/events": {
+      "get": {
+        "summary": "Server-sent events with new blocks, accepted transactions and party balances",
+        "description": "Starts with a parties event. Event names are block, transaction and parties and data holds a Block, a Transaction or a list of Party objects.",
+        "responses": {"200": {"description": "Event stream", "content": {"text/event-stream": {}}}}
+      }
+    },
+    "/events/ws": {
+      "get": {
+        "summary": "Read-only websocket with the same events as /events",
+        "description": "Every message is an Event object.",
+        "responses": {"101": {"description": "Switching protocols"}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "Address": {"name": "address", "in": "path", "required": true, "schema": {"type": "string"}}
+    },
+    "responses": {
+      "Error": {
+        "description": "Error",
+        "headers": {
+          "X-Correlation-ID": {"schema": {"type": "string"}},
+          "Retry-After": {"schema": {"type": "integer"}}
+        },
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+      }
+    },
+    "schemas": {
+      "Error": {
+        "type": "object",
+        "properties": {
+          "error": {
+            "type": "object",
+            "required": ["code", "message", "status", "retryable"],
+            "properties": {
+              "code": {
+                "type": "string",
+                "enum": ["internal-server-error", "invalid-data-error", "unauthorized-error", "not-found-error", "message-unknown", "block-not-found", "invalid-transaction", "user-already-voted", "voter-revoked", "ballot-spent", "invalid-replacement", "token-not-requested", "token-already-used", "token-session-open", "ballot-pool-empty", "election-closed", "election-open", "ballots-pending", "tally-published", "recast-pending", "invalid-recast-sequence", "too-many-requests", "request-too-large", "server-busy", "request-timeout"]
+              },
+              "message": {"type": "string"},
+              "status": {"type": "integer"},
+              "retryable": {"type": "boolean"},
+              "retryAfter": {"type": "integer", "description": "Seconds to wait before retrying, same value as the Retry-After response header"},
+              "correlationId": {"type": "string", "description": "Same value as the X-Correlation-ID response header"}
+            }
+          }
+        }
+      },
+      "Vote": {
+        "type": "object",
+        "required": ["sender", "recipient"],
+        "properties": {
+          "sender": {"type": "string", "format": "byte"},
+          "recipient": {"type": "string", "format": "byte"},
+          "verifier": {"type": "string", "format": "byte"},
+          "signature": {"type": "string", "format": "byte"},
+          "token": {"type": "string", "format": "byte"},
+          "ballot": {"type": "string", "format": "byte"},
+          "ring": {
+            "type": "object",
+            "properties": {
+              "keyImage": {"type": "string", "format": "byte"},
+              "challenge": {"type": "string", "format": "byte"},
+              "responses": {"type": "array", "items": {"type": "string", "format": "byte"}}
+            }
+          },
+          "party": {"type": "string", "format": "byte"},
+          "sequence": {"type": "integer"}
+        }
+      },
+      "Party": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string"},
+          "address": {"type": "string"},
+          "metadata": {"type": "object", "additionalProperties": {"type": "string"}},
+          "active": {"type": "boolean"},
+          "balance": {"type": "integer"},
+          "encryptedVotes": {"type": "object"}
+        }
+      },
+      "Election": {
+        "type": "object",
+        "properties": {
+          "phase": {"type": "string", "enum": ["voting", "closed", "tallied"]},
+          "closes": {"type": "string", "format": "date-time"},
+          "encrypted": {"type": "boolean"},
+          "electionKey": {"type": "object"}
+        }
+      },
+      "RecastSequence": {
+        "type": "object",
+        "properties": {"sequence": {"type": "integer"}}
+      },
+      "TokenCommitmentRequest": {
+        "type": "object",
+        "properties": {
+          "sender": {"type": "string", "format": "byte"},
+          "verifier": {"type": "string", "format": "byte"},
+          "signature": {"type": "string", "format": "byte"}
+        }
+      },
+      "TokenCommitmentResponse": {
+        "type": "object",
+        "properties": {"commitment": {"type": "string", "format": "byte"}}
+      },
+      "IssueTokenRequest": {
+        "type": "object",
+        "properties": {
+          "sender": {"type": "string", "format": "byte"},
+          "challenge": {"type": "string", "format": "byte"},
+          "verifier": {"type": "string", "format": "byte"},
+          "signature": {"type": "string", "format": "byte"},
+          "transferSignature": {"type": "string", "format": "byte"}
+        }
+      },
+      "IssueTokenResponse": {
+        "type": "object",
+        "properties": {"blindSignature": {"type": "string", "format": "byte"}}
+      },
+      "AdminRequest": {
+        "type": "object",
+        "required": ["action", "timestamp", "nonce", "verifier", "signature"],
+        "properties": {
+          "action": {"type": "string", "enum": ["register-party", "update-party", "deactivate-party", "register-voter", "schedule-upgrade"]},
+          "address": {"type": "string", "description": "Required by every action except schedule-upgrade"},
+          "name": {"type": "string"},
+          "metadata": {"type": "object", "additionalProperties": {"type": "string"}},
+          "upgrade": {
+            "type": "object",
+            "description": "Required by schedule-upgrade",
+            "properties": {
+              "height": {"type": "integer"},
+              "version": {"type": "integer"},
+              "maxBlockSize": {"type": "integer"}
+            }
+          },
+          "timestamp": {"type": "integer", "format": "int64", "description": "Unix seconds, accepted within five minutes of the server clock"},
+          "nonce": {"type": "string", "description": "Random value, every request can be used only once"},
+          "verifier": {"type": "string", "format": "byte"},
+          "signature": {"type": "string", "format": "byte"}
+        }
+      },
+      "RevocationRequest": {
+        "type": "object",
+        "properties": {
+          "revoked": {"type": "string"},
+          "replacement": {"type": "string"},
+          "verifier": {"type": "string", "format": "byte"},
+          "signature": {"type": "string", "format": "byte"}
+        }
+      },
+      "Revocation": {
+        "type": "object",
+        "properties": {
+          "revoked": {"type": "string"},
+          "replacement": {"type": "string"},
+          "transactionId": {"type": "string", "format": "byte"},
+          "timestamp": {"type": "integer", "format": "int64"}
+        }
+      },
+      "Checkpoint": {
+        "type": "object",
+        "properties": {
+          "height": {"type": "integer"},
+          "hash": {"type": "string", "description": "Hex encoded hash of the block at the checkpoint height"},
+          "utxoRoot": {"type": "string", "description": "Hex encoded state root after the block, covering the UTXO set and the chain state"},
+          "timestamp": {"type": "integer", "format": "int64"},
+          "signature": {"type": "string", "description": "Hex encoded signature of the checkpoint by the alfa node"}
+        }
+      },
+      "SlotForger": {
+        "type": "object",
+        "properties": {
+          "nodeId": {"type": "string"},
+          "address": {"type": "string"}
+        }
+      },
+      "Slot": {
+        "type": "object",
+        "properties": {
+          "slot": {"type": "integer"},
+          "epoch": {"type": "integer"},
+          "height": {"type": "integer"},
+          "designated": {"$ref": "#/components/schemas/SlotForger"},
+          "backup": {"$ref": "#/components/schemas/SlotForger"},
+          "deadline": {"type": "string", "format": "date-time", "description": "Time after which the backup forger is asked to forge"},
+          "end": {"type": "string", "format": "date-time"},
+          "escalated": {"type": "boolean"},
+          "filled": {"type": "boolean"}
+        }
+      },
+      "MissedSlot": {
+        "type": "object",
+        "properties": {
+          "slot": {"type": "integer"},
+          "epoch": {"type": "integer"},
+          "height": {"type": "integer"},
+          "nodeId": {"type": "string"},
+          "address": {"type": "string"},
+          "role": {"type": "string", "enum": ["designated", "backup"]},
+          "time": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Slashing": {
+        "type": "object",
+        "properties": {
+          "forger": {"type": "string"},
+          "offence": {"type": "string", "enum": ["invalid-block", "equivocation"]},
+          "height": {"type": "integer"},
+          "blockHash": {"type": "string", "format": "byte"},
+          "stakeTransactionId": {"type": "string", "format": "byte"},
+          "reason": {"type": "string"},
+          "evidence": {"type": "object", "description": "Block forged message signed by the forger including the rejected block, or an equivocation proof with two such messages for the same height"},
+          "transactionId": {"type": "string", "format": "byte"},
+          "timestamp": {"type": "integer", "format": "int64"}
+        }
+      },
+      "DecryptionProgress": {
+        "type": "object",
+        "properties": {
+          "received": {"type": "integer"},
+          "threshold": {"type": "integer"},
+          "published": {"type": "boolean"}
+        }
+      },
+      "Head": {
+        "type": "object",
+        "properties": {
+          "height": {"type": "integer"},
+          "hash": {"type": "string"},
+          "timestamp": {"type": "integer", "format": "int64"}
+        }
+      },
+      "Health": {
+        "type": "object",
+        "properties": {"status": {"type": "string"}}
+      },
+      "Readiness": {
+        "type": "object",
+        "properties": {
+          "ready": {"type": "boolean"},
+          "checks": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "properties": {
+                "name": {"type": "string"},
+                "passed": {"type": "boolean"},
+                "error": {"type": "string"}
+              }
+            }
+          }
+        }
+      },
+      "Status": {
+        "type": "object",
+        "properties": {
+          "node": {"type": "string"},
+          "tip": {"$ref": "#/components/schemas/Head"},
+          "sync": {
+            "type": "object",
+            "description": "Party nodes only",
+            "properties": {
+              "connected": {"type": "boolean"},
+              "alfaHeight": {"type": "integer"},
+              "lag": {"type": "integer"},
+              "error": {"type": "string"}
+            }
+          },
+          "peers": {"type": "integer"},
+          "lastForge": {
+            "type": "object",
+            "properties": {
+              "height": {"type": "integer"},
+              "time": {"type": "string", "format": "date-time"},
+              "result": {"type": "string", "enum": ["requested", "forged", "skipped", "failed"]},
+              "block": {"type": "string"},
+              "error": {"type": "string"}
+            }
+          },
+          "stake": {
+            "type": "object",
+            "properties": {
+              "address": {"type": "string"},
+              "balance": {"type": "integer"},
+              "eligible": {"type": "boolean"}
+            }
+          },
+          "phase": {"type": "string", "enum": ["voting", "closed", "tallied"]}
+        }
+      },
+      "Block": {
+        "type": "object",
+        "properties": {
+          "hash": {"type": "string"},
+          "prev": {"type": "string"},
+          "height": {"type": "integer"},
+          "version": {"type": "integer"},
+          "magicNumber": {"type": "integer"},
+          "transactionHash": {"type": "string"},
+          "timestamp": {"type": "integer", "format": "int64"},
+          "slot": {"type": "integer", "description": "Slot in which the block was forged, it must match the timestamp"},
+          "utxoRoot": {"type": "string", "description": "Hex encoded state root after the block is applied, covering the UTXO set and the chain state"},
+          "forger": {"type": "string", "description": "Address of the forger that signed the header"},
+          "signature": {"type": "string", "description": "Hex encoded signature of the header by the forger"},
+          "transactionsCount": {"type": "integer"},
+          "transactions": {"type": "array", "items": {"$ref": "#/components/schemas/Transaction"}}
+        }
+      },
+      "BlockPage": {
+        "type": "object",
+        "properties": {
+          "blocks": {"type": "array", "items": {"$ref": "#/components/schemas/Block"}},
+          "next": {"type": "integer"}
+        }
+      },
+      "Transaction": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "string"},
+          "type": {"type": "string"},
+          "inputs": {"type": "array", "items": {"$ref": "#/components/schemas/Input"}},
+          "outputs": {"type": "array", "items": {"$ref": "#/components/schemas/Output"}},
+          "payload": {"type": "string"},
+          "timestamp": {"type": "integer", "format": "int64"},
+          "block": {"type": "string"},
+          "height": {"type": "integer"},
+          "position": {"type": "integer"}
+        }
+      },
+      "Input": {
+        "type": "object",
+        "properties": {
+          "transactionId": {"type": "string"},
+          "vout": {"type": "integer"},
+          "publicKeyHash": {"type": "string"},
+          "address": {"type": "string"},
+          "signature": {"type": "string"},
+          "verifier": {"type": "string"},
+          "token": {"type": "string"},
+          "keyImage": {"type": "string"}
+        }
+      },
+      "Output": {
+        "type": "object",
+        "properties": {
+          "value": {"type": "integer"},
+          "publicKeyHash": {"type": "string"},
+          "address": {"type": "string"}
+        }
+      },
+      "UTXO": {
+        "type": "object",
+        "properties": {
+          "transactionId": {"type": "string"},
+          "vout": {"type": "integer"},
+          "value": {"type": "integer"}
+        }
+      },
+      "AddressUTXOs": {
+        "type": "object",
+        "properties": {
+          "address": {"type": "string"},
+          "balance": {"type": "integer"},
+          "utxos": {"type": "array", "items": {"$ref": "#/components/schemas/UTXO"}}
+        }
+      },
+      "AddressHistory": {
+        "type": "object",
+        "properties": {
+          "address": {"type": "string"},
+          "transactions": {"type": "array", "items": {"$ref": "#/components/schemas/Transaction"}}
+        }
+      },
+      "Event": {
+        "type": "object",
+        "properties": {
+          "event": {"type": "string", "enum": ["block", "transaction", "parties"]},
+          "data": {}
+        }
+      }
+    }
+  }
+}`
+
+func Specification() Handler {
+	return func(request Request) (Response, error) {
+		return Response{
+			Status: http.StatusOK,
+			Body:   json.RawMessage(specification),
+		}, nil
+	}
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type Vote struct {
+	Sender    string                `json:"sender"`
+	Recipient string                `json:"recipient"`
+	Verifier  string                `json:"verifier"`
+	Signature string                `json:"signature"`
+	Token     string                `json:"token,omitempty"`
+	Ballot    string                `json:"ballot,omitempty"`
+	Ring      *wallet.RingSignature `json:"ring,omitempty"`
+	Party     string                `json:"party,omitempty"`
+	Sequence  int                   `json:"sequence,omitempty"`
+}
+
+func (v Vote) Voter() string {
+	switch {
+	case v.Ring != nil:
+		return base64.StdEncoding.EncodeToString(v.Ring.KeyImage)
+	case v.Token != "":
+		return v.Verifier
+	default:
+		return v.Sender
+	}
+}
+
+func (v Vote) Signable() ([]byte, error) {
+	data := struct {
+		Sender    string `json:"sender"`
+		Recipient string `json:"recipient"`
+		Value     int    `json:"value"`
+		Payload   []byte `json:"payload,omitempty"`
+	}{
+		Sender:    v.Sender,
+		Recipient: v.Recipient,
+		Value:     transaction.VoteValue,
+	}
+	if v.Ballot != "" {
+		ballot, err := base64.StdEncoding.DecodeString(v.Ballot)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to decode ballot")
+		}
+		data.Payload = transaction.PayloadHash(ballot)
+	}
+	if v.Party != "" {
+		recast, err := v.Recast()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := recast.Payload()
+		if err != nil {
+			return nil, err
+		}
+		data.Payload = transaction.PayloadHash(payload)
+	}
+	return json.Marshal(data)
+}
+
+func (v Vote) Recast() (*transaction.Recast, error) {
+	voter, err := base64.StdEncoding.DecodeString(v.Sender)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decode sender")
+	}
+	party, err := base64.StdEncoding.DecodeString(v.Party)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decode party")
+	}
+	return &transaction.Recast{
+		Voter:    voter,
+		Party:    party,
+		Sequence: v.Sequence,
+	}, nil
+}
+
+type TokenCommitmentRequest struct {
+	Sender    string `json:"sender"`
+	Verifier  string `json:"verifier"`
+	Signature string `json:"signature"`
+}
+
+func (t TokenCommitmentRequest) Signable() ([]byte, error) {
+	data := struct {
+		Sender string `json:"sender"`
+	}{
+		Sender: t.Sender,
+	}
+	return json.Marshal(data)
+}
+
+type TokenCommitmentResponse struct {
+	Commitment []byte `json:"commitment"`
+}
+
+type IssueTokenRequest struct {
+	Sender            string `json:"sender"`
+	Challenge         string `json:"challenge"`
+	Verifier          string `json:"verifier"`
+	Signature         string `json:"signature"`
+	TransferSignature string `json:"transferSignature"`
+}
+
+func (i IssueTokenRequest) Signable() ([]byte, error) {
+	data := struct {
+		Sender    string `json:"sender"`
+		Challenge string `json:"challenge"`
+	}{
+		Sender:    i.Sender,
+		Challenge: i.Challenge,
+	}
+	return json.Marshal(data)
+}
+
+type IssueTokenResponse struct {
+	BlindSignature []byte `json:"blindSignature"`
+}
+
+type Transfer struct {
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+	Value     int    `json:"value"`
+}
+
+func (t Transfer) Signable() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+type Election struct {
+	Phase       election.Phase       `json:"phase"`
+	Closes      string               `json:"closes,omitempty"`
+	Encrypted   bool                 `json:"encrypted"`
+	ElectionKey *elgamal.ElectionKey `json:"electionKey,omitempty"`
+}
+
+type RecastSequence struct {
+	Sequence int `json:"sequence"`
+}
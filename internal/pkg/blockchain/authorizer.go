@@ -3,7 +3,6 @@ package blockchain
 import (
 	"encoding/base64"
 	"fmt"
-	"log"
 
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
@@ -11,7 +10,7 @@ import (
 	"github.com/nebser/crypto-vote/internal/pkg/websocket"
 )
 
-func BlockchainAuthorizer(findBlock FindBlockFn) websocket.Authorizer {
+func BlockchainAuthorizer(isEligible IsEligibleFn) websocket.Authorizer {
 	return func(ping websocket.Ping) error {
 		rawPublicKey, err := base64.StdEncoding.DecodeString(ping.Sender)
 		if err != nil {
@@ -30,19 +29,12 @@ func BlockchainAuthorizer(findBlock FindBlockFn) websocket.Authorizer {
 		if err != nil {
 			return err
 		}
-		criteria := func(b Block) bool {
-			if _, ok := b.Body.Transactions.FindTransactionTo(publicKeyHashed); ok {
-				return true
-			}
-			return false
-		}
-		switch _, ok, err := findBlock(criteria); {
+		switch ok, err := isEligible(publicKeyHashed); {
 		case err != nil:
-			return errors.Errorf("Failed to find block. Error: %s", err)
+			return errors.Errorf("Failed to check eligibility. Error: %s", err)
 		case !ok:
 			return websocket.ErrUnauthorized(fmt.Sprintf("Node %s does not exist", ping.Sender))
 		default:
-			log.Println("Authorized successfully")
 			return nil
 		}
 	}
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +25,10 @@ type Header struct {
 	TransactionHash []byte
 	Hash            []byte
 	Timestamp       int64
+	Slot            int
+	UTXORoot        []byte
+	Forger          []byte
+	Signature       []byte
 }
 
 type Body struct {
@@ -38,9 +44,51 @@ type Block struct {
 
 type Blocks []Block
 
-type VerifyBlockFn func(block Block, hashedSender []byte) bool
+type NewBlockFn func(rules Rules, previousBlock []byte, slot int, utxoRoot []byte, transactions transaction.Transactions) (*Block, error)
 
-type IsReturnStakeBlockFn func(block Block, sender []byte) bool
+type ProjectUTXORootFn func(transactions transaction.Transactions) ([]byte, error)
+
+type VerifyBlockFn func(block Block) bool
+
+type IsReturnStakeBlockFn func(block Block) bool
+
+func (h Header) Signable() ([]byte, error) {
+	data := struct {
+		Version         int    `json:"version"`
+		Prev            []byte `json:"prev"`
+		TransactionHash []byte `json:"transactionHash"`
+		Hash            []byte `json:"hash"`
+		Timestamp       int64  `json:"timestamp"`
+		Slot            int    `json:"slot"`
+		UTXORoot        []byte `json:"utxoRoot"`
+		Forger          []byte `json:"forger"`
+	}{
+		Version:         h.Version,
+		Prev:            h.Prev,
+		TransactionHash: h.TransactionHash,
+		Hash:            h.Hash,
+		Timestamp:       h.Timestamp,
+		Slot:            h.Slot,
+		UTXORoot:        h.UTXORoot,
+		Forger:          h.Forger,
+	}
+	return json.Marshal(data)
+}
+
+func (h Header) ForgerHash() ([]byte, error) {
+	if len(h.Forger) == 0 {
+		return nil, errors.Errorf("Block %x has no forger", h.Hash)
+	}
+	return wallet.HashedPublicKey(h.Forger)
+}
+
+func (h Header) Verified() bool {
+	hash, err := createHash(h.Prev, h.TransactionHash, h.Timestamp, h.Slot, h.UTXORoot, h.Forger)
+	if err != nil || bytes.Compare(h.Hash, hash) != 0 {
+		return false
+	}
+	return len(h.Forger) > 0 && wallet.Verify(h, h.Signature, h.Forger)
+}
 
 func (b Block) String() string {
 	builder := strings.Builder{}
@@ -50,29 +98,32 @@ func (b Block) String() string {
 	t := time.Unix(b.Header.Timestamp, 0)
 	builder.WriteString("Timestamp: ")
 	builder.WriteString(t.Format(time.RFC3339))
+	builder.WriteString(fmt.Sprintf("\nSlot: %d", b.Header.Slot))
 	builder.WriteString(fmt.Sprintf("\nPrev: %x\n", b.Header.Prev))
 	builder.WriteString(b.Body.Transactions.String())
 	builder.WriteString("-----END BLOCK-----\n")
 	return builder.String()
 }
 
-func NewBlock(previousBlock []byte, transactions transaction.Transactions) (*Block, error) {
+func newBlock(rules Rules, previousBlock []byte, timestamp int64, slot int, utxoRoot, forger []byte, transactions transaction.Transactions) (*Block, error) {
 	transactionsHash := transactions.Hash()
-	timestamp := time.Now().Unix()
-	blockHash, err := createHash(previousBlock, transactionsHash, timestamp)
+	blockHash, err := createHash(previousBlock, transactionsHash, timestamp, slot, utxoRoot, forger)
 	if err != nil {
 		return nil, errors.New("Failed to create block hash")
 	}
-	header := Header{
-		Prev:            previousBlock,
-		TransactionHash: transactionsHash,
-		Timestamp:       timestamp,
-		Hash:            blockHash,
-	}
 	return &Block{
-		Header: header,
+		Header: Header{
+			Version:         rules.Version,
+			Prev:            previousBlock,
+			TransactionHash: transactionsHash,
+			Timestamp:       timestamp,
+			Slot:            slot,
+			UTXORoot:        utxoRoot,
+			Hash:            blockHash,
+			Forger:          forger,
+		},
 		Metadata: Metadata{
-			MagicNumber: magicNumber,
+			MagicNumber: rules.MagicNumber,
 			Size:        len(transactions),
 		},
 		Body: Body{
@@ -82,16 +133,46 @@ func NewBlock(previousBlock []byte, transactions transaction.Transactions) (*Blo
 	}, nil
 }
 
-func createHash(previousBlock, transactionsHash []byte, timestamp int64) ([]byte, error) {
+func (b Block) Signed(forger wallet.Wallet) (*Block, error) {
+	signature, err := wallet.Sign(b.Header, forger.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to sign block header %x", b.Header.Hash)
+	}
+	b.Header.Signature = signature
+	return &b, nil
+}
+
+func NewBlock(forger wallet.Wallet) NewBlockFn {
+	return func(rules Rules, previousBlock []byte, slot int, utxoRoot []byte, transactions transaction.Transactions) (*Block, error) {
+		block, err := newBlock(rules, previousBlock, time.Now().Unix(), slot, utxoRoot, forger.PublicKey, transactions)
+		if err != nil {
+			return nil, err
+		}
+		return block.Signed(forger)
+	}
+}
+
+func NewGenesisBlock(parameters Parameters, forger []byte, timestamp int64, utxoRoot []byte, transactions transaction.Transactions) (*Block, error) {
+	return newBlock(parameters.Rules(1), nil, timestamp, 0, utxoRoot, forger, transactions)
+}
+
+func createHash(previousBlock, transactionsHash []byte, timestamp int64, slot int, utxoRoot, forger []byte) ([]byte, error) {
 	timestampBytes, err := intToHex(timestamp)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to convert timestamp %d to byte array", timestamp)
 	}
+	slotBytes, err := intToHex(int64(slot))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to convert slot %d to byte array", slot)
+	}
 	hashable := bytes.Join(
 		[][]byte{
 			previousBlock,
 			transactionsHash,
 			timestampBytes,
+			slotBytes,
+			utxoRoot,
+			forger,
 		},
 		[]byte{},
 	)
@@ -107,8 +188,29 @@ func intToHex(num int64) ([]byte, error) {
 	return buff.Bytes(), nil
 }
 
-func VerfiyBlock(verifyTransaction transaction.VerifyTransctionFn, isStakeTransaction transaction.IsStakeTransactionFn) VerifyBlockFn {
-	return func(block Block, hashedSender []byte) bool {
+func verifyHeader(block Block) ([]byte, bool) {
+	if !block.Header.Verified() || bytes.Compare(block.Body.Transactions.Hash(), block.Header.TransactionHash) != 0 {
+		return nil, false
+	}
+	forger, err := block.Header.ForgerHash()
+	if err != nil {
+		return nil, false
+	}
+	return forger, true
+}
+
+func (v VerifyBlockFn) And(other VerifyBlockFn) VerifyBlockFn {
+	return func(block Block) bool {
+		return v(block) && other(block)
+	}
+}
+
+func VerfiyBlock(verifyTransaction transaction.VerifyTransctionFn, isStakeTransaction transaction.IsStakeTransactionFn, verifySlot VerifySlotFn) VerifyBlockFn {
+	return func(block Block) bool {
+		forger, ok := verifyHeader(block)
+		if !ok || !verifySlot(block.Header) {
+			return false
+		}
 		for _, transaction := range block.Body.Transactions {
 			if !verifyTransaction(transaction) {
 				return false
@@ -120,34 +222,25 @@ func VerfiyBlock(verifyTransaction transaction.VerifyTransctionFn, isStakeTransa
 		if !isStakeTransaction(block.Body.Transactions[0]) {
 			return false
 		}
-		if !block.Body.Transactions[0].AreInputsFrom(hashedSender) {
-			return false
-		}
-		transactionHash := block.Body.Transactions.Hash()
-		blockHash, err := createHash(block.Header.Prev, transactionHash, block.Header.Timestamp)
-		if err != nil {
-			return false
-		}
-		return bytes.Compare(block.Header.Hash, blockHash) == 0
+		return block.Body.Transactions[0].AreInputsFrom(forger)
+	}
+}
+
+func VerifyUniqueTokens() VerifyBlockFn {
+	return func(block Block) bool {
+		return block.Body.Transactions.UniqueTokens()
 	}
 }
 
-func IsReturnStakeBlock(verifyTransaction transaction.VerifyTransctionFn, alfaKeyHash []byte) IsReturnStakeBlockFn {
-	return func(block Block, sender []byte) bool {
+func IsReturnStakeBlock(verifyTransaction transaction.VerifyTransctionFn, alfaKeyHash []byte, verifySlot VerifySlotFn) IsReturnStakeBlockFn {
+	return func(block Block) bool {
 		if len(block.Body.Transactions) != 1 || !transaction.IsReturnStakeTransaction(alfaKeyHash)(block.Body.Transactions[0]) {
 			return false
 		}
-		if bytes.Compare(alfaKeyHash, sender) != 0 {
-			return false
-		}
-		if !verifyTransaction(block.Body.Transactions[0]) {
-			return false
-		}
-		transactionHash := block.Body.Transactions.Hash()
-		blockHash, err := createHash(block.Header.Prev, transactionHash, block.Header.Timestamp)
-		if err != nil {
+		forger, ok := verifyHeader(block)
+		if !ok || bytes.Compare(alfaKeyHash, forger) != 0 || !verifySlot(block.Header) {
 			return false
 		}
-		return bytes.Compare(block.Header.Hash, blockHash) == 0
+		return verifyTransaction(block.Body.Transactions[0])
 	}
 }
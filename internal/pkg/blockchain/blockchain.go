@@ -25,7 +25,7 @@ type GetBlockFn func(hash []byte) (*Block, error)
 
 type FindBlockFn func(criteria func(Block) bool) (Block, bool, error)
 
-type ForgeBlockFn func(transaction.Transactions) (*Block, error)
+type ForgeBlockFn func(slot int, transactions transaction.Transactions) (*Block, error)
 
 type AddNewBlockFn func(Block) error
 
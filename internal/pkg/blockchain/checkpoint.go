@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+var ErrCheckpointConflict = errors.New("Blockchain conflicts with the latest checkpoint")
+
+type Checkpoint struct {
+	Height    int    `json:"height"`
+	Hash      []byte `json:"hash"`
+	UTXORoot  []byte `json:"utxoRoot"`
+	Timestamp int64  `json:"timestamp"`
+	Signature []byte `json:"signature"`
+}
+
+type GetCheckpointFn func() (*Checkpoint, error)
+
+type SaveCheckpointFn func(Checkpoint) error
+
+type ChainStateFn func() (*Checkpoint, error)
+
+func (c Checkpoint) Signable() ([]byte, error) {
+	data := struct {
+		Height    int    `json:"height"`
+		Hash      []byte `json:"hash"`
+		UTXORoot  []byte `json:"utxoRoot"`
+		Timestamp int64  `json:"timestamp"`
+	}{
+		Height:    c.Height,
+		Hash:      c.Hash,
+		UTXORoot:  c.UTXORoot,
+		Timestamp: c.Timestamp,
+	}
+	return json.Marshal(data)
+}
+
+func (c Checkpoint) Signed(authority wallet.Wallet) (*Checkpoint, error) {
+	c.Timestamp = time.Now().Unix()
+	signature, err := wallet.Sign(c, authority.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to sign checkpoint at height %d", c.Height)
+	}
+	c.Signature = signature
+	return &c, nil
+}
+
+func (c Checkpoint) Verified(authorityPublicKey []byte) bool {
+	if c.Height <= 0 || len(c.Hash) == 0 || len(c.Signature) == 0 {
+		return false
+	}
+	return wallet.Verify(c, c.Signature, authorityPublicKey)
+}
+
+func (c Checkpoint) Matches(state Checkpoint) error {
+	if state.Height != c.Height || bytes.Compare(state.Hash, c.Hash) != 0 {
+		return errors.Wrapf(ErrCheckpointConflict, "Block at height %d is %x instead of %x", c.Height, state.Hash, c.Hash)
+	}
+	if bytes.Compare(state.UTXORoot, c.UTXORoot) != 0 {
+		return errors.Wrapf(ErrCheckpointConflict, "UTXO set root at height %d is %x instead of %x", c.Height, state.UTXORoot, c.UTXORoot)
+	}
+	return nil
+}
+
+func VerifyCheckpoint(getCheckpoint GetCheckpointFn, getBlock GetBlockFn, getBlockHeight GetBlockHeightFn) VerifyBlockFn {
+	return func(block Block) bool {
+		checkpoint, err := getCheckpoint()
+		switch {
+		case err != nil:
+			return false
+		case checkpoint == nil:
+			return true
+		}
+		height, err := getBlockHeight(block.Header.Prev)
+		if err != nil || height < checkpoint.Height {
+			return false
+		}
+		current := block.Header.Prev
+		for ; height > checkpoint.Height; height-- {
+			prev, err := getBlock(current)
+			if err != nil || prev == nil {
+				return false
+			}
+			current = prev.Header.Prev
+		}
+		return bytes.Compare(current, checkpoint.Hash) == 0
+	}
+}
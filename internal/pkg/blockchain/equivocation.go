@@ -0,0 +1,76 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+type Equivocation struct {
+	Forger []byte          `json:"forger"`
+	Height int             `json:"height"`
+	First  json.RawMessage `json:"first"`
+	Second json.RawMessage `json:"second"`
+}
+
+type RecordHeaderFn func(forger []byte, height int, hash []byte, evidence json.RawMessage) (*Equivocation, error)
+
+type SaveEquivocationFn func(Equivocation) (bool, error)
+
+type VerifyEquivocationFn func(Equivocation) bool
+
+func forgedHeight(getBlockHeight GetBlockHeightFn, block Block) (int, bool) {
+	parent, err := getBlockHeight(block.Header.Prev)
+	if err != nil || parent == 0 {
+		return 0, false
+	}
+	return parent + 1, true
+}
+
+func VerifyEquivocation(getBlockHeight GetBlockHeightFn) VerifyEquivocationFn {
+	return func(proof Equivocation) bool {
+		firstForger, first, ok := parseForgeEvidence(proof.First)
+		if !ok {
+			return false
+		}
+		secondForger, second, ok := parseForgeEvidence(proof.Second)
+		if !ok {
+			return false
+		}
+		if bytes.Compare(firstForger, proof.Forger) != 0 || bytes.Compare(secondForger, proof.Forger) != 0 {
+			return false
+		}
+		firstHeight, ok := forgedHeight(getBlockHeight, first.Block)
+		if !ok || firstHeight != proof.Height {
+			return false
+		}
+		secondHeight, ok := forgedHeight(getBlockHeight, second.Block)
+		if !ok || secondHeight != proof.Height {
+			return false
+		}
+		return bytes.Compare(first.Block.Header.Hash, second.Block.Header.Hash) != 0
+	}
+}
+
+func (e Equivocation) Slashing() (transaction.Slashing, error) {
+	evidence, err := json.Marshal(e)
+	if err != nil {
+		return transaction.Slashing{}, errors.Wrapf(err, "Failed to serialize equivocation of %x", e.Forger)
+	}
+	_, first, _ := parseForgeEvidence(e.First)
+	var stakeTransactionID []byte
+	if len(first.Block.Body.Transactions) > 0 {
+		stakeTransactionID = first.Block.Body.Transactions[0].ID
+	}
+	return transaction.Slashing{
+		Forger:             e.Forger,
+		Offence:            transaction.EquivocationOffence,
+		Height:             e.Height,
+		BlockHash:          first.Block.Header.Hash,
+		StakeTransactionID: stakeTransactionID,
+		Reason:             "Forger signed two different blocks at the same height",
+		Evidence:           evidence,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+type ForgeResult string
+
+const (
+	ForgeRequested ForgeResult = "requested"
+	Forged         ForgeResult = "forged"
+	ForgeSkipped   ForgeResult = "skipped"
+	ForgeFailed    ForgeResult = "failed"
+)
+
+type ForgeAttempt struct {
+	Height int         `json:"height"`
+	Time   time.Time   `json:"time"`
+	Result ForgeResult `json:"result"`
+	Block  string      `json:"block,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type RecordForgeFn func(ForgeAttempt)
+
+type LastForgeFn func() *ForgeAttempt
+
+type ForgeTracker struct {
+	lock *sync.Mutex
+	last *ForgeAttempt
+}
+
+func NewForgeAttempt(height int, result ForgeResult, block []byte, err error) ForgeAttempt {
+	attempt := ForgeAttempt{
+		Height: height,
+		Time:   time.Now().UTC(),
+		Result: result,
+	}
+	if block != nil {
+		attempt.Block = hex.EncodeToString(block)
+	}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+func NewForgeTracker() *ForgeTracker {
+	return &ForgeTracker{lock: &sync.Mutex{}}
+}
+
+func (t *ForgeTracker) Record(attempt ForgeAttempt) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.last = &attempt
+}
+
+func (t *ForgeTracker) Last() *ForgeAttempt {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.last == nil {
+		return nil
+	}
+	last := *t.last
+	return &last
+}
@@ -0,0 +1,51 @@
+package blockchain
+
+import (
+	"bytes"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+)
+
+type TransactionRecord struct {
+	Transaction transaction.Transaction
+	Block       []byte
+	Height      int
+	Position    int
+}
+
+type GetHeightFn func() (int, error)
+
+type GetBlockHeightFn func(hash []byte) (int, error)
+
+type GetBlockByHeightFn func(height int) (*Block, error)
+
+type GetTransactionRecordFn func(id []byte) (*TransactionRecord, error)
+
+type GetAddressHistoryFn func(publicKeyHash []byte) ([]TransactionRecord, error)
+
+type IsEligibleFn func(publicKeyHash []byte) (bool, error)
+
+func (b Block) Records(height int) []TransactionRecord {
+	records := make([]TransactionRecord, 0, len(b.Body.Transactions))
+	for i, t := range b.Body.Transactions {
+		records = append(records, TransactionRecord{
+			Transaction: t,
+			Block:       b.Header.Hash,
+			Height:      height,
+			Position:    i,
+		})
+	}
+	return records
+}
+
+func Involves(t transaction.Transaction, publicKeyHash []byte) bool {
+	if _, found := t.Inputs.Find(func(in transaction.Input) bool {
+		return bytes.Compare(in.PublicKeyHash, publicKeyHash) == 0
+	}); found {
+		return true
+	}
+	_, found := t.Outputs.Find(func(o transaction.Output) bool {
+		return bytes.Compare(o.PublicKeyHash, publicKeyHash) == 0
+	})
+	return found
+}
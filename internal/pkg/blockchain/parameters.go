@@ -0,0 +1,179 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+var ErrProtocolMismatch = errors.New("Block doesn't follow the protocol rules")
+
+type Parameters struct {
+	MagicNumber   int                   `json:"magicNumber"`
+	Version       int                   `json:"version"`
+	SlotDuration  time.Duration         `json:"slotDuration"`
+	SlotsPerEpoch int                   `json:"slotsPerEpoch"`
+	MaxBlockSize  int                   `json:"maxBlockSize"`
+	Upgrades      []transaction.Upgrade `json:"-"`
+}
+
+type Rules struct {
+	MagicNumber  int
+	Version      int
+	MaxBlockSize int
+}
+
+type GetParametersFn func() (*Parameters, error)
+
+type VerifyProtocolFn func(block Block) error
+
+func DefaultParameters() Parameters {
+	return Parameters{
+		MagicNumber:   magicNumber,
+		Version:       version,
+		SlotDuration:  SlotDuration,
+		SlotsPerEpoch: SlotsPerEpoch,
+		MaxBlockSize:  MaxBlockSize,
+	}
+}
+
+func GenesisParameters(genesis Block) (Parameters, error) {
+	parameters := DefaultParameters()
+	for _, t := range genesis.Body.Transactions {
+		if t.Type != transaction.ChainParametersTransaction {
+			continue
+		}
+		if err := json.Unmarshal(t.Payload, &parameters); err != nil {
+			return parameters, errors.Wrapf(err, "Failed to unmarshal chain parameters %s", t.Payload)
+		}
+	}
+	return parameters, nil
+}
+
+func (p Parameters) Upgraded(upgrades []transaction.Upgrade) (Parameters, error) {
+	height, version := 1, p.Version
+	for _, u := range upgrades {
+		switch {
+		case u.Height <= height:
+			return p, errors.Errorf("Upgrade to version %d at height %d must come after height %d", u.Version, u.Height, height)
+		case u.Version <= version:
+			return p, errors.Errorf("Upgrade at height %d must raise version %d, got %d", u.Height, version, u.Version)
+		case u.MaxBlockSize < 0:
+			return p, errors.Errorf("Upgrade at height %d has negative maximum block size %d", u.Height, u.MaxBlockSize)
+		}
+		height, version = u.Height, u.Version
+	}
+	p.Upgrades = upgrades
+	return p, nil
+}
+
+func (p Parameters) CheckpointInterval() time.Duration {
+	return p.SlotDuration * time.Duration(p.SlotsPerEpoch)
+}
+
+func (p Parameters) Rules(height int) Rules {
+	rules := Rules{
+		MagicNumber:  p.MagicNumber,
+		Version:      p.Version,
+		MaxBlockSize: p.MaxBlockSize,
+	}
+	for _, u := range p.Upgrades {
+		if u.Height > height {
+			break
+		}
+		rules.Version = u.Version
+		if u.MaxBlockSize > 0 {
+			rules.MaxBlockSize = u.MaxBlockSize
+		}
+	}
+	return rules
+}
+
+func (p Parameters) Verify(height int, block Block) error {
+	rules := p.Rules(height)
+	switch {
+	case block.Metadata.MagicNumber != rules.MagicNumber:
+		return errors.Wrapf(ErrProtocolMismatch, "Block %x has magic number %#x instead of %#x", block.Header.Hash, block.Metadata.MagicNumber, rules.MagicNumber)
+	case block.Header.Version != rules.Version:
+		return errors.Wrapf(ErrProtocolMismatch, "Block %x at height %d has version %d instead of %d", block.Header.Hash, height, block.Header.Version, rules.Version)
+	case height > 1 && len(block.Body.Transactions) > rules.MaxBlockSize:
+		return errors.Wrapf(ErrProtocolMismatch, "Block %x at height %d has %d transactions, more than %d", block.Header.Hash, height, len(block.Body.Transactions), rules.MaxBlockSize)
+	}
+	return nil
+}
+
+func GetParameters(getBlockByHeight GetBlockByHeightFn, getUpgrades transaction.GetUpgradesFn) GetParametersFn {
+	return func() (*Parameters, error) {
+		genesis, err := getBlockByHeight(1)
+		switch {
+		case err != nil:
+			return nil, errors.Wrap(err, "Failed to retrieve genesis block")
+		case genesis == nil:
+			return nil, errors.New("Genesis block does not exist")
+		}
+		parameters, err := GenesisParameters(*genesis)
+		if err != nil {
+			return nil, err
+		}
+		upgrades, err := getUpgrades()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve upgrades")
+		}
+		upgraded, err := parameters.Upgraded(upgrades)
+		if err != nil {
+			return nil, err
+		}
+		return &upgraded, nil
+	}
+}
+
+func (p Parameters) Scheduled(height int, u transaction.Upgrade) error {
+	if u.Height <= height+1 {
+		return errors.Errorf("Upgrade to version %d at height %d must activate after height %d", u.Version, u.Height, height+1)
+	}
+	_, err := p.Upgraded(append(append([]transaction.Upgrade{}, p.Upgrades...), u))
+	return err
+}
+
+func VerifyUpgrades(getParameters GetParametersFn, getHeight GetHeightFn) transaction.VerifyTransctionFn {
+	return func(t transaction.Transaction) bool {
+		if t.Type != transaction.UpgradeTransaction {
+			return true
+		}
+		u, ok := t.Upgrade()
+		if !ok {
+			return false
+		}
+		parameters, err := getParameters()
+		if err != nil {
+			return false
+		}
+		height, err := getHeight()
+		if err != nil {
+			return false
+		}
+		return parameters.Scheduled(height, *u) == nil
+	}
+}
+
+func VerifyProtocol(getParameters GetParametersFn, getBlockHeight GetBlockHeightFn) VerifyProtocolFn {
+	return func(block Block) error {
+		parameters, err := getParameters()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve chain parameters")
+		}
+		if block.Header.Prev == nil {
+			return parameters.Verify(1, block)
+		}
+		parent, err := getBlockHeight(block.Header.Prev)
+		switch {
+		case err != nil:
+			return errors.Wrapf(err, "Failed to retrieve height of block %x", block.Header.Prev)
+		case parent == 0:
+			return errors.Wrapf(ErrProtocolMismatch, "Block %x extends unknown block %x", block.Header.Hash, block.Header.Prev)
+		}
+		return parameters.Verify(parent+1, block)
+	}
+}
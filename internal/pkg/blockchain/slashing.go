@@ -0,0 +1,228 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+type forgeEvidence struct {
+	Height int   `json:"height"`
+	Block  Block `json:"block"`
+}
+
+func parseForgeEvidence(raw json.RawMessage) (forger []byte, evidence forgeEvidence, ok bool) {
+	var ping websocket.Ping
+	if err := json.Unmarshal(raw, &ping); err != nil {
+		return nil, evidence, false
+	}
+	if ping.Message != websocket.BlockForgedMessage || !ping.Verified() {
+		return nil, evidence, false
+	}
+	sender, err := base64.StdEncoding.DecodeString(ping.Sender)
+	if err != nil {
+		return nil, evidence, false
+	}
+	forger, err = wallet.HashedPublicKey(sender)
+	if err != nil {
+		return nil, evidence, false
+	}
+	if err := json.Unmarshal(ping.Body, &evidence); err != nil {
+		return nil, evidence, false
+	}
+	headerForger, err := evidence.Block.Header.ForgerHash()
+	if err != nil || !evidence.Block.Header.Verified() || bytes.Compare(headerForger, forger) != 0 {
+		return nil, evidence, false
+	}
+	return forger, evidence, true
+}
+
+type InvalidBlockEvidence struct {
+	Forged   json.RawMessage `json:"forged"`
+	Conflict *Block          `json:"conflict,omitempty"`
+}
+
+type ProveDoubleSpendFn func(forged json.RawMessage, block Block) (*InvalidBlockEvidence, error)
+
+func outputKey(in transaction.Input) string {
+	return fmt.Sprintf("%x:%d", in.TransactionID, in.Vout)
+}
+
+func spentOutputs(transactions transaction.Transactions) (map[string]bool, bool) {
+	spent := map[string]bool{}
+	for _, t := range transactions {
+		for _, in := range t.Inputs {
+			if in.Vout == -1 && (t.IsAuthority() || t.IsBase()) {
+				continue
+			}
+			if spent[outputKey(in)] {
+				return spent, true
+			}
+			spent[outputKey(in)] = true
+		}
+	}
+	return spent, false
+}
+
+func doubleSpends(block Block, conflict *Block) bool {
+	spent, twice := spentOutputs(block.Body.Transactions)
+	if twice || conflict == nil {
+		return twice
+	}
+	earlier, _ := spentOutputs(conflict.Body.Transactions)
+	for key := range earlier {
+		if spent[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (e InvalidBlockEvidence) verified(getBlockHeight GetBlockHeightFn, forged forgeEvidence) bool {
+	if _, ok := verifyHeader(forged.Block); !ok {
+		return false
+	}
+	if e.Conflict == nil {
+		return doubleSpends(forged.Block, nil)
+	}
+	if _, ok := verifyHeader(*e.Conflict); !ok {
+		return false
+	}
+	parent, err := getBlockHeight(forged.Block.Header.Prev)
+	if err != nil || parent == 0 || parent+1 != forged.Height {
+		return false
+	}
+	height, err := getBlockHeight(e.Conflict.Header.Hash)
+	if err != nil || height == 0 || height > parent {
+		return false
+	}
+	return doubleSpends(forged.Block, e.Conflict)
+}
+
+func ProveDoubleSpend(
+	getTransactionRecord GetTransactionRecordFn,
+	getAddressHistory GetAddressHistoryFn,
+	getBlock GetBlockFn,
+	getBlockHeight GetBlockHeightFn,
+) ProveDoubleSpendFn {
+	return func(forged json.RawMessage, block Block) (*InvalidBlockEvidence, error) {
+		if _, twice := spentOutputs(block.Body.Transactions); twice {
+			return &InvalidBlockEvidence{Forged: forged}, nil
+		}
+		parent, err := getBlockHeight(block.Header.Prev)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to retrieve height of block %x", block.Header.Prev)
+		}
+		for _, t := range block.Body.Transactions {
+			for _, in := range t.Inputs {
+				if in.Vout < 0 {
+					continue
+				}
+				created, err := getTransactionRecord(in.TransactionID)
+				switch {
+				case err != nil:
+					return nil, errors.Wrapf(err, "Failed to retrieve transaction %x", in.TransactionID)
+				case created == nil || in.Vout >= len(created.Transaction.Outputs):
+					continue
+				}
+				history, err := getAddressHistory(created.Transaction.Outputs[in.Vout].PublicKeyHash)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Failed to retrieve history of output %s", outputKey(in))
+				}
+				for _, record := range history {
+					if record.Height > parent {
+						continue
+					}
+					if spent, _ := spentOutputs(transaction.Transactions{record.Transaction}); !spent[outputKey(in)] {
+						continue
+					}
+					conflict, err := getBlock(record.Block)
+					switch {
+					case err != nil:
+						return nil, errors.Wrapf(err, "Failed to retrieve block %x", record.Block)
+					case conflict == nil:
+						return nil, errors.Errorf("Block %x of transaction %x does not exist", record.Block, record.Transaction.ID)
+					}
+					return &InvalidBlockEvidence{Forged: forged, Conflict: conflict}, nil
+				}
+			}
+		}
+		return nil, nil
+	}
+}
+
+func VerifySlashingEvidence(getBlockHeight GetBlockHeightFn, verifyEquivocation VerifyEquivocationFn) transaction.VerifyEvidenceFn {
+	return func(s transaction.Slashing) bool {
+		switch s.Offence {
+		case transaction.InvalidBlockOffence:
+			var proof InvalidBlockEvidence
+			if err := json.Unmarshal(s.Evidence, &proof); err != nil {
+				return false
+			}
+			forger, evidence, ok := parseForgeEvidence(proof.Forged)
+			if !ok || bytes.Compare(forger, s.Forger) != 0 {
+				return false
+			}
+			if evidence.Height != s.Height || bytes.Compare(evidence.Block.Header.Hash, s.BlockHash) != 0 {
+				return false
+			}
+			if len(evidence.Block.Body.Transactions) == 0 {
+				return false
+			}
+			if bytes.Compare(evidence.Block.Body.Transactions[0].ID, s.StakeTransactionID) != 0 {
+				return false
+			}
+			return proof.verified(getBlockHeight, evidence)
+		case transaction.EquivocationOffence:
+			var proof Equivocation
+			if err := json.Unmarshal(s.Evidence, &proof); err != nil {
+				return false
+			}
+			if !verifyEquivocation(proof) || bytes.Compare(proof.Forger, s.Forger) != 0 || proof.Height != s.Height {
+				return false
+			}
+			_, first, _ := parseForgeEvidence(proof.First)
+			return bytes.Compare(first.Block.Header.Hash, s.BlockHash) == 0
+		default:
+			return false
+		}
+	}
+}
+
+func slashedSender(isSlashed transaction.IsSlashedFn, sender string) (bool, error) {
+	rawPublicKey, err := base64.StdEncoding.DecodeString(sender)
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to decode sender %s", sender)
+	}
+	publicKeyHash, err := wallet.HashedPublicKey(rawPublicKey)
+	if err != nil {
+		return false, errors.Wrapf(err, "Failed to hash public key of %s", sender)
+	}
+	return isSlashed(publicKeyHash)
+}
+
+func SlashedAuthorizer(isSlashed transaction.IsSlashedFn) websocket.Authorizer {
+	return func(ping websocket.Ping) error {
+		switch slashed, err := slashedSender(isSlashed, ping.Sender); {
+		case err != nil:
+			return errors.Wrap(err, "Failed to check slashing")
+		case slashed:
+			return websocket.ErrUnauthorized(fmt.Sprintf("Node %s has been slashed", ping.Sender))
+		default:
+			return nil
+		}
+	}
+}
+
+func ExcludeSlashed(isSlashed transaction.IsSlashedFn) websocket.ExcludedFn {
+	return func(sender string) bool {
+		slashed, err := slashedSender(isSlashed, sender)
+		return err != nil || slashed
+	}
+}
@@ -0,0 +1,238 @@
+package blockchain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	SlotDuration  = 30 * time.Second
+	SlotsPerEpoch = 10
+	MaxClockDrift = 5 * time.Second
+)
+
+type SlotRole string
+
+const (
+	DesignatedForger SlotRole = "designated"
+	BackupForger     SlotRole = "backup"
+)
+
+var (
+	ErrSlotNotAssigned = errors.New("Slot is not assigned to the forger")
+	ErrSlotMissed      = errors.New("Deadline of the slot has passed")
+	ErrSlotFilled      = errors.New("Slot is already filled")
+)
+
+type Clock struct {
+	Genesis       time.Time
+	SlotDuration  time.Duration
+	SlotsPerEpoch int
+}
+
+type GetClockFn func() (*Clock, error)
+
+type VerifySlotFn func(Header) bool
+
+type SlotAssignment struct {
+	Slot       int              `json:"slot"`
+	Epoch      int              `json:"epoch"`
+	Height     int              `json:"height"`
+	Designated websocket.Forger `json:"designated"`
+	Backup     websocket.Forger `json:"backup"`
+	Deadline   time.Time        `json:"deadline"`
+	End        time.Time        `json:"end"`
+	Escalated  bool             `json:"escalated"`
+	Filled     bool             `json:"filled"`
+}
+
+type MissedSlot struct {
+	Slot   int       `json:"slot"`
+	Epoch  int       `json:"epoch"`
+	Height int       `json:"height"`
+	NodeID string    `json:"nodeId"`
+	Sender string    `json:"sender"`
+	Role   SlotRole  `json:"role"`
+	Time   time.Time `json:"time"`
+}
+
+type RecordMissedSlotFn func(MissedSlot) error
+
+type GetMissedSlotsFn func() ([]MissedSlot, error)
+
+type ClaimSlotFn func(slot int, sender string) error
+
+type ReleaseSlotFn func(slot int)
+
+type CurrentSlotFn func() *SlotAssignment
+
+func NewClock(genesis Header, parameters Parameters) Clock {
+	return Clock{
+		Genesis:       time.Unix(genesis.Timestamp, 0),
+		SlotDuration:  parameters.SlotDuration,
+		SlotsPerEpoch: parameters.SlotsPerEpoch,
+	}
+}
+
+func GetClock(getBlockByHeight GetBlockByHeightFn) GetClockFn {
+	return func() (*Clock, error) {
+		genesis, err := getBlockByHeight(1)
+		switch {
+		case err != nil:
+			return nil, errors.Wrap(err, "Failed to retrieve genesis block")
+		case genesis == nil:
+			return nil, errors.New("Genesis block does not exist")
+		}
+		parameters, err := GenesisParameters(*genesis)
+		if err != nil {
+			return nil, err
+		}
+		clock := NewClock(genesis.Header, parameters)
+		return &clock, nil
+	}
+}
+
+func (c Clock) SlotAt(t time.Time) int {
+	if t.Before(c.Genesis) {
+		return 0
+	}
+	return int(t.Sub(c.Genesis) / c.SlotDuration)
+}
+
+func (c Clock) Epoch(slot int) int {
+	return slot / c.SlotsPerEpoch
+}
+
+func (c Clock) Start(slot int) time.Time {
+	return c.Genesis.Add(time.Duration(slot) * c.SlotDuration)
+}
+
+func (c Clock) Deadline(slot int) time.Time {
+	return c.Start(slot).Add(c.SlotDuration / 2)
+}
+
+func (c Clock) End(slot int) time.Time {
+	return c.Start(slot + 1)
+}
+
+func (c Clock) Next(t time.Time) time.Time {
+	return c.Start(c.SlotAt(t) + 1)
+}
+
+func (c Clock) Assign(slot, height int, forgers []websocket.Forger) SlotAssignment {
+	return SlotAssignment{
+		Slot:       slot,
+		Epoch:      c.Epoch(slot),
+		Height:     height,
+		Designated: forgers[slot%len(forgers)],
+		Backup:     forgers[(slot+1)%len(forgers)],
+		Deadline:   c.Deadline(slot),
+		End:        c.End(slot),
+	}
+}
+
+func (a SlotAssignment) Missed(role SlotRole) MissedSlot {
+	forger := a.Designated
+	if role == BackupForger {
+		forger = a.Backup
+	}
+	return MissedSlot{
+		Slot:   a.Slot,
+		Epoch:  a.Epoch,
+		Height: a.Height,
+		NodeID: forger.NodeID,
+		Sender: forger.Sender,
+		Role:   role,
+		Time:   time.Now().UTC(),
+	}
+}
+
+func VerifySlot(getClock GetClockFn, getBlock GetBlockFn) VerifySlotFn {
+	return func(header Header) bool {
+		clock, err := getClock()
+		if err != nil {
+			return false
+		}
+		timestamp := time.Unix(header.Timestamp, 0)
+		if timestamp.After(time.Now().Add(MaxClockDrift)) || clock.SlotAt(timestamp) != header.Slot {
+			return false
+		}
+		prev, err := getBlock(header.Prev)
+		if err != nil || prev == nil {
+			return false
+		}
+		return header.Slot >= prev.Header.Slot && header.Timestamp >= prev.Header.Timestamp
+	}
+}
+
+type SlotTracker struct {
+	lock    *sync.Mutex
+	current *SlotAssignment
+}
+
+func NewSlotTracker() *SlotTracker {
+	return &SlotTracker{lock: &sync.Mutex{}}
+}
+
+func (t *SlotTracker) Assign(assignment SlotAssignment) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.current = &assignment
+}
+
+func (t *SlotTracker) Current() *SlotAssignment {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.current == nil {
+		return nil
+	}
+	current := *t.current
+	return &current
+}
+
+func (t *SlotTracker) Escalate(slot int) *SlotAssignment {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.current == nil || t.current.Slot != slot || t.current.Filled {
+		return nil
+	}
+	t.current.Escalated = true
+	current := *t.current
+	return &current
+}
+
+func (t *SlotTracker) IsFilled(slot int) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.current != nil && t.current.Slot == slot && t.current.Filled
+}
+
+func (t *SlotTracker) Claim(slot int, sender string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch {
+	case t.current == nil || t.current.Slot != slot:
+		return ErrSlotNotAssigned
+	case t.current.Filled:
+		return ErrSlotFilled
+	case time.Now().After(t.current.End):
+		return ErrSlotMissed
+	case t.current.Designated.Sender == sender:
+	case t.current.Escalated && t.current.Backup.Sender == sender:
+	default:
+		return ErrSlotNotAssigned
+	}
+	t.current.Filled = true
+	return nil
+}
+
+func (t *SlotTracker) Release(slot int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.current != nil && t.current.Slot == slot {
+		t.current.Filled = false
+	}
+}
@@ -0,0 +1,55 @@
+package blockchain
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidSnapshot = errors.New("Invalid snapshot")
+
+type SnapshotEntry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type Snapshot struct {
+	Genesis Block                      `json:"genesis"`
+	Headers []Header                   `json:"headers"`
+	Buckets map[string][]SnapshotEntry `json:"buckets"`
+}
+
+type ExportSnapshotFn func() (*Snapshot, error)
+
+type ImportSnapshotFn func(Snapshot) error
+
+func (s Snapshot) Tip() Header {
+	return s.Headers[len(s.Headers)-1]
+}
+
+func (s Snapshot) Height() int {
+	return len(s.Headers)
+}
+
+func PrunedBlock(rules Rules, header Header) Block {
+	return Block{
+		Metadata: Metadata{MagicNumber: rules.MagicNumber},
+		Header:   header,
+	}
+}
+
+func VerifySnapshot(genesisHash []byte, snapshot Snapshot, checkpoint *Checkpoint) error {
+	if len(snapshot.Headers) == 0 {
+		return errors.Wrap(ErrInvalidSnapshot, "Snapshot contains no headers")
+	}
+	if err := VerifyHeaderChain(genesisHash, nil, 1, snapshot.Headers, checkpoint); err != nil {
+		return err
+	}
+	if _, ok := verifyHeader(snapshot.Genesis); !ok || bytes.Compare(snapshot.Genesis.Header.Hash, genesisHash) != 0 {
+		return errors.Wrapf(ErrInvalidSnapshot, "Snapshot genesis block %x doesn't match its header", snapshot.Genesis.Header.Hash)
+	}
+	if checkpoint != nil && checkpoint.Height == snapshot.Height() && bytes.Compare(snapshot.Tip().UTXORoot, checkpoint.UTXORoot) != 0 {
+		return errors.Wrapf(ErrCheckpointConflict, "Snapshot state root is %x instead of %x", snapshot.Tip().UTXORoot, checkpoint.UTXORoot)
+	}
+	return nil
+}
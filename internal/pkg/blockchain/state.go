@@ -0,0 +1,93 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"sort"
+)
+
+const stateDigestSize = 384
+
+var stateModulus = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 8*stateDigestSize), big.NewInt(1103717))
+
+func writeStateField(hash io.Writer, field []byte) {
+	length := make([]byte, 8)
+	binary.BigEndian.PutUint64(length, uint64(len(field)))
+	hash.Write(length)
+	hash.Write(field)
+}
+
+func stateElement(bucket []byte, entry SnapshotEntry) *big.Int {
+	seed := sha256.New()
+	writeStateField(seed, bucket)
+	writeStateField(seed, entry.Key)
+	writeStateField(seed, entry.Value)
+	prefix := seed.Sum(nil)
+	expanded := make([]byte, 0, stateDigestSize)
+	for counter := uint32(0); len(expanded) < stateDigestSize; counter++ {
+		block := sha256.New()
+		block.Write(prefix)
+		binary.Write(block, binary.BigEndian, counter)
+		expanded = block.Sum(expanded)
+	}
+	element := new(big.Int).SetBytes(expanded)
+	return element.Mod(element, stateModulus)
+}
+
+func encodeStateDigest(digest *big.Int) []byte {
+	raw := digest.Bytes()
+	encoded := make([]byte, stateDigestSize)
+	copy(encoded[stateDigestSize-len(raw):], raw)
+	return encoded
+}
+
+func decodeStateDigest(digest []byte) *big.Int {
+	if len(digest) == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).SetBytes(digest)
+}
+
+func EmptyStateDigest() []byte {
+	return encodeStateDigest(big.NewInt(1))
+}
+
+func AddStateEntry(digest, bucket []byte, entry SnapshotEntry) []byte {
+	added := decodeStateDigest(digest)
+	added.Mul(added, stateElement(bucket, entry))
+	return encodeStateDigest(added.Mod(added, stateModulus))
+}
+
+func RemoveStateEntry(digest, bucket []byte, entry SnapshotEntry) []byte {
+	removed := decodeStateDigest(digest)
+	removed.Mul(removed, new(big.Int).ModInverse(stateElement(bucket, entry), stateModulus))
+	return encodeStateDigest(removed.Mod(removed, stateModulus))
+}
+
+func StateDigest(bucket []byte, entries []SnapshotEntry) []byte {
+	digest := EmptyStateDigest()
+	for _, entry := range entries {
+		digest = AddStateEntry(digest, bucket, entry)
+	}
+	return digest
+}
+
+func StateRoot(digests map[string][]byte) []byte {
+	empty := EmptyStateDigest()
+	names := make([]string, 0, len(digests))
+	for name, digest := range digests {
+		if len(digest) > 0 && bytes.Compare(digest, empty) != 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, name := range names {
+		writeStateField(hash, []byte(name))
+		writeStateField(hash, digests[name])
+	}
+	return hash.Sum(nil)
+}
@@ -0,0 +1,104 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+const MaxHeadersPerRequest = 100
+
+var (
+	ErrInvalidHeaderChain = errors.New("Invalid header chain")
+	ErrGenesisMismatch    = errors.New("Genesis block doesn't match the pinned genesis hash")
+)
+
+type GetHeadersFn func(from, count int) ([]Header, error)
+
+type VerifySyncedBlockFn func(height int, block Block) error
+
+func VerifyHeaderChain(genesisHash, prev []byte, height int, headers []Header, checkpoint *Checkpoint) error {
+	for i, header := range headers {
+		current := height + i
+		if current == 1 && bytes.Compare(header.Hash, genesisHash) != 0 {
+			return errors.Wrapf(ErrGenesisMismatch, "Genesis block is %x instead of %x", header.Hash, genesisHash)
+		}
+		if bytes.Compare(header.Prev, prev) != 0 {
+			return errors.Wrapf(ErrInvalidHeaderChain, "Header %x at height %d does not link to %x", header.Hash, current, prev)
+		}
+		if !header.Verified() {
+			return errors.Wrapf(ErrInvalidHeaderChain, "Header %x at height %d is not signed by its forger", header.Hash, current)
+		}
+		if checkpoint != nil && checkpoint.Height == current && bytes.Compare(header.Hash, checkpoint.Hash) != 0 {
+			return errors.Wrapf(ErrCheckpointConflict, "Block at height %d is %x instead of %x", current, header.Hash, checkpoint.Hash)
+		}
+		prev = header.Hash
+	}
+	return nil
+}
+
+func VerifySyncedBlock(
+	genesisHash, alfaKeyHash []byte,
+	verifyTransaction transaction.VerifyTransctionFn,
+	isStakeTransaction transaction.IsStakeTransactionFn,
+	verifySlot VerifySlotFn,
+	verifyBlockRules VerifyBlockFn,
+	getParameters GetParametersFn,
+) VerifySyncedBlockFn {
+	return func(height int, block Block) error {
+		invalid := func(format string, args ...interface{}) error {
+			return errors.Wrapf(ErrInvalidBlock, "Block %x at height %d %s", block.Header.Hash, height, fmt.Sprintf(format, args...))
+		}
+		forger, ok := verifyHeader(block)
+		if !ok {
+			return invalid("has an invalid hash, signature or transaction hash")
+		}
+		authority := bytes.Compare(forger, alfaKeyHash) == 0
+		if height == 1 {
+			if bytes.Compare(block.Header.Hash, genesisHash) != 0 {
+				return errors.Wrapf(ErrGenesisMismatch, "Genesis block is %x instead of %x", block.Header.Hash, genesisHash)
+			}
+			if !authority {
+				return invalid("is not forged by the alfa node")
+			}
+			parameters, err := GenesisParameters(block)
+			if err != nil {
+				return err
+			}
+			return parameters.Verify(height, block)
+		}
+		parameters, err := getParameters()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve chain parameters")
+		}
+		if err := parameters.Verify(height, block); err != nil {
+			return err
+		}
+		if !verifySlot(block.Header) {
+			return invalid("is not forged in its slot %d", block.Header.Slot)
+		}
+		for i, t := range block.Body.Transactions {
+			if t.IsBase() && !t.IsAuthority() {
+				if !authority {
+					return invalid("mints transaction %x at position %d without being forged by the alfa node", t.ID, i)
+				}
+				continue
+			}
+			if !verifyTransaction(t) {
+				return invalid("contains invalid transaction %x at position %d", t.ID, i)
+			}
+		}
+		if !verifyBlockRules(block) {
+			return invalid("breaks the rules for transactions of slot %d", block.Header.Slot)
+		}
+		if authority {
+			return nil
+		}
+		if len(block.Body.Transactions) == 0 || !isStakeTransaction(block.Body.Transactions[0]) || !block.Body.Transactions[0].AreInputsFrom(forger) {
+			return invalid("doesn't start with a stake of its forger")
+		}
+		return nil
+	}
+}
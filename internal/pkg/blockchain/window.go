@@ -0,0 +1,70 @@
+package blockchain
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+type IsClosedAtSlotFn func(slot int) (bool, error)
+
+func isClosedAtSlot(getClock GetClockFn, schedule *election.Schedule, slot int) (bool, error) {
+	if schedule == nil {
+		return false, nil
+	}
+	clock, err := getClock()
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to retrieve slot clock")
+	}
+	return schedule.IsClosed(clock.Start(slot)), nil
+}
+
+func IsRecastClosed(getClock GetClockFn, getSchedule transaction.GetRecastScheduleFn) IsClosedAtSlotFn {
+	return func(slot int) (bool, error) {
+		schedule, err := getSchedule()
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to retrieve recast schedule")
+		}
+		return isClosedAtSlot(getClock, schedule, slot)
+	}
+}
+
+func IsElectionClosed(getClock GetClockFn, getSchedule election.GetScheduleFn) IsClosedAtSlotFn {
+	return func(slot int) (bool, error) {
+		schedule, err := getSchedule()
+		if err != nil {
+			return false, errors.Wrap(err, "Failed to retrieve election schedule")
+		}
+		return isClosedAtSlot(getClock, schedule, slot)
+	}
+}
+
+func VerifyRecastWindow(isRecastClosed IsClosedAtSlotFn) VerifyBlockFn {
+	return func(block Block) bool {
+		closed, err := isRecastClosed(block.Header.Slot)
+		if err != nil {
+			return false
+		}
+		for _, t := range block.Body.Transactions {
+			if !t.InRecastWindow(closed) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func VerifyElectionWindow(isElectionClosed IsClosedAtSlotFn) VerifyBlockFn {
+	return func(block Block) bool {
+		closed, err := isElectionClosed(block.Header.Slot)
+		if err != nil {
+			return false
+		}
+		for _, t := range block.Body.Transactions {
+			if !t.InElectionWindow(closed) {
+				return false
+			}
+		}
+		return true
+	}
+}
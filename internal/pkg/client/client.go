@@ -0,0 +1,306 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/party"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultBaseURL = "http://localhost:8000"
+	DefaultTimeout = 10 * time.Second
+)
+
+type Client struct {
+	baseURL string
+	http    *http.Client
+	stream  *http.Client
+}
+
+type Event struct {
+	Name string
+	Data json.RawMessage
+}
+
+type EventHandlerFn func(Event) error
+
+func New(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: timeout},
+		stream:  &http.Client{},
+	}
+}
+
+func (c *Client) url(path string, query url.Values) string {
+	if len(query) == 0 {
+		return c.baseURL + path
+	}
+	return fmt.Sprintf("%s%s?%s", c.baseURL, path, query.Encode())
+}
+
+func (c *Client) do(request *http.Request, result interface{}) error {
+	response, err := c.http.Do(request)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to call %s", request.URL)
+	}
+	defer response.Body.Close()
+	raw, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read response of %s", request.URL)
+	}
+	if response.StatusCode != http.StatusOK {
+		return responseError(response, raw)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return errors.Wrapf(err, "Failed to unmarshal response %s", raw)
+	}
+	return nil
+}
+
+func (c *Client) get(path string, query url.Values, result interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, c.url(path, query), nil)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create request to %s", path)
+	}
+	return c.do(request, result)
+}
+
+func (c *Client) post(path string, payload interface{}, result interface{}) error {
+	return c.send(http.MethodPost, path, payload, result)
+}
+
+func (c *Client) send(method, path string, payload interface{}, result interface{}) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to marshal payload %#v", payload)
+	}
+	request, err := http.NewRequest(method, c.url(path, nil), bytes.NewReader(raw))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create request to %s", path)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	return c.do(request, result)
+}
+
+func (c *Client) Vote(vote api.Vote) error {
+	return c.post("/vote", vote, nil)
+}
+
+func (c *Client) Parties() (party.Parties, error) {
+	var parties party.Parties
+	if err := c.get("/parties", nil, &parties); err != nil {
+		return nil, err
+	}
+	return parties, nil
+}
+
+func (c *Client) Election() (*api.Election, error) {
+	var result api.Election
+	if err := c.get("/election", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Ring() ([][]byte, error) {
+	var ring [][]byte
+	if err := c.get("/ring", nil, &ring); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+func (c *Client) RecastSequence(address string) (int, error) {
+	var result api.RecastSequence
+	if err := c.get("/recasts/"+url.PathEscape(address), nil, &result); err != nil {
+		return 0, err
+	}
+	return result.Sequence, nil
+}
+
+func (c *Client) TokenCommitment(request api.TokenCommitmentRequest) ([]byte, error) {
+	var result api.TokenCommitmentResponse
+	if err := c.post("/tokens/commitment", request, &result); err != nil {
+		return nil, err
+	}
+	return result.Commitment, nil
+}
+
+func (c *Client) IssueToken(request api.IssueTokenRequest) ([]byte, error) {
+	var result api.IssueTokenResponse
+	if err := c.post("/tokens", request, &result); err != nil {
+		return nil, err
+	}
+	return result.BlindSignature, nil
+}
+
+func (c *Client) Status() (*api.Status, error) {
+	var result api.Status
+	if err := c.get("/status", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RegisterParty(request api.AdminRequest) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.post("/admin/parties", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) UpdateParty(request api.AdminRequest) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.send(http.MethodPut, "/admin/parties/"+url.PathEscape(request.Address), request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) DeactivateParty(request api.AdminRequest) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.post(fmt.Sprintf("/admin/parties/%s/deactivation", url.PathEscape(request.Address)), request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) RegisterVoter(request api.AdminRequest) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.post("/admin/voters", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) ScheduleUpgrade(request api.AdminRequest) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.post("/admin/upgrades", request, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Head() (*api.Head, error) {
+	var result api.Head
+	if err := c.get("/head", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Blocks(start, limit int) (*api.BlockPage, error) {
+	query := url.Values{}
+	if start > 0 {
+		query.Set("start", strconv.Itoa(start))
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var result api.BlockPage
+	if err := c.get("/blocks", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BlockByHeight(height int) (*api.Block, error) {
+	var result api.Block
+	if err := c.get(fmt.Sprintf("/blocks/%d", height), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) BlockByHash(hash string) (*api.Block, error) {
+	var result api.Block
+	if err := c.get("/blocks/"+url.PathEscape(hash), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Transaction(id string) (*api.Transaction, error) {
+	var result api.Transaction
+	if err := c.get("/transactions/"+url.PathEscape(id), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) PendingTransactions() ([]api.Transaction, error) {
+	var result []api.Transaction
+	if err := c.get("/transactions/pending", nil, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) AddressUTXOs(address string) (*api.AddressUTXOs, error) {
+	var result api.AddressUTXOs
+	if err := c.get(fmt.Sprintf("/addresses/%s/utxos", url.PathEscape(address)), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) AddressHistory(address string) (*api.AddressHistory, error) {
+	var result api.AddressHistory
+	if err := c.get(fmt.Sprintf("/addresses/%s/transactions", url.PathEscape(address)), nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Events(handle EventHandlerFn) error {
+	request, err := http.NewRequest(http.MethodGet, c.url("/events", nil), nil)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create events request")
+	}
+	response, err := c.stream.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "Failed to subscribe to events")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		raw, _ := ioutil.ReadAll(response.Body)
+		return responseError(response, raw)
+	}
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var event Event
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event.Name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = json.RawMessage(strings.TrimPrefix(line, "data: "))
+		case line == "" && event.Name != "":
+			if err := handle(event); err != nil {
+				return err
+			}
+			event = Event{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "Failed to read events")
+	}
+	return errors.New("Event stream closed")
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+)
+
+var (
+	ErrInternalServer        = failure.New(failure.Internal)
+	ErrInvalidData           = failure.New(failure.InvalidData)
+	ErrUnauthorized          = failure.New(failure.Unauthorized)
+	ErrNotFound              = failure.New(failure.NotFound)
+	ErrUserAlreadyVoted      = failure.New(failure.UserAlreadyVoted)
+	ErrVoterRevoked          = failure.New(failure.VoterRevoked)
+	ErrBallotSpent           = failure.New(failure.BallotSpent)
+	ErrInvalidReplacement    = failure.New(failure.InvalidReplacement)
+	ErrAmbiguousBallot       = failure.New(failure.AmbiguousBallot)
+	ErrTokenNotRequested     = failure.New(failure.TokenNotRequested)
+	ErrTokenAlreadyUsed      = failure.New(failure.TokenAlreadyUsed)
+	ErrTokenSessionOpen      = failure.New(failure.TokenSessionOpen)
+	ErrBallotPoolEmpty       = failure.New(failure.BallotPoolEmpty)
+	ErrElectionClosed        = failure.New(failure.ElectionClosed)
+	ErrElectionOpen          = failure.New(failure.ElectionOpen)
+	ErrBallotsPending        = failure.New(failure.BallotsPending)
+	ErrTallyPublished        = failure.New(failure.TallyPublished)
+	ErrRecastPending         = failure.New(failure.RecastPending)
+	ErrInvalidRecastSequence = failure.New(failure.InvalidRecastSequence)
+	ErrVoterRegistered       = failure.New(failure.VoterRegistered)
+	ErrPartyExists           = failure.New(failure.PartyExists)
+	ErrPartyInactive         = failure.New(failure.PartyInactive)
+	ErrPartyPending          = failure.New(failure.PartyPending)
+	ErrUpgradePending        = failure.New(failure.UpgradePending)
+)
+
+func responseError(response *http.Response, raw []byte) error {
+	var envelope failure.Envelope
+	if err := json.Unmarshal(raw, &envelope); err == nil && envelope.Error.Code != "" {
+		return envelope.Error
+	}
+	e := failure.Newf(failure.Internal, "Request failed with status %d: %s", response.StatusCode, raw)
+	e.Status = response.StatusCode
+	e.Retryable = response.StatusCode >= http.StatusInternalServerError
+	e.CorrelationID = response.Header.Get(api.CorrelationIDHeader)
+	return e
+}
+
+func IsRetryable(err error) bool {
+	e, ok := failure.From(err)
+	return ok && e.Retryable
+}
@@ -0,0 +1,154 @@
+package election
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/pkg/errors"
+)
+
+type Phase int
+
+const (
+	VotingPhase Phase = iota
+	ClosedPhase
+	TalliedPhase
+)
+
+func (p Phase) String() string {
+	switch p {
+	case VotingPhase:
+		return "voting"
+	case ClosedPhase:
+		return "closed"
+	case TalliedPhase:
+		return "tallied"
+	default:
+		return fmt.Sprintf("Unknown phase %d", p)
+	}
+}
+
+func (p Phase) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Phase) UnmarshalText(text []byte) error {
+	for _, phase := range []Phase{VotingPhase, ClosedPhase, TalliedPhase} {
+		if phase.String() == string(text) {
+			*p = phase
+			return nil
+		}
+	}
+	return errors.Errorf("Unknown phase %s", text)
+}
+
+type Schedule struct {
+	Closes time.Time `json:"closes"`
+}
+
+func (s Schedule) IsClosed(now time.Time) bool {
+	return !s.Closes.IsZero() && !now.Before(s.Closes)
+}
+
+type TrusteeDecryption struct {
+	Trustee     int                                  `json:"trustee"`
+	Decryptions map[string]elgamal.PartialDecryption `json:"decryptions"`
+}
+
+type EncryptedTally struct {
+	Ballots     int                           `json:"ballots"`
+	Ciphertexts map[string]elgamal.Ciphertext `json:"ciphertexts"`
+}
+
+type Tally struct {
+	Votes       map[string]int      `json:"votes"`
+	Encrypted   EncryptedTally      `json:"encrypted"`
+	Decryptions []TrusteeDecryption `json:"decryptions"`
+}
+
+type GetElectionKeyFn func() (*elgamal.ElectionKey, error)
+
+type GetEncryptedTallyFn func() (EncryptedTally, error)
+
+type SaveDecryptionFn func(TrusteeDecryption) ([]TrusteeDecryption, error)
+
+type GetTallyFn func() (*Tally, error)
+
+type SaveTallyFn func(Tally) error
+
+type IsClosedFn func() bool
+
+type GetScheduleFn func() (*Schedule, error)
+
+type GetPhaseFn func() (Phase, error)
+
+type HasPendingBallotsFn func() (bool, error)
+
+var ErrElectionClosed = errors.New("Election is closed")
+
+var ErrElectionOpen = errors.New("Election is still open")
+
+func IsClosed(schedule Schedule) IsClosedFn {
+	return func() bool {
+		return schedule.IsClosed(time.Now())
+	}
+}
+
+func GetPhase(schedule Schedule, getTally GetTallyFn) GetPhaseFn {
+	return func() (Phase, error) {
+		tally, err := getTally()
+		switch {
+		case err != nil:
+			return VotingPhase, errors.Wrap(err, "Failed to retrieve tally")
+		case tally != nil:
+			return TalliedPhase, nil
+		case schedule.IsClosed(time.Now()):
+			return ClosedPhase, nil
+		default:
+			return VotingPhase, nil
+		}
+	}
+}
+
+func (t TrusteeDecryption) Verify(key elgamal.ElectionKey, encrypted EncryptedTally) bool {
+	if len(t.Decryptions) != len(encrypted.Ciphertexts) {
+		return false
+	}
+	for party, c := range encrypted.Ciphertexts {
+		pd, ok := t.Decryptions[party]
+		if !ok || pd.Trustee != t.Trustee || !key.VerifyPartialDecryption(c, pd) {
+			return false
+		}
+	}
+	return true
+}
+
+func Decrypt(key elgamal.ElectionKey, encrypted EncryptedTally, decryptions []TrusteeDecryption) (*Tally, error) {
+	valid := []TrusteeDecryption{}
+	for _, d := range decryptions {
+		if d.Verify(key, encrypted) {
+			valid = append(valid, d)
+		}
+	}
+	if len(valid) < key.Threshold {
+		return nil, elgamal.ErrNotEnoughShares
+	}
+	votes := map[string]int{}
+	for party, c := range encrypted.Ciphertexts {
+		partials := make([]elgamal.PartialDecryption, 0, len(valid))
+		for _, d := range valid {
+			partials = append(partials, d.Decryptions[party])
+		}
+		count, err := key.Combine(c, partials, encrypted.Ballots)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to decrypt tally of %s", party)
+		}
+		votes[party] = count
+	}
+	return &Tally{
+		Votes:       votes,
+		Encrypted:   encrypted,
+		Decryptions: valid,
+	}, nil
+}
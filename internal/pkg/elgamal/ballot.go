@@ -0,0 +1,123 @@
+package elgamal
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+type EncryptedChoice struct {
+	Party      string     `json:"party"`
+	Ciphertext Ciphertext `json:"ciphertext"`
+	Proof      BitProof   `json:"proof"`
+}
+
+type EncryptedBallot struct {
+	Choices  []EncryptedChoice `json:"choices"`
+	SumProof DLEQProof         `json:"sumProof"`
+}
+
+func NewEncryptedBallot(key ElectionKey, voter []byte, parties []string, choice string) (*EncryptedBallot, error) {
+	publicKey := key.PublicKey
+	context := key.ballotContext(voter)
+	yx, yy, err := decodePoint(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid election public key")
+	}
+	ballot := EncryptedBallot{}
+	randomness := new(big.Int)
+	chosen := false
+	for _, party := range parties {
+		m := 0
+		if party == choice {
+			m = 1
+			chosen = true
+		}
+		c, r, err := encrypt(publicKey, m)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to encrypt choice for %s", party)
+		}
+		proof, err := proveBit(context, publicKey, *c, m, r)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to prove choice for %s", party)
+		}
+		ballot.Choices = append(ballot.Choices, EncryptedChoice{
+			Party:      party,
+			Ciphertext: *c,
+			Proof:      *proof,
+		})
+		randomness.Add(randomness, r)
+	}
+	if !chosen {
+		return nil, errors.Errorf("Choice %s is not among the parties", choice)
+	}
+	ax, ay, shiftedX, shiftedY, err := ballot.sum()
+	if err != nil {
+		return nil, err
+	}
+	gx, gy := generator()
+	sumProof, err := proveDLEQ(context, gx, gy, ax, ay, yx, yy, shiftedX, shiftedY, randomness.Mod(randomness, order()))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prove that ballot contains a single choice")
+	}
+	ballot.SumProof = *sumProof
+	return &ballot, nil
+}
+
+func (b EncryptedBallot) sum() (ax, ay, shiftedX, shiftedY *big.Int, err error) {
+	total := Ciphertext{}
+	for _, choice := range b.Choices {
+		if total, err = total.Add(choice.Ciphertext); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	ax, ay, bx, by, err := total.points()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	gx, gy := generator()
+	shiftedX, shiftedY = sub(bx, by, gx, gy)
+	return ax, ay, shiftedX, shiftedY, nil
+}
+
+func (b EncryptedBallot) Verify(key ElectionKey, voter []byte) bool {
+	publicKey := key.PublicKey
+	context := key.ballotContext(voter)
+	yx, yy, err := decodePoint(publicKey)
+	if err != nil || len(b.Choices) == 0 {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, choice := range b.Choices {
+		if seen[choice.Party] || !verifyBit(context, publicKey, choice.Ciphertext, choice.Proof) {
+			return false
+		}
+		seen[choice.Party] = true
+	}
+	ax, ay, shiftedX, shiftedY, err := b.sum()
+	if err != nil {
+		return false
+	}
+	gx, gy := generator()
+	return verifyDLEQ(context, gx, gy, ax, ay, yx, yy, shiftedX, shiftedY, b.SumProof)
+}
+
+func (b EncryptedBallot) Parties() (parties []string) {
+	for _, choice := range b.Choices {
+		parties = append(parties, choice.Party)
+	}
+	return
+}
+
+func (b EncryptedBallot) Serialized() ([]byte, error) {
+	return json.Marshal(b)
+}
+
+func ParseBallot(raw []byte) (*EncryptedBallot, error) {
+	var ballot EncryptedBallot
+	if err := json.Unmarshal(raw, &ballot); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal encrypted ballot")
+	}
+	return &ballot, nil
+}
@@ -0,0 +1,77 @@
+package elgamal
+
+import (
+	"testing"
+)
+
+func newTestKey(t *testing.T, trustees, threshold int) (ElectionKey, []Share) {
+	key, shares, err := GenerateElectionKey(trustees, threshold)
+	if err != nil {
+		t.Fatalf("Failed to generate election key: %s", err)
+	}
+	return *key, shares
+}
+
+func newTestBallot(t *testing.T, key ElectionKey, voter []byte) EncryptedBallot {
+	ballot, err := NewEncryptedBallot(key, voter, []string{"a", "b", "c"}, "b")
+	if err != nil {
+		t.Fatalf("Failed to create ballot: %s", err)
+	}
+	return *ballot
+}
+
+func TestBallotVerifies(t *testing.T) {
+	key, _ := newTestKey(t, 3, 2)
+	voter := []byte("voter")
+	if !newTestBallot(t, key, voter).Verify(key, voter) {
+		t.Fatal("Valid ballot is rejected")
+	}
+}
+
+func TestBallotIsBoundToVoterAndElection(t *testing.T) {
+	key, _ := newTestKey(t, 3, 2)
+	other, _ := newTestKey(t, 3, 2)
+	ballot := newTestBallot(t, key, []byte("voter"))
+	if ballot.Verify(key, []byte("another voter")) {
+		t.Fatal("Ballot is accepted for another voter")
+	}
+	other.PublicKey = key.PublicKey
+	if ballot.Verify(other, []byte("voter")) {
+		t.Fatal("Ballot is accepted for another election")
+	}
+}
+
+func TestBallotRejectsTamperedProofs(t *testing.T) {
+	key, _ := newTestKey(t, 3, 2)
+	voter := []byte("voter")
+	ballot := newTestBallot(t, key, voter)
+	tampered := newTestBallot(t, key, voter)
+	tampered.Choices[0].Proof = ballot.Choices[1].Proof
+	if tampered.Verify(key, voter) {
+		t.Fatal("Ballot with a swapped bit proof is accepted")
+	}
+	tampered = newTestBallot(t, key, voter)
+	tampered.SumProof = ballot.SumProof
+	if tampered.Verify(key, voter) {
+		t.Fatal("Ballot with a foreign sum proof is accepted")
+	}
+	tampered = newTestBallot(t, key, voter)
+	tampered.Choices[0].Ciphertext, tampered.Choices[1].Ciphertext = tampered.Choices[1].Ciphertext, tampered.Choices[0].Ciphertext
+	if tampered.Verify(key, voter) {
+		t.Fatal("Ballot with swapped ciphertexts is accepted")
+	}
+}
+
+func TestBallotRejectsDoubleChoice(t *testing.T) {
+	key, _ := newTestKey(t, 3, 2)
+	voter := []byte("voter")
+	first := newTestBallot(t, key, voter)
+	second, err := NewEncryptedBallot(key, voter, []string{"a", "b", "c"}, "a")
+	if err != nil {
+		t.Fatalf("Failed to create ballot: %s", err)
+	}
+	first.Choices[0] = second.Choices[0]
+	if first.Verify(key, voter) {
+		t.Fatal("Ballot with two choices is accepted")
+	}
+}
@@ -0,0 +1,121 @@
+package elgamal
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+type PartialDecryption struct {
+	Trustee    int       `json:"trustee"`
+	Decryption []byte    `json:"decryption"`
+	Proof      DLEQProof `json:"proof"`
+}
+
+var ErrNotEnoughShares = errors.New("Not enough valid partial decryptions")
+
+var ErrTallyOutOfRange = errors.New("Decrypted value is out of range")
+
+func PartiallyDecrypt(key ElectionKey, share Share, c Ciphertext) (*PartialDecryption, error) {
+	ax, ay, _, _, err := c.points()
+	if err != nil {
+		return nil, err
+	}
+	secret := new(big.Int).SetBytes(share.Secret)
+	vx, vy, err := decodePoint(share.VerificationKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid verification key")
+	}
+	dx, dy := mul(ax, ay, secret)
+	gx, gy := generator()
+	proof, err := proveDLEQ(key.ID(), gx, gy, vx, vy, ax, ay, dx, dy, secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to prove partial decryption")
+	}
+	return &PartialDecryption{
+		Trustee:    share.Index,
+		Decryption: encodePoint(dx, dy),
+		Proof:      *proof,
+	}, nil
+}
+
+func (k ElectionKey) VerifyPartialDecryption(c Ciphertext, pd PartialDecryption) bool {
+	trustee, ok := k.Trustee(pd.Trustee)
+	if !ok {
+		return false
+	}
+	ax, ay, _, _, err := c.points()
+	if err != nil {
+		return false
+	}
+	vx, vy, err := decodePoint(trustee.VerificationKey)
+	if err != nil {
+		return false
+	}
+	dx, dy, err := decodePoint(pd.Decryption)
+	if err != nil {
+		return false
+	}
+	gx, gy := generator()
+	return verifyDLEQ(k.ID(), gx, gy, vx, vy, ax, ay, dx, dy, pd.Proof)
+}
+
+func lagrangeCoefficient(index int, indexes []int) *big.Int {
+	numerator := big.NewInt(1)
+	denominator := big.NewInt(1)
+	for _, other := range indexes {
+		if other == index {
+			continue
+		}
+		numerator.Mul(numerator, big.NewInt(int64(other)))
+		denominator.Mul(denominator, big.NewInt(int64(other-index)))
+	}
+	denominator.Mod(denominator, order())
+	numerator.Mul(numerator, new(big.Int).ModInverse(denominator, order()))
+	return numerator.Mod(numerator, order())
+}
+
+func (k ElectionKey) Combine(c Ciphertext, decryptions []PartialDecryption, max int) (int, error) {
+	valid := []PartialDecryption{}
+	seen := map[int]bool{}
+	for _, pd := range decryptions {
+		if seen[pd.Trustee] || !k.VerifyPartialDecryption(c, pd) {
+			continue
+		}
+		seen[pd.Trustee] = true
+		valid = append(valid, pd)
+		if len(valid) == k.Threshold {
+			break
+		}
+	}
+	if len(valid) < k.Threshold {
+		return 0, ErrNotEnoughShares
+	}
+	indexes := make([]int, 0, len(valid))
+	for _, pd := range valid {
+		indexes = append(indexes, pd.Trustee)
+	}
+	sx, sy := new(big.Int), new(big.Int)
+	for _, pd := range valid {
+		dx, dy, err := decodePoint(pd.Decryption)
+		if err != nil {
+			return 0, err
+		}
+		lx, ly := mul(dx, dy, lagrangeCoefficient(pd.Trustee, indexes))
+		sx, sy = add(sx, sy, lx, ly)
+	}
+	_, _, bx, by, err := c.points()
+	if err != nil {
+		return 0, err
+	}
+	mx, my := sub(bx, by, sx, sy)
+	gx, gy := generator()
+	cx, cy := new(big.Int), new(big.Int)
+	for m := 0; m <= max; m++ {
+		if cx.Cmp(mx) == 0 && cy.Cmp(my) == 0 {
+			return m, nil
+		}
+		cx, cy = add(cx, cy, gx, gy)
+	}
+	return 0, ErrTallyOutOfRange
+}
@@ -0,0 +1,101 @@
+package elgamal
+
+import (
+	"testing"
+)
+
+func encryptedTally(t *testing.T, key ElectionKey, votes int) Ciphertext {
+	total := Ciphertext{}
+	for i := 0; i < votes; i++ {
+		c, _, err := encrypt(key.PublicKey, 1)
+		if err != nil {
+			t.Fatalf("Failed to encrypt vote: %s", err)
+		}
+		if total, err = total.Add(*c); err != nil {
+			t.Fatalf("Failed to add vote: %s", err)
+		}
+	}
+	return total
+}
+
+func partialDecryptions(t *testing.T, key ElectionKey, shares []Share, c Ciphertext) []PartialDecryption {
+	decryptions := make([]PartialDecryption, 0, len(shares))
+	for _, share := range shares {
+		pd, err := PartiallyDecrypt(key, share, c)
+		if err != nil {
+			t.Fatalf("Failed to decrypt with share %d: %s", share.Index, err)
+		}
+		decryptions = append(decryptions, *pd)
+	}
+	return decryptions
+}
+
+func subsets(n, k int) [][]int {
+	if k == 0 {
+		return [][]int{{}}
+	}
+	if n < k {
+		return nil
+	}
+	result := subsets(n-1, k)
+	for _, s := range subsets(n-1, k-1) {
+		result = append(result, append(s, n-1))
+	}
+	return result
+}
+
+func TestPartialDecryptionProof(t *testing.T) {
+	key, shares := newTestKey(t, 3, 2)
+	c := encryptedTally(t, key, 2)
+	pd := partialDecryptions(t, key, shares[:1], c)[0]
+	if !key.VerifyPartialDecryption(c, pd) {
+		t.Fatal("Valid partial decryption is rejected")
+	}
+	forged := pd
+	forged.Trustee = shares[1].Index
+	if key.VerifyPartialDecryption(c, forged) {
+		t.Fatal("Partial decryption is accepted for another trustee")
+	}
+	forged = pd
+	forged.Decryption = partialDecryptions(t, key, shares[1:2], c)[0].Decryption
+	if key.VerifyPartialDecryption(c, forged) {
+		t.Fatal("Partial decryption with a foreign decryption is accepted")
+	}
+	other, _ := newTestKey(t, 3, 2)
+	other.Trustees = key.Trustees
+	if other.VerifyPartialDecryption(c, pd) {
+		t.Fatal("Partial decryption is accepted for another election")
+	}
+}
+
+func TestCombineWithAnyThresholdOfShares(t *testing.T) {
+	const votes = 4
+	key, shares := newTestKey(t, 5, 3)
+	c := encryptedTally(t, key, votes)
+	all := partialDecryptions(t, key, shares, c)
+	for _, subset := range subsets(len(all), key.Threshold) {
+		decryptions := []PartialDecryption{}
+		for _, i := range subset {
+			decryptions = append(decryptions, all[i])
+		}
+		tally, err := key.Combine(c, decryptions, votes)
+		if err != nil {
+			t.Fatalf("Failed to combine shares %v: %s", subset, err)
+		}
+		if tally != votes {
+			t.Fatalf("Shares %v decrypted %d instead of %d", subset, tally, votes)
+		}
+	}
+}
+
+func TestCombineRequiresThreshold(t *testing.T) {
+	key, shares := newTestKey(t, 5, 3)
+	c := encryptedTally(t, key, 2)
+	all := partialDecryptions(t, key, shares, c)
+	if _, err := key.Combine(c, all[:2], 2); err != ErrNotEnoughShares {
+		t.Fatalf("Expected %s, got %v", ErrNotEnoughShares, err)
+	}
+	if _, err := key.Combine(c, []PartialDecryption{all[0], all[0], all[1]}, 2); err != ErrNotEnoughShares {
+		t.Fatalf("Expected %s for repeated shares, got %v", ErrNotEnoughShares, err)
+	}
+}
@@ -0,0 +1,258 @@
+package elgamal
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+const scalarLength = 32
+
+type Ciphertext struct {
+	A []byte `json:"a"`
+	B []byte `json:"b"`
+}
+
+type Trustee struct {
+	Index           int    `json:"index"`
+	VerificationKey []byte `json:"verificationKey"`
+}
+
+type ElectionKey struct {
+	PublicKey []byte    `json:"publicKey"`
+	Threshold int       `json:"threshold"`
+	Trustees  []Trustee `json:"trustees"`
+}
+
+type Share struct {
+	Index           int    `json:"index"`
+	Secret          []byte `json:"secret"`
+	VerificationKey []byte `json:"verificationKey"`
+}
+
+var ErrInvalidPoint = errors.New("Point is not on the curve")
+
+var ErrInvalidCiphertext = errors.New("Ciphertext is not valid")
+
+func curve() elliptic.Curve {
+	return elliptic.P256()
+}
+
+func order() *big.Int {
+	return curve().Params().N
+}
+
+func encodeScalar(s *big.Int) []byte {
+	raw := s.Bytes()
+	result := make([]byte, scalarLength-len(raw), scalarLength)
+	return append(result, raw...)
+}
+
+func encodePoint(x, y *big.Int) []byte {
+	return append(encodeScalar(x), encodeScalar(y)...)
+}
+
+func decodePoint(raw []byte) (*big.Int, *big.Int, error) {
+	if len(raw) != 2*scalarLength {
+		return nil, nil, ErrInvalidPoint
+	}
+	x := new(big.Int).SetBytes(raw[:scalarLength])
+	y := new(big.Int).SetBytes(raw[scalarLength:])
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return x, y, nil
+	}
+	if !curve().IsOnCurve(x, y) {
+		return nil, nil, ErrInvalidPoint
+	}
+	return x, y, nil
+}
+
+func isIdentity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+func negate(x, y *big.Int) (*big.Int, *big.Int) {
+	if isIdentity(x, y) {
+		return x, y
+	}
+	return x, new(big.Int).Sub(curve().Params().P, y)
+}
+
+func add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	switch {
+	case isIdentity(x1, y1):
+		return x2, y2
+	case isIdentity(x2, y2):
+		return x1, y1
+	}
+	nx, ny := negate(x2, y2)
+	if x1.Cmp(nx) == 0 && y1.Cmp(ny) == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return curve().Add(x1, y1, x2, y2)
+}
+
+func sub(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	nx, ny := negate(x2, y2)
+	return add(x1, y1, nx, ny)
+}
+
+func mul(x, y, k *big.Int) (*big.Int, *big.Int) {
+	if isIdentity(x, y) || new(big.Int).Mod(k, order()).Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return curve().ScalarMult(x, y, encodeScalar(new(big.Int).Mod(k, order())))
+}
+
+func baseMul(k *big.Int) (*big.Int, *big.Int) {
+	if new(big.Int).Mod(k, order()).Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+	return curve().ScalarBaseMult(encodeScalar(new(big.Int).Mod(k, order())))
+}
+
+func generator() (*big.Int, *big.Int) {
+	params := curve().Params()
+	return params.Gx, params.Gy
+}
+
+func randomScalar() (*big.Int, error) {
+	for {
+		k, err := rand.Int(rand.Reader, order())
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate random scalar")
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+func hashToScalar(elements ...[]byte) *big.Int {
+	hasher := sha256.New()
+	for _, e := range elements {
+		hasher.Write(e)
+	}
+	e := new(big.Int).SetBytes(hasher.Sum(nil))
+	return e.Mod(e, order())
+}
+
+func (k ElectionKey) ID() []byte {
+	hasher := sha256.New()
+	hasher.Write(k.PublicKey)
+	binary.Write(hasher, binary.BigEndian, int64(k.Threshold))
+	for _, t := range k.Trustees {
+		binary.Write(hasher, binary.BigEndian, int64(t.Index))
+		hasher.Write(t.VerificationKey)
+	}
+	return hasher.Sum(nil)
+}
+
+func (k ElectionKey) ballotContext(voter []byte) []byte {
+	return append(k.ID(), voter...)
+}
+
+func GenerateElectionKey(trustees, threshold int) (*ElectionKey, []Share, error) {
+	if threshold < 1 || trustees < threshold {
+		return nil, nil, errors.Errorf("Invalid threshold %d for %d trustees", threshold, trustees)
+	}
+	coefficients := make([]*big.Int, 0, threshold)
+	for i := 0; i < threshold; i++ {
+		c, err := randomScalar()
+		if err != nil {
+			return nil, nil, err
+		}
+		coefficients = append(coefficients, c)
+	}
+	key := &ElectionKey{
+		PublicKey: encodePoint(baseMul(coefficients[0])),
+		Threshold: threshold,
+	}
+	shares := make([]Share, 0, trustees)
+	for i := 1; i <= trustees; i++ {
+		secret := new(big.Int)
+		for power := len(coefficients) - 1; power >= 0; power-- {
+			secret.Mul(secret, big.NewInt(int64(i)))
+			secret.Add(secret, coefficients[power])
+			secret.Mod(secret, order())
+		}
+		verificationKey := encodePoint(baseMul(secret))
+		shares = append(shares, Share{
+			Index:           i,
+			Secret:          encodeScalar(secret),
+			VerificationKey: verificationKey,
+		})
+		key.Trustees = append(key.Trustees, Trustee{
+			Index:           i,
+			VerificationKey: verificationKey,
+		})
+	}
+	return key, shares, nil
+}
+
+func (k ElectionKey) Trustee(index int) (Trustee, bool) {
+	for _, t := range k.Trustees {
+		if t.Index == index {
+			return t, true
+		}
+	}
+	return Trustee{}, false
+}
+
+func encrypt(publicKey []byte, m int) (*Ciphertext, *big.Int, error) {
+	yx, yy, err := decodePoint(publicKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid election public key")
+	}
+	r, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	ax, ay := baseMul(r)
+	mx, my := baseMul(big.NewInt(int64(m)))
+	rx, ry := mul(yx, yy, r)
+	bx, by := add(mx, my, rx, ry)
+	return &Ciphertext{
+		A: encodePoint(ax, ay),
+		B: encodePoint(bx, by),
+	}, r, nil
+}
+
+func (c Ciphertext) points() (ax, ay, bx, by *big.Int, err error) {
+	if ax, ay, err = decodePoint(c.A); err != nil {
+		return nil, nil, nil, nil, ErrInvalidCiphertext
+	}
+	if bx, by, err = decodePoint(c.B); err != nil {
+		return nil, nil, nil, nil, ErrInvalidCiphertext
+	}
+	return ax, ay, bx, by, nil
+}
+
+func (c Ciphertext) IsEmpty() bool {
+	return len(c.A) == 0 && len(c.B) == 0
+}
+
+func (c Ciphertext) Add(other Ciphertext) (Ciphertext, error) {
+	switch {
+	case c.IsEmpty():
+		return other, nil
+	case other.IsEmpty():
+		return c, nil
+	}
+	ax1, ay1, bx1, by1, err := c.points()
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	ax2, ay2, bx2, by2, err := other.points()
+	if err != nil {
+		return Ciphertext{}, err
+	}
+	return Ciphertext{
+		A: encodePoint(add(ax1, ay1, ax2, ay2)),
+		B: encodePoint(add(bx1, by1, bx2, by2)),
+	}, nil
+}
@@ -0,0 +1,165 @@
+package elgamal
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+type DLEQProof struct {
+	Commitment1 []byte `json:"commitment1"`
+	Commitment2 []byte `json:"commitment2"`
+	Response    []byte `json:"response"`
+}
+
+type BitProof struct {
+	Commitments [4][]byte `json:"commitments"`
+	Challenges  [2][]byte `json:"challenges"`
+	Responses   [2][]byte `json:"responses"`
+}
+
+func proveDLEQ(context []byte, g1x, g1y, h1x, h1y, g2x, g2y, h2x, h2y, secret *big.Int) (*DLEQProof, error) {
+	w, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	a1 := encodePoint(mul(g1x, g1y, w))
+	a2 := encodePoint(mul(g2x, g2y, w))
+	c := hashToScalar(
+		context,
+		encodePoint(g1x, g1y), encodePoint(h1x, h1y),
+		encodePoint(g2x, g2y), encodePoint(h2x, h2y),
+		a1, a2,
+	)
+	z := new(big.Int).Mul(c, secret)
+	z.Add(z, w)
+	z.Mod(z, order())
+	return &DLEQProof{
+		Commitment1: a1,
+		Commitment2: a2,
+		Response:    encodeScalar(z),
+	}, nil
+}
+
+func verifyDLEQ(context []byte, g1x, g1y, h1x, h1y, g2x, g2y, h2x, h2y *big.Int, proof DLEQProof) bool {
+	a1x, a1y, err := decodePoint(proof.Commitment1)
+	if err != nil {
+		return false
+	}
+	a2x, a2y, err := decodePoint(proof.Commitment2)
+	if err != nil {
+		return false
+	}
+	c := hashToScalar(
+		context,
+		encodePoint(g1x, g1y), encodePoint(h1x, h1y),
+		encodePoint(g2x, g2y), encodePoint(h2x, h2y),
+		proof.Commitment1, proof.Commitment2,
+	)
+	z := new(big.Int).SetBytes(proof.Response)
+	return checkResponse(g1x, g1y, a1x, a1y, h1x, h1y, z, c) && checkResponse(g2x, g2y, a2x, a2y, h2x, h2y, z, c)
+}
+
+func checkResponse(gx, gy, ax, ay, hx, hy, z, c *big.Int) bool {
+	lx, ly := mul(gx, gy, z)
+	chx, chy := mul(hx, hy, c)
+	rx, ry := add(ax, ay, chx, chy)
+	return lx.Cmp(rx) == 0 && ly.Cmp(ry) == 0
+}
+
+func commit(gx, gy, hx, hy, z, c *big.Int) []byte {
+	zgx, zgy := mul(gx, gy, z)
+	chx, chy := mul(hx, hy, c)
+	return encodePoint(sub(zgx, zgy, chx, chy))
+}
+
+func proveBit(context, publicKey []byte, c Ciphertext, m int, r *big.Int) (*BitProof, error) {
+	if m != 0 && m != 1 {
+		return nil, errors.Errorf("Value %d is not a bit", m)
+	}
+	yx, yy, err := decodePoint(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid election public key")
+	}
+	ax, ay, bx, by, err := c.points()
+	if err != nil {
+		return nil, err
+	}
+	gx, gy := generator()
+	fake := 1 - m
+	fakeChallenge, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	fakeResponse, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	w, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	var commitments [4][]byte
+	commitments[2*m] = encodePoint(baseMul(w))
+	commitments[2*m+1] = encodePoint(mul(yx, yy, w))
+
+	shiftedX, shiftedY := bx, by
+	if fake == 1 {
+		shiftedX, shiftedY = sub(bx, by, gx, gy)
+	}
+	commitments[2*fake] = commit(gx, gy, ax, ay, fakeResponse, fakeChallenge)
+	commitments[2*fake+1] = commit(yx, yy, shiftedX, shiftedY, fakeResponse, fakeChallenge)
+
+	challenge := hashToScalar(context, publicKey, c.A, c.B, commitments[0], commitments[1], commitments[2], commitments[3])
+	realChallenge := new(big.Int).Sub(challenge, fakeChallenge)
+	realChallenge.Mod(realChallenge, order())
+	realResponse := new(big.Int).Mul(realChallenge, r)
+	realResponse.Add(realResponse, w)
+	realResponse.Mod(realResponse, order())
+
+	var challenges, responses [2][]byte
+	challenges[m] = encodeScalar(realChallenge)
+	challenges[fake] = encodeScalar(fakeChallenge)
+	responses[m] = encodeScalar(realResponse)
+	responses[fake] = encodeScalar(fakeResponse)
+	return &BitProof{
+		Commitments: commitments,
+		Challenges:  challenges,
+		Responses:   responses,
+	}, nil
+}
+
+func verifyBit(context, publicKey []byte, c Ciphertext, proof BitProof) bool {
+	yx, yy, err := decodePoint(publicKey)
+	if err != nil {
+		return false
+	}
+	ax, ay, bx, by, err := c.points()
+	if err != nil {
+		return false
+	}
+	gx, gy := generator()
+	challenge := hashToScalar(context, publicKey, c.A, c.B, proof.Commitments[0], proof.Commitments[1], proof.Commitments[2], proof.Commitments[3])
+	sum := new(big.Int)
+	for bit := 0; bit < 2; bit++ {
+		cx, cy, err := decodePoint(proof.Commitments[2*bit])
+		if err != nil {
+			return false
+		}
+		dx, dy, err := decodePoint(proof.Commitments[2*bit+1])
+		if err != nil {
+			return false
+		}
+		ch := new(big.Int).SetBytes(proof.Challenges[bit])
+		z := new(big.Int).SetBytes(proof.Responses[bit])
+		shiftedX, shiftedY := bx, by
+		if bit == 1 {
+			shiftedX, shiftedY = sub(bx, by, gx, gy)
+		}
+		if !checkResponse(gx, gy, cx, cy, ax, ay, z, ch) || !checkResponse(yx, yy, dx, dy, shiftedX, shiftedY, z, ch) {
+			return false
+		}
+		sum.Add(sum, ch)
+	}
+	return sum.Mod(sum, order()).Cmp(challenge) == 0
+}
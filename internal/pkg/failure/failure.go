@@ -0,0 +1,162 @@
+package failure
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Code string
+
+const (
+	Internal              Code = "internal-server-error"
+	InvalidData           Code = "invalid-data-error"
+	Unauthorized          Code = "unauthorized-error"
+	NotFound              Code = "not-found-error"
+	UnknownMessage        Code = "message-unknown"
+	BlockNotFound         Code = "block-not-found"
+	InvalidTransaction    Code = "invalid-transaction"
+	UserAlreadyVoted      Code = "user-already-voted"
+	VoterRevoked          Code = "voter-revoked"
+	BallotSpent           Code = "ballot-spent"
+	InvalidReplacement    Code = "invalid-replacement"
+	AmbiguousBallot       Code = "ambiguous-ballot"
+	TokenNotRequested     Code = "token-not-requested"
+	TokenAlreadyUsed      Code = "token-already-used"
+	TokenSessionOpen      Code = "token-session-open"
+	BallotPoolEmpty       Code = "ballot-pool-empty"
+	ElectionClosed        Code = "election-closed"
+	ElectionOpen          Code = "election-open"
+	BallotsPending        Code = "ballots-pending"
+	TallyPublished        Code = "tally-published"
+	RecastPending         Code = "recast-pending"
+	InvalidRecastSequence Code = "invalid-recast-sequence"
+	TooManyRequests       Code = "too-many-requests"
+	RequestTooLarge       Code = "request-too-large"
+	ServerBusy            Code = "server-busy"
+	RequestTimeout        Code = "request-timeout"
+	VoterRegistered       Code = "voter-registered"
+	PartyExists           Code = "party-exists"
+	PartyInactive         Code = "party-inactive"
+	PartyPending          Code = "party-pending"
+	SlotNotAssigned       Code = "slot-not-assigned"
+	SlotMissed            Code = "slot-missed"
+	CheckpointConflict    Code = "checkpoint-conflict"
+	ProtocolMismatch      Code = "protocol-mismatch"
+	UpgradePending        Code = "upgrade-pending"
+)
+
+type definition struct {
+	status    int
+	retryable bool
+	message   string
+}
+
+var catalog = map[Code]definition{
+	Internal:              {http.StatusInternalServerError, true, "Unexpected error occurred"},
+	InvalidData:           {http.StatusBadRequest, false, "Invalid data provided"},
+	Unauthorized:          {http.StatusUnauthorized, false, "Unauthorized"},
+	NotFound:              {http.StatusNotFound, false, "Not found"},
+	UnknownMessage:        {http.StatusBadRequest, false, "Unknown message"},
+	BlockNotFound:         {http.StatusNotFound, false, "Block not found"},
+	InvalidTransaction:    {http.StatusBadRequest, false, "Invalid transaction signature"},
+	UserAlreadyVoted:      {http.StatusConflict, false, "User already voted"},
+	VoterRevoked:          {http.StatusForbidden, false, "Voter key has been revoked"},
+	BallotSpent:           {http.StatusConflict, false, "Ballot of the revoked voter is already spent"},
+	InvalidReplacement:    {http.StatusConflict, false, "Replacement address already owns a ballot"},
+	AmbiguousBallot:       {http.StatusConflict, false, "Revoked voter owns more than one ballot"},
+	TokenNotRequested:     {http.StatusConflict, false, "Token commitment must be requested first"},
+	TokenAlreadyUsed:      {http.StatusConflict, false, "Ballot token is already used"},
+	TokenSessionOpen:      {http.StatusConflict, true, "Previous token commitment is still open"},
+	BallotPoolEmpty:       {http.StatusServiceUnavailable, true, "There are no anonymous ballots available yet"},
+	ElectionClosed:        {http.StatusConflict, false, "Election is closed"},
+	ElectionOpen:          {http.StatusConflict, true, "Election is still open"},
+	BallotsPending:        {http.StatusConflict, true, "Encrypted ballots are still waiting to be included in a block"},
+	TallyPublished:        {http.StatusConflict, false, "Tally is already published"},
+	RecastPending:         {http.StatusConflict, true, "Previous vote is not included in a block yet"},
+	InvalidRecastSequence: {http.StatusConflict, true, "Recast sequence does not follow the previous vote"},
+	TooManyRequests:       {http.StatusTooManyRequests, true, "Too many requests"},
+	RequestTooLarge:       {http.StatusRequestEntityTooLarge, false, "Request body is too large"},
+	ServerBusy:            {http.StatusServiceUnavailable, true, "Server is busy"},
+	RequestTimeout:        {http.StatusServiceUnavailable, true, "Request timed out"},
+	VoterRegistered:       {http.StatusConflict, false, "Voter is already registered"},
+	PartyExists:           {http.StatusConflict, false, "Party is already registered"},
+	PartyInactive:         {http.StatusConflict, false, "Party is deactivated"},
+	PartyPending:          {http.StatusConflict, true, "Previous change of the party is not included in a block yet"},
+	SlotNotAssigned:       {http.StatusConflict, false, "Slot is not assigned to the forger"},
+	SlotMissed:            {http.StatusConflict, false, "Deadline of the slot has passed"},
+	CheckpointConflict:    {http.StatusConflict, false, "Blockchain conflicts with the latest checkpoint"},
+	ProtocolMismatch:      {http.StatusConflict, false, "Block doesn't follow the protocol rules"},
+	UpgradePending:        {http.StatusConflict, true, "Previous upgrade is not included in a block yet"},
+}
+
+type Error struct {
+	Code          Code   `json:"code"`
+	Message       string `json:"message"`
+	Status        int    `json:"status"`
+	Retryable     bool   `json:"retryable"`
+	RetryAfter    int    `json:"retryAfter,omitempty"`
+	CorrelationID string `json:"correlationId,omitempty"`
+}
+
+type Envelope struct {
+	Error Error `json:"error"`
+}
+
+func New(code Code) Error {
+	d, ok := catalog[code]
+	if !ok {
+		d = catalog[Internal]
+	}
+	return Error{
+		Code:      code,
+		Message:   d.message,
+		Status:    d.status,
+		Retryable: d.retryable,
+	}
+}
+
+func Newf(code Code, format string, args ...interface{}) Error {
+	e := New(code)
+	e.Message = fmt.Sprintf(format, args...)
+	return e
+}
+
+func From(err error) (Error, bool) {
+	var e Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return New(Internal), false
+}
+
+func (e Error) Error() string {
+	if e.CorrelationID == "" {
+		return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("%s: %s (correlation id %s)", e.Code, e.Message, e.CorrelationID)
+}
+
+func (e Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.Code == e.Code
+}
+
+func (e Error) WithCorrelationID(id string) Error {
+	e.CorrelationID = id
+	return e
+}
+
+func (e Error) WithRetryAfter(d time.Duration) Error {
+	e.RetryAfter = int((d + time.Second - 1) / time.Second)
+	if e.RetryAfter < 1 {
+		e.RetryAfter = 1
+	}
+	return e
+}
+
+func (e Error) Envelope() Envelope {
+	return Envelope{Error: e}
+}
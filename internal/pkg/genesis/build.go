@@ -0,0 +1,124 @@
+package genesis
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/party"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type payload struct {
+	txType transaction.Type
+	data   interface{}
+}
+
+func Build(spec Spec, projectRoot blockchain.ProjectUTXORootFn) (*blockchain.Block, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	authority, err := spec.AuthorityKey()
+	if err != nil {
+		return nil, err
+	}
+	timestamp := spec.Time.Unix()
+	txs := transaction.Transactions{}
+	add := func(txType transaction.Type, outputs transaction.Outputs, raw []byte) error {
+		t, err := transaction.NewGenesisTransaction(txType, authority, outputs, raw, timestamp)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create genesis %s transaction", txType)
+		}
+		txs = append(txs, *t)
+		return nil
+	}
+	if err := add(transaction.RegularTransaction, mintOutputs(spec, authority), nil); err != nil {
+		return nil, err
+	}
+	parameters, err := spec.ChainParameters()
+	if err != nil {
+		return nil, err
+	}
+	payloads := []payload{
+		{txType: transaction.ChainParametersTransaction, data: parameters},
+	}
+	if spec.Election.Key != nil {
+		payloads = append(payloads, payload{txType: transaction.ElectionSetupTransaction, data: *spec.Election.Key})
+	}
+	if spec.Voters.Ring {
+		ring := make([][]byte, 0, len(spec.Voters.Roll))
+		for _, v := range spec.Voters.Roll {
+			key, _ := hex.DecodeString(v.RingKey)
+			ring = append(ring, key)
+		}
+		payloads = append(payloads, payload{txType: transaction.EligibleVotersTransaction, data: ring})
+	}
+	if spec.Election.Closes != nil {
+		payloads = append(payloads, payload{txType: transaction.ElectionScheduleTransaction, data: election.Schedule{Closes: spec.Election.Closes.UTC()}})
+	}
+	if spec.Election.Recast {
+		payloads = append(payloads, payload{txType: transaction.RecastSetupTransaction, data: election.Schedule{Closes: spec.Election.Closes.UTC()}})
+	}
+	for _, p := range payloads {
+		raw, err := json.Marshal(p.data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to serialize genesis %s payload", p.txType)
+		}
+		if err := add(p.txType, transaction.Outputs{}, raw); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range spec.Parties {
+		raw, err := transaction.PartyPayload(party.Party{
+			Name:     p.Name,
+			Address:  p.Address,
+			Metadata: p.Metadata,
+			Active:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := add(transaction.PartyTransaction, transaction.Outputs{}, raw); err != nil {
+			return nil, err
+		}
+	}
+	root, err := projectRoot(txs)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to project genesis state root")
+	}
+	return blockchain.NewGenesisBlock(parameters, authority, timestamp, root, txs)
+}
+
+func mintOutputs(spec Spec, authority []byte) transaction.Outputs {
+	authorityHash, _ := wallet.HashedPublicKey(authority)
+	outputs := transaction.Outputs{}
+	if spec.Authority.Supply > 0 {
+		outputs = append(outputs, transaction.Output{
+			Value:         spec.Authority.Supply,
+			PublicKeyHash: authorityHash,
+		})
+	}
+	for _, p := range spec.Parties {
+		if p.Stake == 0 {
+			continue
+		}
+		outputs = append(outputs, transaction.Output{
+			Value:         p.Stake,
+			PublicKeyHash: wallet.ExtractPublicKeyHash(p.Address),
+		})
+	}
+	for _, v := range spec.Voters.Roll {
+		recipient := wallet.ExtractPublicKeyHash(v.Address)
+		if spec.Voters.Ring {
+			recipient = transaction.RingPoolHash()
+		}
+		outputs = append(outputs, transaction.Output{
+			Value:         spec.Voters.BallotValue,
+			PublicKeyHash: recipient,
+		})
+	}
+	return outputs
+}
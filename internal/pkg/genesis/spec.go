@@ -0,0 +1,238 @@
+package genesis
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidSpec = errors.New("Invalid genesis spec")
+
+type Authority struct {
+	PublicKey string `json:"publicKey"`
+	Supply    int    `json:"supply"`
+}
+
+type Party struct {
+	Name     string            `json:"name"`
+	Address  string            `json:"address"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Stake    int               `json:"stake"`
+}
+
+type Voter struct {
+	Address string `json:"address"`
+	RingKey string `json:"ringKey,omitempty"`
+}
+
+type Voters struct {
+	BallotValue int     `json:"ballotValue"`
+	Ring        bool    `json:"ring,omitempty"`
+	Roll        []Voter `json:"roll"`
+}
+
+type Election struct {
+	Key    *elgamal.ElectionKey `json:"key,omitempty"`
+	Closes *time.Time           `json:"closes,omitempty"`
+	Recast bool                 `json:"recast,omitempty"`
+}
+
+type Parameters struct {
+	MagicNumber   int    `json:"magicNumber"`
+	Version       int    `json:"version"`
+	SlotDuration  string `json:"slotDuration"`
+	SlotsPerEpoch int    `json:"slotsPerEpoch"`
+	MaxBlockSize  int    `json:"maxBlockSize"`
+}
+
+type Spec struct {
+	Time       time.Time  `json:"time"`
+	Authority  Authority  `json:"authority"`
+	Parties    []Party    `json:"parties"`
+	Voters     Voters     `json:"voters"`
+	Election   Election   `json:"election"`
+	Parameters Parameters `json:"parameters"`
+}
+
+type Options struct {
+	Time        time.Time
+	ElectionKey *elgamal.ElectionKey
+	Closes      *time.Time
+	Ring        bool
+	Recast      bool
+}
+
+func Load(path string) (*Spec, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read genesis spec %s", path)
+	}
+	var spec Spec
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal genesis spec %s", path)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+func (s Spec) Write(path string) error {
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize genesis spec")
+	}
+	if err := ioutil.WriteFile(path, raw, 0644); err != nil {
+		return errors.Wrapf(err, "Failed to write genesis spec %s", path)
+	}
+	return nil
+}
+
+func FromWallets(authority wallet.Wallet, parties map[string]wallet.Wallet, voters wallet.Wallets, options Options) Spec {
+	defaults := blockchain.DefaultParameters()
+	spec := Spec{
+		Time: options.Time.UTC().Truncate(time.Second),
+		Authority: Authority{
+			PublicKey: hex.EncodeToString(authority.PublicKey),
+			Supply:    100 * transaction.VoteValue,
+		},
+		Parties: []Party{},
+		Voters: Voters{
+			BallotValue: transaction.VoteValue,
+			Ring:        options.Ring,
+			Roll:        []Voter{},
+		},
+		Election: Election{
+			Key:    options.ElectionKey,
+			Closes: options.Closes,
+			Recast: options.Recast,
+		},
+		Parameters: Parameters{
+			MagicNumber:   defaults.MagicNumber,
+			Version:       defaults.Version,
+			SlotDuration:  defaults.SlotDuration.String(),
+			SlotsPerEpoch: defaults.SlotsPerEpoch,
+			MaxBlockSize:  defaults.MaxBlockSize,
+		},
+	}
+	for name, w := range parties {
+		spec.Parties = append(spec.Parties, Party{
+			Name:    name,
+			Address: w.Address,
+			Stake:   transaction.VoteValue,
+		})
+	}
+	sort.Slice(spec.Parties, func(i, j int) bool {
+		return spec.Parties[i].Name < spec.Parties[j].Name
+	})
+	for _, w := range voters {
+		voter := Voter{Address: w.Address}
+		if options.Ring {
+			voter.RingKey = hex.EncodeToString(wallet.RingMember(w.PrivateKey.PublicKey))
+		}
+		spec.Voters.Roll = append(spec.Voters.Roll, voter)
+	}
+	sort.Slice(spec.Voters.Roll, func(i, j int) bool {
+		return spec.Voters.Roll[i].Address < spec.Voters.Roll[j].Address
+	})
+	return spec
+}
+
+func (s Spec) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return errors.Wrapf(ErrInvalidSpec, format, args...)
+	}
+	if s.Time.IsZero() {
+		return invalid("Genesis time is missing")
+	}
+	if _, err := s.AuthorityKey(); err != nil {
+		return invalid("Authority public key is invalid: %s", err)
+	}
+	if s.Authority.Supply < 0 {
+		return invalid("Authority supply %d is negative", s.Authority.Supply)
+	}
+	addresses := map[string]bool{}
+	for _, p := range s.Parties {
+		switch _, err := wallet.ParseAddress(p.Address); {
+		case p.Name == "":
+			return invalid("Party %s has no name", p.Address)
+		case err != nil:
+			return invalid("Party %s has invalid address: %s", p.Name, err)
+		case addresses[p.Address]:
+			return invalid("Party address %s is listed more than once", p.Address)
+		case p.Stake < 0:
+			return invalid("Party %s has negative stake %d", p.Name, p.Stake)
+		}
+		addresses[p.Address] = true
+	}
+	if s.Voters.BallotValue < transaction.VoteValue {
+		return invalid("Ballot value %d is lower than vote value %d", s.Voters.BallotValue, transaction.VoteValue)
+	}
+	voters := map[string]bool{}
+	for _, v := range s.Voters.Roll {
+		switch _, err := wallet.ParseAddress(v.Address); {
+		case err != nil:
+			return invalid("Voter has invalid address %s: %s", v.Address, err)
+		case voters[v.Address]:
+			return invalid("Voter %s is listed more than once", v.Address)
+		case s.Voters.Ring && v.RingKey == "":
+			return invalid("Voter %s has no ring key", v.Address)
+		}
+		if s.Voters.Ring {
+			if _, err := hex.DecodeString(v.RingKey); err != nil {
+				return invalid("Voter %s has invalid ring key: %s", v.Address, err)
+			}
+		}
+		voters[v.Address] = true
+	}
+	switch {
+	case s.Voters.Ring && s.Election.Key != nil:
+		return invalid("Ring votes can't be combined with encrypted ballots")
+	case s.Election.Recast && (s.Voters.Ring || s.Election.Key != nil):
+		return invalid("Recastable votes can't be combined with ring votes or encrypted ballots")
+	case s.Election.Recast && s.Election.Closes == nil:
+		return invalid("Recastable votes require closing time")
+	}
+	parameters, err := s.ChainParameters()
+	if err != nil {
+		return invalid("Chain parameters are invalid: %s", err)
+	}
+	if parameters.MagicNumber == 0 || parameters.Version < 0 || parameters.SlotDuration < time.Second || parameters.SlotsPerEpoch <= 0 || parameters.MaxBlockSize <= 0 {
+		return invalid("Chain parameters %#v are out of range", parameters)
+	}
+	return nil
+}
+
+func (s Spec) AuthorityKey() ([]byte, error) {
+	key, err := hex.DecodeString(s.Authority.PublicKey)
+	switch {
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to decode authority public key %s", s.Authority.PublicKey)
+	case len(key) == 0:
+		return nil, errors.New("Authority public key is missing")
+	}
+	return key, nil
+}
+
+func (s Spec) ChainParameters() (blockchain.Parameters, error) {
+	parameters := blockchain.Parameters{
+		MagicNumber:   s.Parameters.MagicNumber,
+		Version:       s.Parameters.Version,
+		SlotsPerEpoch: s.Parameters.SlotsPerEpoch,
+		MaxBlockSize:  s.Parameters.MaxBlockSize,
+	}
+	slotDuration, err := time.ParseDuration(s.Parameters.SlotDuration)
+	if err != nil {
+		return parameters, errors.Wrapf(err, "Failed to parse slot duration %s", s.Parameters.SlotDuration)
+	}
+	parameters.SlotDuration = slotDuration
+	return parameters, nil
+}
@@ -0,0 +1,74 @@
+package limit
+
+import (
+	"sync"
+	"time"
+)
+
+type AllowFn func(key string) (bool, time.Duration)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	buckets  map[string]*bucket
+	swept    time.Time
+}
+
+func NewLimiter(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &Limiter{
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    float64(burst),
+		buckets:  map[string]*bucket{},
+		swept:    time.Now(),
+	}
+}
+
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || key == "" {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens += float64(now.Sub(b.updated)) / float64(l.interval)
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.updated = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(l.interval))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	full := time.Duration(l.burst * float64(l.interval))
+	if now.Sub(l.swept) < full {
+		return
+	}
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= full {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
@@ -0,0 +1,31 @@
+package limit
+
+type Pool struct {
+	jobs chan func()
+}
+
+func NewPool(workers, queue int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &Pool{jobs: make(chan func(), queue)}
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	for job := range p.jobs {
+		job()
+	}
+}
+
+func (p *Pool) Submit(job func()) bool {
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func NodeID(id string) Field {
+	return F("node", id)
+}
+
+func PeerID(id string) Field {
+	return F("peer", id)
+}
+
+func MessageType(message fmt.Stringer) Field {
+	return F("message", message.String())
+}
+
+func BlockHash(hash []byte) Field {
+	return F("block", hex.EncodeToString(hash))
+}
+
+func TxID(id []byte) Field {
+	return F("tx", hex.EncodeToString(id))
+}
+
+func CorrelationID(id string) Field {
+	return F("correlationId", id)
+}
+
+func Err(err error) Field {
+	if err == nil {
+		return F("error", nil)
+	}
+	return F("error", err.Error())
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type Level int
+
+const DefaultSubsystem = "default"
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "debug"
+	case Info:
+		return "info"
+	case Warn:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+func ParseLevel(raw string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debug":
+		return Debug, nil
+	case "info":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	default:
+		return Info, errors.Errorf("Unknown log level %s", raw)
+	}
+}
+
+type Levels struct {
+	lock        *sync.RWMutex
+	fallback    Level
+	bySubsystem map[string]Level
+}
+
+func NewLevels(fallback Level) *Levels {
+	return &Levels{
+		lock:        &sync.RWMutex{},
+		fallback:    fallback,
+		bySubsystem: map[string]Level{},
+	}
+}
+
+func ParseLevels(fallback, raw string) (*Levels, error) {
+	level, err := ParseLevel(fallback)
+	if err != nil {
+		return nil, err
+	}
+	levels := NewLevels(level)
+	for _, entry := range strings.Split(raw, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("Invalid subsystem log level %s, expected subsystem=level", entry)
+		}
+		level, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		levels.Set(strings.TrimSpace(parts[0]), level)
+	}
+	return levels, nil
+}
+
+func (l *Levels) Get(subsystem string) Level {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	if level, ok := l.bySubsystem[subsystem]; ok {
+		return level
+	}
+	return l.fallback
+}
+
+func (l *Levels) Set(subsystem string, level Level) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if subsystem == "" || subsystem == DefaultSubsystem {
+		l.fallback = level
+		return
+	}
+	l.bySubsystem[subsystem] = level
+}
+
+func (l *Levels) All() map[string]string {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	result := map[string]string{DefaultSubsystem: l.fallback.String()}
+	for subsystem, level := range l.bySubsystem {
+		result[subsystem] = level.String()
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Format string
+
+const (
+	JSON   Format = "json"
+	Logfmt Format = "logfmt"
+)
+
+func ParseFormat(raw string) (Format, error) {
+	switch Format(raw) {
+	case JSON, Logfmt:
+		return Format(raw), nil
+	default:
+		return "", errors.Errorf("Unknown log format %s", raw)
+	}
+}
+
+type output struct {
+	lock   *sync.Mutex
+	writer io.Writer
+	format Format
+}
+
+type Logger struct {
+	out       *output
+	levels    *Levels
+	subsystem string
+	fields    []Field
+}
+
+func New(writer io.Writer, format Format, levels *Levels, fields ...Field) Logger {
+	return Logger{
+		out: &output{
+			lock:   &sync.Mutex{},
+			writer: writer,
+			format: format,
+		},
+		levels: levels,
+		fields: fields,
+	}
+}
+
+func Discard() Logger {
+	return New(ioutil.Discard, Logfmt, NewLevels(Error))
+}
+
+func (l Logger) Subsystem(subsystem string) Logger {
+	l.subsystem = subsystem
+	return l
+}
+
+func (l Logger) With(fields ...Field) Logger {
+	l.fields = append(append([]Field{}, l.fields...), fields...)
+	return l
+}
+
+func (l Logger) Enabled(level Level) bool {
+	return l.out != nil && level >= l.levels.Get(l.subsystem)
+}
+
+func (l Logger) Debug(message string, fields ...Field) {
+	l.log(Debug, message, fields)
+}
+
+func (l Logger) Info(message string, fields ...Field) {
+	l.log(Info, message, fields)
+}
+
+func (l Logger) Warn(message string, fields ...Field) {
+	l.log(Warn, message, fields)
+}
+
+func (l Logger) Error(message string, fields ...Field) {
+	l.log(Error, message, fields)
+}
+
+func (l Logger) log(level Level, message string, fields []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	record := append([]Field{
+		F("ts", time.Now().UTC().Format(time.RFC3339Nano)),
+		F("level", level.String()),
+		F("subsystem", l.subsystem),
+		F("msg", message),
+	}, l.fields...)
+	record = append(record, fields...)
+	var buffer bytes.Buffer
+	if l.out.format == JSON {
+		writeJSON(&buffer, record)
+	} else {
+		writeLogfmt(&buffer, record)
+	}
+	l.out.lock.Lock()
+	defer l.out.lock.Unlock()
+	l.out.writer.Write(buffer.Bytes())
+}
+
+func writeJSON(buffer *bytes.Buffer, record []Field) {
+	buffer.WriteByte('{')
+	for i, field := range record {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.Key)
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			value, _ = json.Marshal(fmt.Sprint(field.Value))
+		}
+		buffer.Write(value)
+	}
+	buffer.WriteString("}\n")
+}
+
+func writeLogfmt(buffer *bytes.Buffer, record []Field) {
+	for i, field := range record {
+		if i > 0 {
+			buffer.WriteByte(' ')
+		}
+		buffer.WriteString(field.Key)
+		buffer.WriteByte('=')
+		value := ""
+		if field.Value != nil {
+			value = fmt.Sprint(field.Value)
+		}
+		if value == "" || strings.ContainsAny(value, " =\"\n\t") {
+			value = fmt.Sprintf("%q", value)
+		}
+		buffer.WriteString(value)
+	}
+	buffer.WriteByte('\n')
+}
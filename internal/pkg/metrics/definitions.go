@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+)
+
+const (
+	Accepted = "accepted"
+	Rejected = "rejected"
+	Forged   = "forged"
+	In       = "in"
+	Out      = "out"
+	Update   = "update"
+	View     = "view"
+)
+
+var (
+	Votes = NewCounter(
+		"cryptovote_votes_total",
+		"Votes accepted and rejected by reason",
+		"result", "reason",
+	)
+	Blocks = NewCounter(
+		"cryptovote_blocks_total",
+		"Blocks forged, accepted and rejected",
+		"result",
+	)
+	SyncBlocks = NewCounter(
+		"cryptovote_sync_blocks_total",
+		"Blocks downloaded from peers during sync, accepted and rejected",
+		"peer", "result",
+	)
+	MissedSlots = NewCounter(
+		"cryptovote_missed_slots_total",
+		"Slots missed by designated and backup forgers",
+		"role",
+	)
+	Messages = NewCounter(
+		"cryptovote_websocket_messages_total",
+		"Websocket messages received and sent by message type",
+		"direction", "message",
+	)
+	BoltTransactions = NewHistogram(
+		"cryptovote_bolt_transaction_duration_seconds",
+		"Duration of bolt transactions",
+		DefaultBuckets,
+		"kind",
+	)
+)
+
+func ObserveVote(err error) {
+	if err == nil {
+		Votes.Inc(Accepted, "")
+		return
+	}
+	e, _ := failure.From(err)
+	Votes.Inc(Rejected, string(e.Code))
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+)
+
+func count(fn func() int) GaugeFn {
+	return func() (float64, error) {
+		return float64(fn()), nil
+	}
+}
+
+func ChainGauges(getHeight func() (int, error), getTransactions transaction.GetTransactionsFn, countUTXOs transaction.CountUTXOsFn) {
+	NewGauge("cryptovote_chain_height", "Height of the local blockchain", func() (float64, error) {
+		height, err := getHeight()
+		return float64(height), err
+	})
+	NewGauge("cryptovote_pending_transactions", "Transactions waiting to be included in a block", func() (float64, error) {
+		transactions, err := getTransactions()
+		return float64(len(transactions)), err
+	})
+	NewGauge("cryptovote_utxo_set_size", "Unspent transaction outputs", func() (float64, error) {
+		size, err := countUTXOs()
+		return float64(size), err
+	})
+}
+
+func HubGauges(peers, queueDepth func() int) {
+	NewGauge("cryptovote_websocket_peers", "Registered websocket peers", count(peers))
+	NewGauge("cryptovote_websocket_queue_depth", "Messages waiting to be sent to websocket peers", count(queueDepth))
+}
+
+func FeedGauges(queueDepth func() int) {
+	NewGauge("cryptovote_feed_queue_depth", "Messages waiting to be published to event subscribers", count(queueDepth))
+}
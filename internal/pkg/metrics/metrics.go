@@ -0,0 +1,259 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+)
+
+type collector interface {
+	name() string
+	write(io.Writer) error
+}
+
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+var Default = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+func (r *Registry) snapshot() []collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := append([]collector{}, r.collectors...)
+	sort.Slice(result, func(i, j int) bool { return result[i].name() < result[j].name() })
+	return result
+}
+
+func (r *Registry) Handler(log logger.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var buffer bytes.Buffer
+		for _, c := range r.snapshot() {
+			if err := c.write(&buffer); err != nil {
+				log.Warn("Failed to collect metric", logger.F("metric", c.name()), logger.Err(err))
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buffer.Bytes())
+	})
+}
+
+func Handler(log logger.Logger) http.Handler {
+	return Default.Handler(log)
+}
+
+type series struct {
+	labels []string
+	values []string
+}
+
+func (s series) format(extra ...string) string {
+	pairs := []string{}
+	for i, label := range s.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label, s.values[i]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", extra[i], extra[i+1]))
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func header(w io.Writer, name, help, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	return err
+}
+
+type Counter struct {
+	metric string
+	help   string
+	labels []string
+	mu     sync.Mutex
+	values map[string]float64
+	series map[string]series
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		metric: name,
+		help:   help,
+		labels: labels,
+		values: map[string]float64{},
+		series: map[string]series{},
+	}
+	Default.register(c)
+	return c
+}
+
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) Add(v float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.series[key]; !ok {
+		c.series[key] = series{labels: c.labels, values: values}
+	}
+	c.values[key] += v
+}
+
+func (c *Counter) name() string {
+	return c.metric
+}
+
+func (c *Counter) write(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := header(w, c.metric, c.help, "counter"); err != nil {
+		return err
+	}
+	keys := []string{}
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", c.metric, c.series[key].format(), formatFloat(c.values[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type GaugeFn func() (float64, error)
+
+type gauge struct {
+	metric string
+	help   string
+	value  GaugeFn
+}
+
+func NewGauge(name, help string, value GaugeFn) {
+	Default.register(gauge{metric: name, help: help, value: value})
+}
+
+func (g gauge) name() string {
+	return g.metric
+}
+
+func (g gauge) write(w io.Writer) error {
+	value, err := g.value()
+	if err != nil {
+		return err
+	}
+	if err := header(w, g.metric, g.help, "gauge"); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s %s\n", g.metric, formatFloat(value))
+	return err
+}
+
+var DefaultBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+type histogramValues struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type Histogram struct {
+	metric  string
+	help    string
+	labels  []string
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValues
+	series  map[string]series
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		metric:  name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  map[string]*histogramValues{},
+		series:  map[string]series{},
+	}
+	Default.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, values ...string) {
+	key := strings.Join(values, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValues{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+		h.series[key] = series{labels: h.labels, values: values}
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+func (h *Histogram) name() string {
+	return h.metric
+}
+
+func (h *Histogram) write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := header(w, h.metric, h.help, "histogram"); err != nil {
+		return err
+	}
+	keys := []string{}
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv, s := h.values[key], h.series[key]
+		for i, bound := range h.buckets {
+			if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, s.format("le", formatFloat(bound)), hv.counts[i]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+			h.metric, s.format("le", "+Inf"), hv.count,
+			h.metric, s.format(), formatFloat(hv.sum),
+			h.metric, s.format(), hv.count,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package operations
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	_websocket "github.com/nebser/crypto-vote/internal/pkg/websocket"
+)
+
+type GetCheckpointFn func() (*blockchain.Checkpoint, error)
+
+type getCheckpointResult struct {
+	Checkpoint *blockchain.Checkpoint `json:"checkpoint"`
+}
+
+func GetCheckpoint(conn *websocket.Conn) GetCheckpointFn {
+	return func() (*blockchain.Checkpoint, error) {
+		payload := operation{
+			Message: _websocket.GetCheckpointMessage,
+		}
+		var r getCheckpointResult
+		if err := call(conn, payload, &r); err != nil {
+			return nil, err
+		}
+		return r.Checkpoint, nil
+	}
+}
@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	_websocket "github.com/nebser/crypto-vote/internal/pkg/websocket"
+)
+
+type getHeadersPayload struct {
+	From  int `json:"from"`
+	Count int `json:"count"`
+}
+
+type getHeadersResult struct {
+	Headers []blockchain.Header `json:"headers"`
+}
+
+func GetHeaders(conn *websocket.Conn) blockchain.GetHeadersFn {
+	return func(from, count int) ([]blockchain.Header, error) {
+		payload := operation{
+			Message: _websocket.GetHeadersMessage,
+			Body:    getHeadersPayload{From: from, Count: count},
+		}
+		var r getHeadersResult
+		if err := call(conn, payload, &r); err != nil {
+			return nil, err
+		}
+		return r.Headers, nil
+	}
+}
@@ -0,0 +1,26 @@
+package operations
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	_websocket "github.com/nebser/crypto-vote/internal/pkg/websocket"
+)
+
+type GetSnapshotFn func() (blockchain.Snapshot, error)
+
+type getSnapshotResult struct {
+	Snapshot blockchain.Snapshot `json:"snapshot"`
+}
+
+func GetSnapshot(conn *websocket.Conn) GetSnapshotFn {
+	return func() (blockchain.Snapshot, error) {
+		payload := operation{
+			Message: _websocket.GetSnapshotMessage,
+		}
+		var r getSnapshotResult
+		if err := call(conn, payload, &r); err != nil {
+			return blockchain.Snapshot{}, err
+		}
+		return r.Snapshot, nil
+	}
+}
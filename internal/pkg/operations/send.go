@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
 	_websocket "github.com/nebser/crypto-vote/internal/pkg/websocket"
 	"github.com/pkg/errors"
 )
@@ -31,7 +32,11 @@ func call(conn *websocket.Conn, op operation, result interface{}) error {
 		return errors.Wrapf(err, "Failed to send operation %#v", op)
 	}
 	if r.Message == _websocket.ErrorMessage {
-		return errors.Errorf("Failed to perform operation %#v. Error: %s", op, r.Body)
+		var envelope failure.Envelope
+		if err := json.Unmarshal(r.Body, &envelope); err != nil || envelope.Error.Code == "" {
+			return errors.Errorf("Failed to perform operation %#v. Error: %s", op, r.Body)
+		}
+		return errors.Wrapf(envelope.Error, "Failed to perform operation %s", op.Message)
 	}
 	if err := json.Unmarshal(r.Body, result); err != nil {
 		return errors.Wrapf(err, "Failed to unmarshal response %s", r.Body)
@@ -1,9 +1,17 @@
 package party
 
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/pkg/errors"
+)
+
 type Party struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Balance int    `json:"balance"`
+	Name           string              `json:"name"`
+	Address        string              `json:"address"`
+	Metadata       map[string]string   `json:"metadata,omitempty"`
+	Active         bool                `json:"active"`
+	Balance        int                 `json:"balance"`
+	EncryptedVotes *elgamal.Ciphertext `json:"encryptedVotes,omitempty"`
 }
 
 type Parties []Party
@@ -27,6 +35,35 @@ func (p Parties) Less(i, j int) bool {
 	}
 }
 
+func (p Parties) Find(address string) (Party, bool) {
+	for _, party := range p {
+		if party.Address == address {
+			return party, true
+		}
+	}
+	return Party{}, false
+}
+
+func (p Parties) Active() Parties {
+	result := Parties{}
+	for _, party := range p {
+		if party.Active {
+			result = append(result, party)
+		}
+	}
+	return result
+}
+
+type ChangeFn func(current *Party) (Party, error)
+
+var ErrPartyExists = errors.New("Party is already registered")
+
+var ErrPartyNotFound = errors.New("Party is not registered")
+
+var ErrPartyInactive = errors.New("Party is deactivated")
+
+var ErrPartyPending = errors.New("Previous change of the party is not included in a block yet")
+
 type GetPartyFn func(string) (*Party, error)
 
 type GetPartiesFn func() (Parties, error)
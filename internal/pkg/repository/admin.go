@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/api"
+	"github.com/pkg/errors"
+)
+
+func adminRequestsBucket() []byte {
+	return []byte("admin-requests")
+}
+
+func pruneAdminRequests(b *bolt.Bucket, now time.Time) error {
+	expired := [][]byte{}
+	err := b.ForEach(func(key, raw []byte) error {
+		if len(raw) == 8 && int64(binary.BigEndian.Uint64(raw)) < now.Unix() {
+			expired = append(expired, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range expired {
+		if err := b.Delete(key); err != nil {
+			return errors.Wrapf(err, "Failed to delete expired admin request %x", key)
+		}
+	}
+	return nil
+}
+
+func UseAdminRequest(db *bolt.DB) api.UseAdminRequestFn {
+	return func(id []byte, expires time.Time) (bool, error) {
+		used := false
+		err := update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, adminRequestsBucket())
+			if err != nil {
+				return err
+			}
+			if err := pruneAdminRequests(b, time.Now()); err != nil {
+				return err
+			}
+			if b.Get(id) != nil {
+				used = true
+				return nil
+			}
+			if err := b.Put(id, intKey(int(expires.Unix()))); err != nil {
+				return errors.Wrapf(err, "Failed to save admin request %x", id)
+			}
+			return nil
+		})
+		return !used, err
+	}
+}
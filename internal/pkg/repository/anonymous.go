@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func tokenSessionsBucket() []byte {
+	return []byte("token-sessions")
+}
+
+func spentTokensBucket() []byte {
+	return []byte("spent-tokens")
+}
+
+type tokenSession struct {
+	Nonce   []byte `json:"nonce"`
+	Expires int64  `json:"expires"`
+}
+
+func getTokenSession(tx *bolt.Tx, voter []byte) (*tokenSession, error) {
+	b := tx.Bucket(tokenSessionsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(voter)
+	if raw == nil {
+		return nil, nil
+	}
+	var session tokenSession
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal token session of %x", voter)
+	}
+	if time.Now().Unix() >= session.Expires {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func getOrCreateBucket(tx *bolt.Tx, name []byte) (*bolt.Bucket, error) {
+	b := tx.Bucket(name)
+	if b != nil {
+		return b, nil
+	}
+	created, err := tx.CreateBucket(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create bucket %s", name)
+	}
+	return created, nil
+}
+
+func getUnspentBallot(tx *bolt.Tx, voter []byte) (*transaction.UTXO, error) {
+	switch revoked, err := isRevoked(tx, voter); {
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to check revocation of %x", voter)
+	case revoked:
+		return nil, transaction.ErrVoterRevoked
+	}
+	utxos, err := getUTXOsByPublicKey(tx, voter)
+	switch {
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to retrieve utxos for %x", voter)
+	case len(utxos) == 0:
+		return nil, transaction.ErrInsufficientVotes
+	}
+	ballot := utxos[0]
+	switch pending, err := isPendingSpend(tx, ballot); {
+	case err != nil:
+		return nil, errors.Wrapf(err, "Failed to check pending transactions for %x", voter)
+	case pending:
+		return nil, transaction.ErrInsufficientVotes
+	}
+	return &ballot, nil
+}
+
+func getUnspentPoolBallot(tx *bolt.Tx, pool []byte) (*transaction.UTXO, error) {
+	utxos, err := getUTXOsByPublicKey(tx, pool)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve ballots of pool %x", pool)
+	}
+	available := transaction.UTXOs{}
+	for _, u := range utxos {
+		pending, err := isPendingSpend(tx, u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to check pending transactions for ballot of pool %x", pool)
+		}
+		if !pending {
+			available = append(available, u)
+		}
+	}
+	if len(available) == 0 {
+		return nil, transaction.ErrBallotPoolEmpty
+	}
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(len(available))))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to pick a ballot of pool %x", pool)
+	}
+	return &available[i.Int64()], nil
+}
+
+func NewTokenCommitment(db *bolt.DB) transaction.NewTokenCommitmentFn {
+	return func(voter []byte) ([]byte, error) {
+		var commitment []byte
+		err := update(db, func(tx *bolt.Tx) error {
+			if _, err := getUnspentBallot(tx, voter); err != nil {
+				return err
+			}
+			switch session, err := getTokenSession(tx, voter); {
+			case err != nil:
+				return err
+			case session != nil:
+				return transaction.ErrTokenSessionOpen
+			}
+			nonce, created, err := wallet.NewBlindingNonce()
+			if err != nil {
+				return errors.Wrap(err, "Failed to create blinding nonce")
+			}
+			raw, err := json.Marshal(tokenSession{
+				Nonce:   nonce,
+				Expires: time.Now().Add(transaction.TokenSessionValidity).Unix(),
+			})
+			if err != nil {
+				return errors.Wrapf(err, "Failed to serialize token session for %x", voter)
+			}
+			b, err := getOrCreateBucket(tx, tokenSessionsBucket())
+			if err != nil {
+				return err
+			}
+			if err := b.Put(voter, raw); err != nil {
+				return errors.Wrapf(err, "Failed to save token session for %x", voter)
+			}
+			commitment = created
+			return nil
+		})
+		return commitment, err
+	}
+}
+
+func IssueBallotToken(db *bolt.DB, signBlinded transaction.SignBlindedFn) transaction.IssueBallotTokenFn {
+	return func(voter, signature, verifier, challenge []byte) (transaction.Transaction, []byte, error) {
+		var result transaction.Transaction
+		var blindSignature []byte
+		err := update(db, func(tx *bolt.Tx) error {
+			session, err := getTokenSession(tx, voter)
+			switch {
+			case err != nil:
+				return err
+			case session == nil:
+				return transaction.ErrNoTokenSession
+			}
+			if err := tx.Bucket(tokenSessionsBucket()).Delete(voter); err != nil {
+				return errors.Wrapf(err, "Failed to delete token session for %x", voter)
+			}
+			ballot, err := getUnspentBallot(tx, voter)
+			if err != nil {
+				return err
+			}
+			inputs := transaction.Inputs{
+				{
+					PublicKeyHash: voter,
+					Signature:     signature,
+					TransactionID: ballot.TransactionID,
+					Vout:          ballot.Vout,
+					Verifier:      verifier,
+				},
+			}
+			outputs := transaction.Outputs{
+				{
+					PublicKeyHash: transaction.BallotPoolHash(),
+					Value:         transaction.VoteValue,
+				},
+			}
+			if ballot.Value > transaction.VoteValue {
+				outputs = append(outputs, transaction.Output{
+					PublicKeyHash: voter,
+					Value:         ballot.Value - transaction.VoteValue,
+				})
+			}
+			tr, err := transaction.NewTypedTransaction(transaction.BallotTokenTransaction, inputs, outputs)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create ballot token transaction")
+			}
+			signed, err := signBlinded(session.Nonce, challenge)
+			if err != nil {
+				return errors.Wrap(err, "Failed to sign blinded token")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save ballot token transaction")
+			}
+			result = *tr
+			blindSignature = signed
+			return nil
+		})
+		return result, blindSignature, err
+	}
+}
+
+func CastAnonymousVote(db *bolt.DB) transaction.CastAnonymousVoteFn {
+	return func(to, signature, verifier, token []byte) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			oneTimeKeyHash, err := wallet.HashedPublicKey(verifier)
+			if err != nil {
+				return errors.Wrap(err, "Failed to hash one-time key")
+			}
+			switch spender, err := getTokenSpender(tx, oneTimeKeyHash); {
+			case err != nil:
+				return err
+			case spender != nil || isMarkedPending(tx, pendingTokensBucket(), oneTimeKeyHash):
+				return transaction.ErrTokenSpent
+			}
+			pool := transaction.BallotPoolHash()
+			ballot, err := getUnspentPoolBallot(tx, pool)
+			if err != nil {
+				return err
+			}
+			inputs := transaction.Inputs{
+				{
+					PublicKeyHash: pool,
+					Signature:     signature,
+					TransactionID: ballot.TransactionID,
+					Vout:          ballot.Vout,
+					Verifier:      verifier,
+					Token:         token,
+				},
+			}
+			outputs := transaction.Outputs{
+				{
+					PublicKeyHash: to,
+					Value:         ballot.Value,
+				},
+			}
+			tr, err := transaction.NewTypedTransaction(transaction.AnonymousVoteTransaction, inputs, outputs)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create anonymous vote transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save anonymous vote transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
+
+func saveTokenSpender(tx *bolt.Tx, oneTimeKeyHash, transactionID []byte) error {
+	if err := putState(tx, spentTokensBucket(), oneTimeKeyHash, transactionID); err != nil {
+		return errors.Wrapf(err, "Failed to mark token %x as spent", oneTimeKeyHash)
+	}
+	return nil
+}
+
+func saveSpentTokens(tx *bolt.Tx, tr transaction.Transaction) error {
+	if tr.Type != transaction.AnonymousVoteTransaction {
+		return nil
+	}
+	for _, in := range tr.Inputs {
+		oneTimeKeyHash, err := wallet.HashedPublicKey(in.Verifier)
+		if err != nil {
+			return errors.Wrap(err, "Failed to hash one-time key")
+		}
+		if err := saveTokenSpender(tx, oneTimeKeyHash, tr.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getTokenSpender(tx *bolt.Tx, oneTimeKeyHash []byte) ([]byte, error) {
+	b := tx.Bucket(spentTokensBucket())
+	if b == nil {
+		return nil, nil
+	}
+	spender := b.Get(oneTimeKeyHash)
+	if spender == nil {
+		return nil, nil
+	}
+	return append([]byte{}, spender...), nil
+}
+
+func GetTokenSpender(db *bolt.DB) transaction.GetTokenSpenderFn {
+	return func(oneTimeKeyHash []byte) ([]byte, error) {
+		var result []byte
+		err := view(db, func(tx *bolt.Tx) error {
+			spender, err := getTokenSpender(tx, oneTimeKeyHash)
+			if err != nil {
+				return err
+			}
+			result = spender
+			return nil
+		})
+		return result, err
+	}
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func TestTokenCommitmentAllowsOneOpenSession(t *testing.T) {
+	db, voters := newTestChain(t)
+	voter := voters[0].PublicKeyHash()
+	newTokenCommitment := NewTokenCommitment(db)
+	if _, err := newTokenCommitment(voter); err != nil {
+		t.Fatalf("Failed to open token session: %s", err)
+	}
+	if _, err := newTokenCommitment(voter); !errors.Is(err, transaction.ErrTokenSessionOpen) {
+		t.Fatalf("Second token session is opened with %v", err)
+	}
+	if _, err := newTokenCommitment(voters[1].PublicKeyHash()); err != nil {
+		t.Fatalf("Failed to open token session of another voter: %s", err)
+	}
+}
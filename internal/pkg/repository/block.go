@@ -12,9 +12,13 @@ type block struct {
 	PrevBlock        []byte                   `json:"prevBlock"`
 	TransactionHash  []byte                   `json:"transactionHash"`
 	Timestamp        int64                    `json:"timestamp"`
+	Slot             int                      `json:"slot"`
+	UTXORoot         []byte                   `json:"utxoRoot"`
 	TransactionCount int                      `json:"transactionCount"`
 	Transactions     transaction.Transactions `json:"transactions"`
 	Hash             []byte                   `json:"hash"`
+	Forger           []byte                   `json:"forger"`
+	Signature        []byte                   `json:"signature"`
 }
 
 func (b block) toBlock() blockchain.Block {
@@ -27,8 +31,12 @@ func (b block) toBlock() blockchain.Block {
 			Hash:            b.Hash,
 			Prev:            b.PrevBlock,
 			Timestamp:       b.Timestamp,
+			Slot:            b.Slot,
+			UTXORoot:        b.UTXORoot,
 			TransactionHash: b.TransactionHash,
 			Version:         b.Version,
+			Forger:          b.Forger,
+			Signature:       b.Signature,
 		},
 		Body: blockchain.Body{
 			Transactions:      b.Transactions,
@@ -45,8 +53,12 @@ func newBlock(b blockchain.Block) block {
 		PrevBlock:        b.Header.Prev,
 		TransactionHash:  b.Header.TransactionHash,
 		Timestamp:        b.Header.Timestamp,
+		Slot:             b.Header.Slot,
+		UTXORoot:         b.Header.UTXORoot,
 		TransactionCount: b.Body.TransactionsCount,
 		Transactions:     b.Body.Transactions,
 		Hash:             b.Header.Hash,
+		Forger:           b.Header.Forger,
+		Signature:        b.Header.Signature,
 	}
 }
@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
+	"os"
 
 	"github.com/boltdb/bolt"
 	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/pkg/errors"
 )
@@ -20,7 +24,7 @@ func tipKey() []byte {
 func GetTip(db *bolt.DB) blockchain.GetTipFn {
 	return func() []byte {
 		var tip []byte
-		db.View(func(tx *bolt.Tx) error {
+		view(db, func(tx *bolt.Tx) error {
 			tip = getTip(tx)
 			return nil
 		})
@@ -33,13 +37,17 @@ func getTip(tx *bolt.Tx) []byte {
 	if b == nil {
 		return nil
 	}
-	return b.Get(tipKey())
+	tip := b.Get(tipKey())
+	if tip == nil {
+		return nil
+	}
+	return append([]byte{}, tip...)
 }
 
 func InitBlockchain(db *bolt.DB) blockchain.InitBlockchainFn {
 	return func(genesis blockchain.Block) ([]byte, error) {
 		var tip []byte
-		err := db.Update(func(tx *bolt.Tx) error {
+		err := update(db, func(tx *bolt.Tx) error {
 			b, err := tx.CreateBucket(blocksBucket())
 			if err != nil {
 				return errors.Wrap(err, "Failed to create blocks bucket")
@@ -87,7 +95,7 @@ func addBlock(tx *bolt.Tx, block blockchain.Block) ([]byte, error) {
 func AddBlock(db *bolt.DB) blockchain.AddBlockFn {
 	return func(block blockchain.Block) ([]byte, error) {
 		var tip []byte
-		err := db.Update(func(tx *bolt.Tx) error {
+		err := update(db, func(tx *bolt.Tx) error {
 			created, err := addBlockWithUTXO(tx, block)
 			if err != nil {
 				return errors.Wrapf(err, "Failed to add block %s", block)
@@ -100,75 +108,181 @@ func AddBlock(db *bolt.DB) blockchain.AddBlockFn {
 }
 
 func addBlockWithUTXO(tx *bolt.Tx, block blockchain.Block) ([]byte, error) {
+	if err := applyTransactions(tx, block.Body.Transactions); err != nil {
+		return nil, err
+	}
+	if root := stateRoot(tx); bytes.Compare(root, block.Header.UTXORoot) != 0 {
+		return nil, errors.Wrapf(blockchain.ErrInvalidBlock, "State root after block %x is %x instead of %x", block.Header.Hash, root, block.Header.UTXORoot)
+	}
+	return storeBlock(tx, block)
+}
+
+func storeBlock(tx *bolt.Tx, block blockchain.Block) ([]byte, error) {
 	tip, err := addBlock(tx, block)
 	if err != nil {
 		return nil, err
 	}
-	for _, transaction := range block.Body.Transactions {
+	if err := indexBlock(tx, block); err != nil {
+		return nil, err
+	}
+	log.Debug("Block stored",
+		logger.BlockHash(block.Header.Hash),
+		logger.F("height", getBlockHeight(tx, block.Header.Hash)),
+		logger.F("transactions", len(block.Body.Transactions)),
+	)
+	return tip, nil
+}
+
+func applyTransactions(tx *bolt.Tx, transactions transaction.Transactions) error {
+	for _, transaction := range transactions {
 		if err := deleteTransaction(tx, transaction); err != nil {
-			return nil, err
+			return err
+		}
+		if err := spendTransactionUTXOs(tx, transaction); err != nil {
+			return err
 		}
 		if err := saveUTXOs(tx, transaction.UTXOs()); err != nil {
-			return nil, err
+			return err
+		}
+		if revocation, ok := transaction.Revocation(); ok {
+			if err := saveRevocation(tx, revocation); err != nil {
+				return err
+			}
+		}
+		if slashing, ok := transaction.Slashing(); ok {
+			if err := saveSlashing(tx, slashing); err != nil {
+				return err
+			}
+		}
+		if err := saveSpentTokens(tx, transaction); err != nil {
+			return err
+		}
+		if err := saveElectionData(tx, transaction); err != nil {
+			return err
+		}
+		if err := saveRingData(tx, transaction); err != nil {
+			return err
+		}
+		if err := saveRecastData(tx, transaction); err != nil {
+			return err
+		}
+		if err := savePartyData(tx, transaction); err != nil {
+			return err
+		}
+		if err := saveEligibleVoters(tx, transaction); err != nil {
+			return err
+		}
+		if err := saveUpgradeData(tx, transaction); err != nil {
+			return err
 		}
 	}
-	return tip, nil
+	return nil
+}
+
+var errProjected = errors.New("State root projected")
+
+func ProjectUTXORoot(db *bolt.DB) blockchain.ProjectUTXORootFn {
+	return func(txs transaction.Transactions) ([]byte, error) {
+		var root []byte
+		err := update(db, func(tx *bolt.Tx) error {
+			if err := applyTransactions(tx, txs); err != nil {
+				return err
+			}
+			root = stateRoot(tx)
+			return errProjected
+		})
+		if err != errProjected {
+			return nil, errors.Wrap(err, "Failed to project state root")
+		}
+		return root, nil
+	}
+}
+
+func ProjectGenesisRoot() blockchain.ProjectUTXORootFn {
+	return func(txs transaction.Transactions) ([]byte, error) {
+		file, err := ioutil.TempFile("", "genesis-*.db")
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create genesis state database")
+		}
+		file.Close()
+		defer os.Remove(file.Name())
+		db, err := bolt.Open(file.Name(), 0600, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to open genesis state database")
+		}
+		defer db.Close()
+		return ProjectUTXORoot(db)(txs)
+	}
+}
+
+func getBlock(tx *bolt.Tx, hash []byte) (*blockchain.Block, error) {
+	b := tx.Bucket(blocksBucket())
+	if b == nil {
+		return nil, errors.New("Blocks bucket does not exist")
+	}
+	rawBlock := b.Get(hash)
+	if rawBlock == nil {
+		return nil, nil
+	}
+	var serialized block
+	if err := json.Unmarshal(rawBlock, &serialized); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal serialized block %s", rawBlock)
+	}
+	bl := serialized.toBlock()
+	return &bl, nil
 }
 
 func GetBlock(db *bolt.DB) blockchain.GetBlockFn {
 	return func(hash []byte) (*blockchain.Block, error) {
 		var result *blockchain.Block
-		err := db.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket(blocksBucket())
-			if b == nil {
-				return errors.New("Blocks bucket does not exist")
-			}
-			rawBlock := b.Get(hash)
-			if rawBlock == nil {
-				return nil
-			}
-			var serialized block
-			if err := json.Unmarshal(rawBlock, &serialized); err != nil {
-				return errors.Wrapf(err, "Failed to unmarshal serialized block %s", rawBlock)
+		err := view(db, func(tx *bolt.Tx) error {
+			block, err := getBlock(tx, hash)
+			if err != nil {
+				return err
 			}
-			bl := serialized.toBlock()
-			result = &bl
+			result = block
 			return nil
 		})
 		return result, err
 	}
 }
 
-func verifyTransactions(tx *bolt.Tx, transactions transaction.Transactions) (transaction.Transactions, transaction.Transactions, error) {
+func verifyTransactions(tx *bolt.Tx, transactions transaction.Transactions, limit int) (transaction.Transactions, transaction.Transactions, error) {
 	var valids transaction.Transactions
 	var invalids transaction.Transactions
 	for _, t := range transactions {
+		if len(valids) == limit {
+			break
+		}
 		sum, err := getInputSum(tx, t)
 		switch {
 		case errors.Is(err, transaction.ErrUTXONotFound):
 			invalids = append(invalids, t)
 		case err != nil:
 			return nil, nil, errors.Wrapf(err, "Failed to get sum of inputs for transaction %s", t)
-		case t.Outputs.Sum() != sum:
+		case !t.IsAuthority() && !t.IsBase() && t.Outputs.Sum() != sum:
 			invalids = append(invalids, t)
 		default:
 			valids = append(valids, t)
 			if err := deleteTransactionUTXOs(tx, t); err != nil {
 				return nil, nil, errors.Wrapf(err, "Failed to delete candidate transaction from utxo set %s", t)
 			}
-			if len(valids) == blockchain.MaxBlockSize {
-				break
-			}
 		}
 	}
 	return valids, invalids, nil
 }
 
-func ForgeBlock(db *bolt.DB) blockchain.ForgeBlockFn {
-	return func(txs transaction.Transactions) (*blockchain.Block, error) {
+func ForgeBlock(db *bolt.DB, getParameters blockchain.GetParametersFn, newBlock blockchain.NewBlockFn) blockchain.ForgeBlockFn {
+	return func(slot int, txs transaction.Transactions) (*blockchain.Block, error) {
+		parameters, err := getParameters()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to retrieve chain parameters")
+		}
 		var block *blockchain.Block
-		err := db.Update(func(tx *bolt.Tx) error {
-			valids, invalids, err := verifyTransactions(tx, txs)
+		err = update(db, func(tx *bolt.Tx) error {
+			tip := getTip(tx)
+			rules := parameters.Rules(getBlockHeight(tx, tip) + 1)
+			valids, invalids, err := verifyTransactions(tx, txs, rules.MaxBlockSize)
 			if err != nil {
 				return err
 			}
@@ -178,15 +292,17 @@ func ForgeBlock(db *bolt.DB) blockchain.ForgeBlockFn {
 			if len(valids) == 1 {
 				return nil
 			}
-			tip := getTip(tx)
-			newBlock, err := blockchain.NewBlock(tip, valids)
+			if err := applyTransactions(tx, valids); err != nil {
+				return errors.Wrap(err, "Failed to apply transactions")
+			}
+			forged, err := newBlock(rules, tip, slot, stateRoot(tx), valids)
 			if err != nil {
 				return errors.Wrap(err, "Failed to set up new block")
 			}
-			if _, err := addBlockWithUTXO(tx, *newBlock); err != nil {
+			if _, err := storeBlock(tx, *forged); err != nil {
 				return errors.Wrap(err, "Failed to add block to database")
 			}
-			block = newBlock
+			block = forged
 			return nil
 		})
 		return block, err
@@ -195,8 +311,8 @@ func ForgeBlock(db *bolt.DB) blockchain.ForgeBlockFn {
 
 func AddNewBlock(db *bolt.DB) blockchain.AddNewBlockFn {
 	return func(block blockchain.Block) error {
-		return db.Update(func(tx *bolt.Tx) error {
-			_, invalids, err := verifyTransactions(tx, block.Body.Transactions)
+		return update(db, func(tx *bolt.Tx) error {
+			_, invalids, err := verifyTransactions(tx, block.Body.Transactions, len(block.Body.Transactions))
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/pkg/errors"
+)
+
+func checkpointsBucket() []byte {
+	return []byte("checkpoints")
+}
+
+func ChainState(db *bolt.DB) blockchain.ChainStateFn {
+	return func() (*blockchain.Checkpoint, error) {
+		var result *blockchain.Checkpoint
+		err := view(db, func(tx *bolt.Tx) error {
+			tip := getTip(tx)
+			if tip == nil {
+				return nil
+			}
+			result = &blockchain.Checkpoint{
+				Height:   getBlockHeight(tx, tip),
+				Hash:     tip,
+				UTXORoot: stateRoot(tx),
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func getCheckpoint(tx *bolt.Tx) (*blockchain.Checkpoint, error) {
+	b := tx.Bucket(checkpointsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	key, raw := b.Cursor().Last()
+	if key == nil {
+		return nil, nil
+	}
+	var checkpoint blockchain.Checkpoint
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal checkpoint %s", raw)
+	}
+	return &checkpoint, nil
+}
+
+func GetCheckpoint(db *bolt.DB) blockchain.GetCheckpointFn {
+	return func() (*blockchain.Checkpoint, error) {
+		var result *blockchain.Checkpoint
+		err := view(db, func(tx *bolt.Tx) error {
+			checkpoint, err := getCheckpoint(tx)
+			result = checkpoint
+			return err
+		})
+		return result, err
+	}
+}
+
+func SaveCheckpoint(db *bolt.DB) blockchain.SaveCheckpointFn {
+	return func(checkpoint blockchain.Checkpoint) error {
+		return update(db, func(tx *bolt.Tx) error {
+			latest, err := getCheckpoint(tx)
+			if err != nil {
+				return err
+			}
+			if latest != nil && latest.Height >= checkpoint.Height {
+				return nil
+			}
+			b, err := getOrCreateBucket(tx, checkpointsBucket())
+			if err != nil {
+				return err
+			}
+			raw, err := json.Marshal(checkpoint)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to serialize checkpoint at height %d", checkpoint.Height)
+			}
+			if err := b.Put(intKey(checkpoint.Height), raw); err != nil {
+				return errors.Wrapf(err, "Failed to save checkpoint at height %d", checkpoint.Height)
+			}
+			return nil
+		})
+	}
+}
@@ -1,6 +1,38 @@
 package repository
 
-import "github.com/boltdb/bolt"
+import (
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
+)
+
+const slowTransaction = 250 * time.Millisecond
+
+var log = logger.Discard()
+
+func SetLogger(l logger.Logger) {
+	log = l
+}
+
+func observe(start time.Time, kind string) {
+	elapsed := time.Since(start)
+	metrics.BoltTransactions.Observe(elapsed.Seconds(), kind)
+	if elapsed >= slowTransaction {
+		log.Warn("Slow bolt transaction", logger.F("kind", kind), logger.F("duration", elapsed.String()))
+	}
+}
+
+func update(db *bolt.DB, fn func(*bolt.Tx) error) error {
+	defer observe(time.Now(), metrics.Update)
+	return db.Update(fn)
+}
+
+func view(db *bolt.DB, fn func(*bolt.Tx) error) error {
+	defer observe(time.Now(), metrics.View)
+	return db.View(fn)
+}
 
 func transactionsArray(transactions ...func(*bolt.Tx) error) func(*bolt.Tx) error {
 	return func(tx *bolt.Tx) error {
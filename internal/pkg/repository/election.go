@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func electionBucket() []byte {
+	return []byte("election")
+}
+
+func encryptedTallyBucket() []byte {
+	return []byte("encrypted-tally")
+}
+
+func tallyDecryptionsBucket() []byte {
+	return []byte("tally-decryptions")
+}
+
+func electionKeyKey() []byte {
+	return []byte("key")
+}
+
+func ballotsKey() []byte {
+	return []byte("ballots")
+}
+
+func tallyKey() []byte {
+	return []byte("tally")
+}
+
+func getElectionKey(tx *bolt.Tx) (*elgamal.ElectionKey, error) {
+	b := tx.Bucket(electionBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(electionKeyKey())
+	if raw == nil {
+		return nil, nil
+	}
+	var key elgamal.ElectionKey
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal election key %s", raw)
+	}
+	return &key, nil
+}
+
+func saveElectionKey(tx *bolt.Tx, key elgamal.ElectionKey) error {
+	raw, err := json.Marshal(key)
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize election key")
+	}
+	if err := putState(tx, electionBucket(), electionKeyKey(), raw); err != nil {
+		return errors.Wrap(err, "Failed to save election key")
+	}
+	return nil
+}
+
+func getEncryptedTally(tx *bolt.Tx) (election.EncryptedTally, error) {
+	result := election.EncryptedTally{
+		Ciphertexts: map[string]elgamal.Ciphertext{},
+	}
+	if b := tx.Bucket(electionBucket()); b != nil {
+		if raw := b.Get(ballotsKey()); raw != nil {
+			result.Ballots = int(binary.BigEndian.Uint64(raw))
+		}
+	}
+	b := tx.Bucket(encryptedTallyBucket())
+	if b == nil {
+		return result, nil
+	}
+	c := b.Cursor()
+	for party, raw := c.First(); party != nil; party, raw = c.Next() {
+		var ciphertext elgamal.Ciphertext
+		if err := json.Unmarshal(raw, &ciphertext); err != nil {
+			return election.EncryptedTally{}, errors.Wrapf(err, "Failed to unmarshal encrypted tally %s", raw)
+		}
+		result.Ciphertexts[string(party)] = ciphertext
+	}
+	return result, nil
+}
+
+func addEncryptedBallot(tx *bolt.Tx, ballot elgamal.EncryptedBallot) error {
+	tally, err := getEncryptedTally(tx)
+	if err != nil {
+		return err
+	}
+	for _, choice := range ballot.Choices {
+		sum := tally.Ciphertexts[choice.Party]
+		added, err := sum.Add(choice.Ciphertext)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to add encrypted choice for %s", choice.Party)
+		}
+		raw, err := json.Marshal(added)
+		if err != nil {
+			return errors.Wrap(err, "Failed to serialize encrypted tally")
+		}
+		if err := putState(tx, encryptedTallyBucket(), []byte(choice.Party), raw); err != nil {
+			return errors.Wrapf(err, "Failed to save encrypted tally for %s", choice.Party)
+		}
+		tally.Ciphertexts[choice.Party] = added
+	}
+	count := make([]byte, 8)
+	binary.BigEndian.PutUint64(count, uint64(tally.Ballots+1))
+	if err := putState(tx, electionBucket(), ballotsKey(), count); err != nil {
+		return errors.Wrap(err, "Failed to save ballot count")
+	}
+	return nil
+}
+
+func saveElectionSchedule(tx *bolt.Tx, schedule election.Schedule) error {
+	raw, err := json.Marshal(schedule)
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize election schedule")
+	}
+	if err := putState(tx, electionBucket(), scheduleKey(), raw); err != nil {
+		return errors.Wrap(err, "Failed to save election schedule")
+	}
+	return nil
+}
+
+func getElectionSchedule(tx *bolt.Tx) (*election.Schedule, error) {
+	b := tx.Bucket(electionBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(scheduleKey())
+	if raw == nil {
+		return nil, nil
+	}
+	var schedule election.Schedule
+	if err := json.Unmarshal(raw, &schedule); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal election schedule %s", raw)
+	}
+	return &schedule, nil
+}
+
+func saveElectionData(tx *bolt.Tx, tr transaction.Transaction) error {
+	if key, ok := tr.ElectionKey(); ok {
+		return saveElectionKey(tx, *key)
+	}
+	if schedule, ok := tr.ElectionSchedule(); ok {
+		return saveElectionSchedule(tx, *schedule)
+	}
+	if ballot, ok := tr.EncryptedBallot(); ok {
+		return addEncryptedBallot(tx, *ballot)
+	}
+	return nil
+}
+
+func GetElectionKey(db *bolt.DB) election.GetElectionKeyFn {
+	return func() (*elgamal.ElectionKey, error) {
+		var result *elgamal.ElectionKey
+		err := view(db, func(tx *bolt.Tx) error {
+			key, err := getElectionKey(tx)
+			if err != nil {
+				return err
+			}
+			result = key
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetElectionSchedule(db *bolt.DB) election.GetScheduleFn {
+	return func() (*election.Schedule, error) {
+		var result *election.Schedule
+		err := view(db, func(tx *bolt.Tx) error {
+			schedule, err := getElectionSchedule(tx)
+			if err != nil {
+				return err
+			}
+			result = schedule
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetEncryptedTally(db *bolt.DB) election.GetEncryptedTallyFn {
+	return func() (election.EncryptedTally, error) {
+		var result election.EncryptedTally
+		err := view(db, func(tx *bolt.Tx) error {
+			tally, err := getEncryptedTally(tx)
+			if err != nil {
+				return err
+			}
+			result = tally
+			return nil
+		})
+		return result, err
+	}
+}
+
+func CastEncryptedVote(db *bolt.DB) transaction.CastEncryptedVoteFn {
+	return func(from, signature, verifier, ballot []byte) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			usedUTXO, err := getUnspentBallot(tx, from)
+			if err != nil {
+				return err
+			}
+			inputs := transaction.Inputs{
+				{
+					PublicKeyHash: from,
+					Signature:     signature,
+					TransactionID: usedUTXO.TransactionID,
+					Vout:          usedUTXO.Vout,
+					Verifier:      verifier,
+				},
+			}
+			outputs := transaction.Outputs{
+				{
+					PublicKeyHash: transaction.BallotBoxHash(),
+					Value:         transaction.VoteValue,
+				},
+			}
+			if usedUTXO.Value > transaction.VoteValue {
+				outputs = append(outputs, transaction.Output{
+					PublicKeyHash: from,
+					Value:         usedUTXO.Value - transaction.VoteValue,
+				})
+			}
+			tr, err := transaction.NewPayloadTransaction(transaction.EncryptedVoteTransaction, inputs, outputs, ballot)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create encrypted vote transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save encrypted vote transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
+
+func HasPendingBallots(db *bolt.DB) election.HasPendingBallotsFn {
+	return func() (bool, error) {
+		var result bool
+		err := view(db, func(tx_ *bolt.Tx) error {
+			b := tx_.Bucket(transactionsBucket())
+			if b == nil {
+				return nil
+			}
+			c := b.Cursor()
+			for key, value := c.First(); key != nil; key, value = c.Next() {
+				var t tx
+				if err := json.Unmarshal(value, &t); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal transaction %s", value)
+				}
+				if t.Type == transaction.EncryptedVoteTransaction {
+					result = true
+					return nil
+				}
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func SaveDecryption(db *bolt.DB) election.SaveDecryptionFn {
+	return func(decryption election.TrusteeDecryption) ([]election.TrusteeDecryption, error) {
+		var result []election.TrusteeDecryption
+		err := update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, tallyDecryptionsBucket())
+			if err != nil {
+				return err
+			}
+			raw, err := json.Marshal(decryption)
+			if err != nil {
+				return errors.Wrap(err, "Failed to serialize trustee decryption")
+			}
+			if err := b.Put([]byte(strconv.Itoa(decryption.Trustee)), raw); err != nil {
+				return errors.Wrapf(err, "Failed to save decryption of trustee %d", decryption.Trustee)
+			}
+			c := b.Cursor()
+			for key, value := c.First(); key != nil; key, value = c.Next() {
+				var d election.TrusteeDecryption
+				if err := json.Unmarshal(value, &d); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal trustee decryption %s", value)
+				}
+				result = append(result, d)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetTally(db *bolt.DB) election.GetTallyFn {
+	return func() (*election.Tally, error) {
+		var result *election.Tally
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(electionBucket())
+			if b == nil {
+				return nil
+			}
+			raw := b.Get(tallyKey())
+			if raw == nil {
+				return nil
+			}
+			var tally election.Tally
+			if err := json.Unmarshal(raw, &tally); err != nil {
+				return errors.Wrapf(err, "Failed to unmarshal tally %s", raw)
+			}
+			result = &tally
+			return nil
+		})
+		return result, err
+	}
+}
+
+func SaveTally(db *bolt.DB) election.SaveTallyFn {
+	return func(tally election.Tally) error {
+		return update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, electionBucket())
+			if err != nil {
+				return err
+			}
+			raw, err := json.Marshal(tally)
+			if err != nil {
+				return errors.Wrap(err, "Failed to serialize tally")
+			}
+			if err := b.Put(tallyKey(), raw); err != nil {
+				return errors.Wrap(err, "Failed to save tally")
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/pkg/errors"
+)
+
+type forgedHeader struct {
+	Hash     []byte          `json:"hash"`
+	Evidence json.RawMessage `json:"evidence"`
+}
+
+func forgedHeadersBucket() []byte {
+	return []byte("forged-headers")
+}
+
+func equivocationsBucket() []byte {
+	return []byte("equivocations")
+}
+
+func forgerHeightKey(forger []byte, height int) []byte {
+	return append(append([]byte{}, forger...), intKey(height)...)
+}
+
+func RecordForgedHeader(db *bolt.DB) blockchain.RecordHeaderFn {
+	return func(forger []byte, height int, hash []byte, evidence json.RawMessage) (*blockchain.Equivocation, error) {
+		var result *blockchain.Equivocation
+		err := update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, forgedHeadersBucket())
+			if err != nil {
+				return err
+			}
+			key := forgerHeightKey(forger, height)
+			if raw := b.Get(key); raw != nil {
+				var recorded forgedHeader
+				if err := json.Unmarshal(raw, &recorded); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal forged header %s", raw)
+				}
+				if bytes.Compare(recorded.Hash, hash) != 0 {
+					result = &blockchain.Equivocation{
+						Forger: forger,
+						Height: height,
+						First:  recorded.Evidence,
+						Second: evidence,
+					}
+				}
+				return nil
+			}
+			raw, err := json.Marshal(forgedHeader{Hash: hash, Evidence: evidence})
+			if err != nil {
+				return errors.Wrapf(err, "Failed to serialize forged header %x", hash)
+			}
+			if err := b.Put(key, raw); err != nil {
+				return errors.Wrapf(err, "Failed to save forged header %x", hash)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func SaveEquivocation(db *bolt.DB) blockchain.SaveEquivocationFn {
+	return func(proof blockchain.Equivocation) (bool, error) {
+		var saved bool
+		err := update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, equivocationsBucket())
+			if err != nil {
+				return err
+			}
+			key := forgerHeightKey(proof.Forger, proof.Height)
+			if b.Get(key) != nil {
+				return nil
+			}
+			raw, err := json.Marshal(proof)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to serialize equivocation of %x", proof.Forger)
+			}
+			if err := b.Put(key, raw); err != nil {
+				return errors.Wrapf(err, "Failed to save equivocation of %x", proof.Forger)
+			}
+			saved = true
+			return nil
+		})
+		return saved, err
+	}
+}
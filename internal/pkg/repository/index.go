@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func blockHeightsBucket() []byte {
+	return []byte("block-heights")
+}
+
+func heightsBucket() []byte {
+	return []byte("heights")
+}
+
+func transactionIndexBucket() []byte {
+	return []byte("transaction-index")
+}
+
+func addressIndexBucket() []byte {
+	return []byte("address-index")
+}
+
+func eligibleVotersBucket() []byte {
+	return []byte("eligible-voters")
+}
+
+type transactionLocation struct {
+	Block    []byte `json:"block"`
+	Height   int    `json:"height"`
+	Position int    `json:"position"`
+}
+
+func intKey(height int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	return key
+}
+
+func getBlockHeight(tx *bolt.Tx, hash []byte) int {
+	b := tx.Bucket(blockHeightsBucket())
+	if b == nil {
+		return 0
+	}
+	raw := b.Get(hash)
+	if raw == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(raw))
+}
+
+func getHashAtHeight(tx *bolt.Tx, height int) []byte {
+	b := tx.Bucket(heightsBucket())
+	if b == nil {
+		return nil
+	}
+	return b.Get(intKey(height))
+}
+
+func indexBlock(tx *bolt.Tx, block blockchain.Block) error {
+	height := 1
+	if block.Header.Prev != nil {
+		prevHeight := getBlockHeight(tx, block.Header.Prev)
+		if prevHeight == 0 {
+			return errors.Errorf("Previous block %x of %x is not indexed", block.Header.Prev, block.Header.Hash)
+		}
+		height = prevHeight + 1
+	}
+	blockHeights, err := getOrCreateBucket(tx, blockHeightsBucket())
+	if err != nil {
+		return err
+	}
+	if err := blockHeights.Put(block.Header.Hash, intKey(height)); err != nil {
+		return errors.Wrapf(err, "Failed to index height of block %x", block.Header.Hash)
+	}
+	heights, err := getOrCreateBucket(tx, heightsBucket())
+	if err != nil {
+		return err
+	}
+	if err := heights.Put(intKey(height), block.Header.Hash); err != nil {
+		return errors.Wrapf(err, "Failed to index block at height %d", height)
+	}
+	for _, record := range block.Records(height) {
+		if err := indexTransaction(tx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indexTransaction(tx *bolt.Tx, record blockchain.TransactionRecord) error {
+	b, err := getOrCreateBucket(tx, transactionIndexBucket())
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(transactionLocation{
+		Block:    record.Block,
+		Height:   record.Height,
+		Position: record.Position,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize location of transaction %x", record.Transaction.ID)
+	}
+	if err := b.Put(record.Transaction.ID, raw); err != nil {
+		return errors.Wrapf(err, "Failed to index transaction %x", record.Transaction.ID)
+	}
+	addresses := map[string]bool{}
+	for _, in := range record.Transaction.Inputs {
+		addresses[string(in.PublicKeyHash)] = true
+	}
+	for _, out := range record.Transaction.Outputs {
+		addresses[string(out.PublicKeyHash)] = true
+	}
+	for address := range addresses {
+		if err := indexAddress(tx, []byte(address), record.Transaction.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indexAddress(tx *bolt.Tx, publicKeyHash, transactionID []byte) error {
+	index, err := getOrCreateBucket(tx, addressIndexBucket())
+	if err != nil {
+		return err
+	}
+	b, err := index.CreateBucketIfNotExists(publicKeyHash)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create address index of %x", publicKeyHash)
+	}
+	sequence, err := b.NextSequence()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to retrieve address index sequence of %x", publicKeyHash)
+	}
+	if err := b.Put(intKey(int(sequence)), transactionID); err != nil {
+		return errors.Wrapf(err, "Failed to index transaction %x of %x", transactionID, publicKeyHash)
+	}
+	return nil
+}
+
+func eligibleVoters(tr transaction.Transaction) [][]byte {
+	if revocation, ok := tr.Revocation(); ok {
+		return [][]byte{revocation.Replacement}
+	}
+	if !tr.IsBase() {
+		return nil
+	}
+	voters := make([][]byte, 0, len(tr.Outputs))
+	for _, out := range tr.Outputs {
+		voters = append(voters, out.PublicKeyHash)
+	}
+	return voters
+}
+
+func saveEligibleVoter(tx *bolt.Tx, publicKeyHash []byte) error {
+	if err := putState(tx, eligibleVotersBucket(), publicKeyHash, []byte{1}); err != nil {
+		return errors.Wrapf(err, "Failed to save eligible voter %x", publicKeyHash)
+	}
+	return nil
+}
+
+func saveEligibleVoters(tx *bolt.Tx, tr transaction.Transaction) error {
+	for _, voter := range eligibleVoters(tr) {
+		if err := saveEligibleVoter(tx, voter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getTransactionRecord(tx *bolt.Tx, id []byte) (*blockchain.TransactionRecord, error) {
+	b := tx.Bucket(transactionIndexBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(id)
+	if raw == nil {
+		return nil, nil
+	}
+	var location transactionLocation
+	if err := json.Unmarshal(raw, &location); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal location of transaction %x", id)
+	}
+	block, err := getBlock(tx, location.Block)
+	switch {
+	case err != nil:
+		return nil, err
+	case block == nil || location.Position >= len(block.Body.Transactions):
+		return nil, errors.Errorf("Indexed block %x of transaction %x does not exist", location.Block, id)
+	}
+	return &blockchain.TransactionRecord{
+		Transaction: block.Body.Transactions[location.Position],
+		Block:       location.Block,
+		Height:      location.Height,
+		Position:    location.Position,
+	}, nil
+}
+
+func isIndexed(tx *bolt.Tx) bool {
+	tip := getTip(tx)
+	return tip == nil || getBlockHeight(tx, tip) != 0
+}
+
+func IndexBlockchain(db *bolt.DB) error {
+	return update(db, func(tx *bolt.Tx) error {
+		if isIndexed(tx) {
+			return nil
+		}
+		var chain blockchain.Blocks
+		for current := getTip(tx); current != nil; {
+			block, err := getBlock(tx, current)
+			switch {
+			case err != nil:
+				return err
+			case block == nil:
+				return errors.Errorf("Block %x does not exist", current)
+			}
+			chain = append(chain, *block)
+			current = block.Header.Prev
+		}
+		for i := len(chain) - 1; i >= 0; i-- {
+			if err := indexBlock(tx, chain[i]); err != nil {
+				return errors.Wrapf(err, "Failed to index block %x", chain[i].Header.Hash)
+			}
+			for _, t := range chain[i].Body.Transactions {
+				if err := saveEligibleVoters(tx, t); err != nil {
+					return err
+				}
+			}
+		}
+		log.Info("Blockchain indexed", logger.F("blocks", len(chain)))
+		return nil
+	})
+}
+
+func GetHeight(db *bolt.DB) blockchain.GetHeightFn {
+	return func() (int, error) {
+		var result int
+		err := view(db, func(tx *bolt.Tx) error {
+			tip := getTip(tx)
+			if tip == nil {
+				return nil
+			}
+			result = getBlockHeight(tx, tip)
+			if result == 0 {
+				return errors.Errorf("Tip %x is not indexed", tip)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetBlockHeight(db *bolt.DB) blockchain.GetBlockHeightFn {
+	return func(hash []byte) (int, error) {
+		var result int
+		err := view(db, func(tx *bolt.Tx) error {
+			result = getBlockHeight(tx, hash)
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetBlockByHeight(db *bolt.DB) blockchain.GetBlockByHeightFn {
+	return func(height int) (*blockchain.Block, error) {
+		var result *blockchain.Block
+		err := view(db, func(tx *bolt.Tx) error {
+			hash := getHashAtHeight(tx, height)
+			if hash == nil {
+				return nil
+			}
+			block, err := getBlock(tx, hash)
+			if err != nil {
+				return err
+			}
+			result = block
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetHeaders(db *bolt.DB) blockchain.GetHeadersFn {
+	return func(from, count int) ([]blockchain.Header, error) {
+		result := []blockchain.Header{}
+		err := view(db, func(tx *bolt.Tx) error {
+			for height := from; height < from+count; height++ {
+				hash := getHashAtHeight(tx, height)
+				if hash == nil {
+					return nil
+				}
+				block, err := getBlock(tx, hash)
+				switch {
+				case err != nil:
+					return err
+				case block == nil:
+					return errors.Errorf("Block %x at height %d is not stored", hash, height)
+				}
+				result = append(result, block.Header)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetTransactionRecord(db *bolt.DB) blockchain.GetTransactionRecordFn {
+	return func(id []byte) (*blockchain.TransactionRecord, error) {
+		var result *blockchain.TransactionRecord
+		err := view(db, func(tx *bolt.Tx) error {
+			record, err := getTransactionRecord(tx, id)
+			if err != nil {
+				return err
+			}
+			result = record
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetAddressHistory(db *bolt.DB) blockchain.GetAddressHistoryFn {
+	return func(publicKeyHash []byte) ([]blockchain.TransactionRecord, error) {
+		var result []blockchain.TransactionRecord
+		err := view(db, func(tx *bolt.Tx) error {
+			index := tx.Bucket(addressIndexBucket())
+			if index == nil {
+				return nil
+			}
+			b := index.Bucket(publicKeyHash)
+			if b == nil {
+				return nil
+			}
+			cursor := b.Cursor()
+			for key, id := cursor.Last(); key != nil; key, id = cursor.Prev() {
+				record, err := getTransactionRecord(tx, id)
+				switch {
+				case err != nil:
+					return err
+				case record == nil:
+					return errors.Errorf("Indexed transaction %x of %x does not exist", id, publicKeyHash)
+				}
+				result = append(result, *record)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func IsEligibleVoter(db *bolt.DB) blockchain.IsEligibleFn {
+	return func(publicKeyHash []byte) (bool, error) {
+		var result bool
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(eligibleVotersBucket())
+			result = b != nil && b.Get(publicKeyHash) != nil
+			return nil
+		})
+		return result, err
+	}
+}
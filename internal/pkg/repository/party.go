@@ -5,12 +5,15 @@ import (
 
 	"github.com/boltdb/bolt"
 	_party "github.com/nebser/crypto-vote/internal/pkg/party"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/pkg/errors"
 )
 
 type party struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
+	Name     string            `json:"name"`
+	Address  string            `json:"address"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Inactive bool              `json:"inactive,omitempty"`
 }
 
 func partiesBucket() []byte {
@@ -19,59 +22,110 @@ func partiesBucket() []byte {
 
 func newParty(p _party.Party) party {
 	return party{
-		Address: p.Address,
-		Name:    p.Name,
+		Address:  p.Address,
+		Name:     p.Name,
+		Metadata: p.Metadata,
+		Inactive: !p.Active,
 	}
 }
 
 func (p party) toParty() _party.Party {
 	return _party.Party{
-		Address: p.Address,
-		Name:    p.Name,
+		Address:  p.Address,
+		Name:     p.Name,
+		Metadata: p.Metadata,
+		Active:   !p.Inactive,
 	}
 }
 
+func saveParty(tx *bolt.Tx, p _party.Party) error {
+	raw, err := json.Marshal(newParty(p))
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize party")
+	}
+	if err := putState(tx, partiesBucket(), []byte(p.Address), raw); err != nil {
+		return errors.Wrapf(err, "Failed to save party %#v", p)
+	}
+	return nil
+}
+
+func getParty(tx *bolt.Tx, address string) (*_party.Party, error) {
+	b := tx.Bucket(partiesBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get([]byte(address))
+	if raw == nil {
+		return nil, nil
+	}
+	var partyRaw party
+	if err := json.Unmarshal(raw, &partyRaw); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal parties")
+	}
+	party := partyRaw.toParty()
+	return &party, nil
+}
+
+func savePartyData(tx *bolt.Tx, tr transaction.Transaction) error {
+	if p, ok := tr.Party(); ok {
+		return saveParty(tx, *p)
+	}
+	return nil
+}
+
 func SaveParty(db *bolt.DB) _party.SavePartyFn {
 	return func(party _party.Party) error {
-		return db.Update(func(tx *bolt.Tx) error {
-			b := tx.Bucket(partiesBucket())
-			if b == nil {
-				created, err := tx.CreateBucket(partiesBucket())
-				if err != nil {
-					return errors.Wrapf(err, "Failed to create bucket %s", partiesBucket())
-				}
-				b = created
+		return update(db, func(tx *bolt.Tx) error {
+			return saveParty(tx, party)
+		})
+	}
+}
+
+func UpdateParty(db *bolt.DB, newPartyTransaction transaction.NewPartyTransactionFn) transaction.UpdatePartyFn {
+	return func(address string, change _party.ChangeFn) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			switch pending, err := isPending(tx, func(t transaction.Transaction) bool {
+				p, ok := t.Party()
+				return ok && p.Address == address
+			}); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check pending changes of party %s", address)
+			case pending:
+				return _party.ErrPartyPending
+			}
+			current, err := getParty(tx, address)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to retrieve party %s", address)
+			}
+			changed, err := change(current)
+			if err != nil {
+				return err
 			}
-			raw, err := json.Marshal(newParty(party))
+			changed.Address = address
+			tr, err := newPartyTransaction(changed)
 			if err != nil {
-				return errors.Wrap(err, "Failed to serialize party")
+				return errors.Wrap(err, "Failed to create party transaction")
 			}
-			if err := b.Put([]byte(party.Address), raw); err != nil {
-				return errors.Wrapf(err, "Failed to save party %#v", party)
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save party transaction")
 			}
+			result = *tr
 			return nil
 		})
+		return result, err
 	}
 }
 
 func GetParty(db *bolt.DB) _party.GetPartyFn {
 	return func(address string) (*_party.Party, error) {
 		var result *_party.Party
-		err := db.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket(partiesBucket())
-			if b == nil {
-				return nil
-			}
-			raw := b.Get([]byte(address))
-			if raw == nil {
-				return nil
-			}
-			var partyRaw party
-			if err := json.Unmarshal(raw, &partyRaw); err != nil {
-				return errors.Wrap(err, "Failed to unmarshal parties")
+		err := view(db, func(tx *bolt.Tx) error {
+			party, err := getParty(tx, address)
+			if err != nil {
+				return err
 			}
-			party := partyRaw.toParty()
-			result = &party
+			result = party
 			return nil
 		})
 		return result, err
@@ -81,7 +135,7 @@ func GetParty(db *bolt.DB) _party.GetPartyFn {
 func GetParties(db *bolt.DB) _party.GetPartiesFn {
 	return func() (_party.Parties, error) {
 		result := _party.Parties{}
-		err := db.View(func(tx *bolt.Tx) error {
+		err := view(db, func(tx *bolt.Tx) error {
 			b := tx.Bucket(partiesBucket())
 			if b == nil {
 				return nil
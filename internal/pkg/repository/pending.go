@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func pendingRevocationsBucket() []byte {
+	return []byte("pending-revocations")
+}
+
+func pendingReplacementsBucket() []byte {
+	return []byte("pending-replacements")
+}
+
+func pendingTokensBucket() []byte {
+	return []byte("pending-tokens")
+}
+
+func pendingKeyImagesBucket() []byte {
+	return []byte("pending-key-images")
+}
+
+func pendingSlashingsBucket() []byte {
+	return []byte("pending-slashings")
+}
+
+type pendingMarker struct {
+	bucket []byte
+	key    []byte
+}
+
+func pendingMarkers(tr transaction.Transaction) ([]pendingMarker, error) {
+	var markers []pendingMarker
+	if r, ok := tr.Revocation(); ok {
+		markers = append(markers,
+			pendingMarker{bucket: pendingRevocationsBucket(), key: r.Revoked},
+			pendingMarker{bucket: pendingReplacementsBucket(), key: r.Replacement},
+		)
+	}
+	if s, ok := tr.Slashing(); ok {
+		markers = append(markers, pendingMarker{bucket: pendingSlashingsBucket(), key: s.Forger})
+	}
+	if keyImage, ok := tr.KeyImage(); ok {
+		markers = append(markers, pendingMarker{bucket: pendingKeyImagesBucket(), key: keyImage})
+	}
+	if tr.Type == transaction.AnonymousVoteTransaction {
+		for _, in := range tr.Inputs {
+			oneTimeKeyHash, err := wallet.HashedPublicKey(in.Verifier)
+			if err != nil {
+				return nil, errors.Wrap(err, "Failed to hash one-time key")
+			}
+			markers = append(markers, pendingMarker{bucket: pendingTokensBucket(), key: oneTimeKeyHash})
+		}
+	}
+	return markers, nil
+}
+
+func isMarkedPending(tx *bolt.Tx, bucket, key []byte) bool {
+	b := tx.Bucket(bucket)
+	return b != nil && b.Get(key) != nil
+}
+
+func markPending(tx *bolt.Tx, tr transaction.Transaction) error {
+	markers, err := pendingMarkers(tr)
+	if err != nil {
+		return err
+	}
+	for _, marker := range markers {
+		b, err := getOrCreateBucket(tx, marker.bucket)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(marker.key, tr.ID); err != nil {
+			return errors.Wrapf(err, "Failed to mark %x as pending in bucket %s", marker.key, marker.bucket)
+		}
+	}
+	return nil
+}
+
+func releasePending(tx *bolt.Tx, tr transaction.Transaction) error {
+	markers, err := pendingMarkers(tr)
+	if err != nil {
+		return err
+	}
+	for _, marker := range markers {
+		b := tx.Bucket(marker.bucket)
+		if b == nil || bytes.Compare(b.Get(marker.key), tr.ID) != 0 {
+			continue
+		}
+		if err := b.Delete(marker.key); err != nil {
+			return errors.Wrapf(err, "Failed to release %x from bucket %s", marker.key, marker.bucket)
+		}
+	}
+	return nil
+}
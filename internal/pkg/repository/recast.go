@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func recastScheduleBucket() []byte {
+	return []byte("recast-schedule")
+}
+
+func recastsBucket() []byte {
+	return []byte("recasts")
+}
+
+func latestRecastsBucket() []byte {
+	return []byte("latest-recasts")
+}
+
+func scheduleKey() []byte {
+	return []byte("schedule")
+}
+
+func getRecastSchedule(tx *bolt.Tx) (*election.Schedule, error) {
+	b := tx.Bucket(recastScheduleBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(scheduleKey())
+	if raw == nil {
+		return nil, nil
+	}
+	var schedule election.Schedule
+	if err := json.Unmarshal(raw, &schedule); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal recast schedule %s", raw)
+	}
+	return &schedule, nil
+}
+
+func saveRecastSchedule(tx *bolt.Tx, schedule election.Schedule) error {
+	raw, err := json.Marshal(schedule)
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize recast schedule")
+	}
+	if err := putState(tx, recastScheduleBucket(), scheduleKey(), raw); err != nil {
+		return errors.Wrap(err, "Failed to save recast schedule")
+	}
+	return nil
+}
+
+func getRecast(tx *bolt.Tx, transactionID []byte) (*transaction.Recast, error) {
+	b := tx.Bucket(recastsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(transactionID)
+	if raw == nil {
+		return nil, nil
+	}
+	var recast transaction.Recast
+	if err := json.Unmarshal(raw, &recast); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal recast %s", raw)
+	}
+	return &recast, nil
+}
+
+func getLatestRecast(tx *bolt.Tx, voter []byte) ([]byte, error) {
+	b := tx.Bucket(latestRecastsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	latest := b.Get(voter)
+	if latest == nil {
+		return nil, nil
+	}
+	return append([]byte{}, latest...), nil
+}
+
+func saveRecast(tx *bolt.Tx, transactionID []byte, recast transaction.Recast) error {
+	raw, err := json.Marshal(recast)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize recast %#v", recast)
+	}
+	if err := putState(tx, recastsBucket(), transactionID, raw); err != nil {
+		return errors.Wrapf(err, "Failed to save recast %x", transactionID)
+	}
+	if err := putState(tx, latestRecastsBucket(), recast.Voter, transactionID); err != nil {
+		return errors.Wrapf(err, "Failed to save latest recast of %x", recast.Voter)
+	}
+	return nil
+}
+
+func saveRecastData(tx *bolt.Tx, tr transaction.Transaction) error {
+	if schedule, ok := tr.RecastSchedule(); ok {
+		return saveRecastSchedule(tx, *schedule)
+	}
+	if recast, ok := tr.Recast(); ok {
+		return saveRecast(tx, tr.ID, *recast)
+	}
+	return nil
+}
+
+func nextRecastSequence(tx *bolt.Tx, voter []byte) (int, []byte, error) {
+	latest, err := getLatestRecast(tx, voter)
+	if err != nil || latest == nil {
+		return 0, nil, err
+	}
+	previous, err := getRecast(tx, latest)
+	switch {
+	case err != nil:
+		return 0, nil, err
+	case previous == nil:
+		return 0, nil, errors.Errorf("Recast %x of %x does not exist", latest, voter)
+	}
+	return previous.Sequence + 1, latest, nil
+}
+
+func GetRecastSchedule(db *bolt.DB) transaction.GetRecastScheduleFn {
+	return func() (*election.Schedule, error) {
+		var result *election.Schedule
+		err := view(db, func(tx *bolt.Tx) error {
+			schedule, err := getRecastSchedule(tx)
+			if err != nil {
+				return err
+			}
+			result = schedule
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetRecast(db *bolt.DB) transaction.GetRecastFn {
+	return func(transactionID []byte) (*transaction.Recast, error) {
+		var result *transaction.Recast
+		err := view(db, func(tx *bolt.Tx) error {
+			recast, err := getRecast(tx, transactionID)
+			if err != nil {
+				return err
+			}
+			result = recast
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetLatestRecast(db *bolt.DB) transaction.GetLatestRecastFn {
+	return func(voter []byte) ([]byte, error) {
+		var result []byte
+		err := view(db, func(tx *bolt.Tx) error {
+			latest, err := getLatestRecast(tx, voter)
+			if err != nil {
+				return err
+			}
+			result = latest
+			return nil
+		})
+		return result, err
+	}
+}
+
+func NextRecastSequence(db *bolt.DB) transaction.NextRecastSequenceFn {
+	return func(voter []byte) (int, error) {
+		var result int
+		err := view(db, func(tx *bolt.Tx) error {
+			sequence, _, err := nextRecastSequence(tx, voter)
+			if err != nil {
+				return err
+			}
+			result = sequence
+			return nil
+		})
+		return result, err
+	}
+}
+
+func CastRecastVote(db *bolt.DB) transaction.CastRecastVoteFn {
+	return func(recast transaction.Recast, signature, verifier []byte) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			sequence, latest, err := nextRecastSequence(tx, recast.Voter)
+			switch {
+			case err != nil:
+				return err
+			case recast.Sequence != sequence:
+				return transaction.ErrInvalidRecastSequence
+			}
+			var ballot *transaction.UTXO
+			if latest == nil {
+				utxos, err := getUTXOsByPublicKey(tx, recast.Voter)
+				switch {
+				case err != nil:
+					return errors.Wrapf(err, "Failed to retrieve utxos for %x", recast.Voter)
+				case len(utxos) == 0:
+					return transaction.ErrInsufficientVotes
+				}
+				ballot, err = getUnspentBallot(tx, recast.Voter)
+				if errors.Is(err, transaction.ErrInsufficientVotes) {
+					return transaction.ErrRecastPending
+				}
+				if err != nil {
+					return err
+				}
+			} else {
+				ballot, err = getTransactionUTXO(tx, latest, 0)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to retrieve escrowed vote %x", latest)
+				}
+				if ballot == nil {
+					return election.ErrElectionClosed
+				}
+				switch pending, err := isPendingSpend(tx, *ballot); {
+				case err != nil:
+					return errors.Wrapf(err, "Failed to check pending transactions for %x", recast.Voter)
+				case pending:
+					return transaction.ErrRecastPending
+				}
+			}
+			payload, err := recast.Payload()
+			if err != nil {
+				return err
+			}
+			inputs := transaction.Inputs{
+				{
+					PublicKeyHash: recast.Voter,
+					Signature:     signature,
+					TransactionID: ballot.TransactionID,
+					Vout:          ballot.Vout,
+					Verifier:      verifier,
+				},
+			}
+			outputs := transaction.Outputs{
+				{
+					PublicKeyHash: transaction.RecastEscrowHash(),
+					Value:         transaction.VoteValue,
+				},
+			}
+			if ballot.Value > transaction.VoteValue {
+				outputs = append(outputs, transaction.Output{
+					PublicKeyHash: recast.Voter,
+					Value:         ballot.Value - transaction.VoteValue,
+				})
+			}
+			tr, err := transaction.NewPayloadTransaction(transaction.RecastVoteTransaction, inputs, outputs, payload)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create recast vote transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save recast vote transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
+
+func ReleaseRecasts(db *bolt.DB, newRelease transaction.NewRecastReleaseTransactionFn) transaction.ReleaseRecastsFn {
+	return func() (transaction.Transactions, error) {
+		var result transaction.Transactions
+		err := update(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(latestRecastsBucket())
+			if b == nil {
+				return nil
+			}
+			var latests [][]byte
+			if err := b.ForEach(func(_, latest []byte) error {
+				latests = append(latests, append([]byte{}, latest...))
+				return nil
+			}); err != nil {
+				return errors.Wrap(err, "Failed to iterate latest recasts")
+			}
+			for _, latest := range latests {
+				escrow, err := getTransactionUTXO(tx, latest, 0)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to retrieve escrowed vote %x", latest)
+				}
+				if escrow == nil {
+					continue
+				}
+				switch pending, err := isPendingSpend(tx, *escrow); {
+				case err != nil:
+					return errors.Wrapf(err, "Failed to check pending release of %x", latest)
+				case pending:
+					continue
+				}
+				recast, err := getRecast(tx, latest)
+				if err != nil {
+					return err
+				}
+				if recast == nil {
+					return errors.Errorf("Recast %x does not exist", latest)
+				}
+				release, err := newRelease(*escrow, recast.Party)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to create release of %x", latest)
+				}
+				if err := saveTransaction(tx, *release); err != nil {
+					return errors.Wrap(err, "Failed to save recast release transaction")
+				}
+				result = append(result, *release)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
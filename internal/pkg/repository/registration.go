@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func RegisterVoter(db *bolt.DB, newVoterRegistrationTransaction transaction.NewVoterRegistrationTransactionFn) transaction.RegisterVoterFn {
+	return func(voter []byte) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			if b := tx.Bucket(eligibleVotersBucket()); b != nil && b.Get(voter) != nil {
+				return transaction.ErrVoterRegistered
+			}
+			switch revoked, err := isRevoked(tx, voter); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check revocation of %x", voter)
+			case revoked:
+				return transaction.ErrVoterRevoked
+			}
+			switch owned, err := getUTXOsByPublicKey(tx, voter); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to retrieve utxos for %x", voter)
+			case len(owned) > 0:
+				return transaction.ErrVoterRegistered
+			}
+			switch pending, err := isPending(tx, func(t transaction.Transaction) bool {
+				registered, ok := t.RegisteredVoter()
+				return ok && bytes.Compare(registered, voter) == 0
+			}); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check pending registrations of %x", voter)
+			case pending:
+				return transaction.ErrVoterRegistered
+			}
+			tr, err := newVoterRegistrationTransaction(voter)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create voter registration transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save voter registration transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+type revocation struct {
+	Revoked       string `json:"revoked"`
+	Replacement   string `json:"replacement"`
+	TransactionID string `json:"transactionId"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+func revocationsBucket() []byte {
+	return []byte("revocations")
+}
+
+func revocationReplacementsBucket() []byte {
+	return []byte("revocation-replacements")
+}
+
+func newRevocation(r transaction.Revocation) revocation {
+	return revocation{
+		Revoked:       base64.StdEncoding.EncodeToString(r.Revoked),
+		Replacement:   base64.StdEncoding.EncodeToString(r.Replacement),
+		TransactionID: base64.StdEncoding.EncodeToString(r.TransactionID),
+		Timestamp:     r.Timestamp,
+	}
+}
+
+func (r revocation) toRevocation() transaction.Revocation {
+	revoked, _ := base64.StdEncoding.DecodeString(r.Revoked)
+	replacement, _ := base64.StdEncoding.DecodeString(r.Replacement)
+	transactionID, _ := base64.StdEncoding.DecodeString(r.TransactionID)
+	return transaction.Revocation{
+		Revoked:       revoked,
+		Replacement:   replacement,
+		TransactionID: transactionID,
+		Timestamp:     r.Timestamp,
+	}
+}
+
+func saveRevocation(tx *bolt.Tx, r transaction.Revocation) error {
+	raw, err := json.Marshal(newRevocation(r))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize revocation %#v", r)
+	}
+	if err := putState(tx, revocationsBucket(), r.Revoked, raw); err != nil {
+		return errors.Wrapf(err, "Failed to save revocation of %x", r.Revoked)
+	}
+	if err := putState(tx, revocationReplacementsBucket(), r.Replacement, r.Revoked); err != nil {
+		return errors.Wrapf(err, "Failed to index replacement of %x", r.Revoked)
+	}
+	return nil
+}
+
+func isReplacement(tx *bolt.Tx, publicKeyHash []byte) bool {
+	b := tx.Bucket(revocationReplacementsBucket())
+	return b != nil && b.Get(publicKeyHash) != nil
+}
+
+func isRevoked(tx *bolt.Tx, publicKeyHash []byte) (bool, error) {
+	if isMarkedPending(tx, pendingRevocationsBucket(), publicKeyHash) {
+		return true, nil
+	}
+	r, err := getRevocation(tx, publicKeyHash)
+	return r != nil, err
+}
+
+func getRevocation(tx *bolt.Tx, publicKeyHash []byte) (*transaction.Revocation, error) {
+	b := tx.Bucket(revocationsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(publicKeyHash)
+	if raw == nil {
+		return nil, nil
+	}
+	var r revocation
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal revocation %s", raw)
+	}
+	result := r.toRevocation()
+	return &result, nil
+}
+
+func RevokeVoter(db *bolt.DB, newRevocationTransaction transaction.NewRevocationTransactionFn) transaction.RevokeFn {
+	return func(revoked, replacement []byte) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			switch existing, err := isRevoked(tx, revoked); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check revocation of %x", revoked)
+			case existing:
+				return transaction.ErrVoterRevoked
+			}
+			switch existing, err := isRevoked(tx, replacement); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check revocation of %x", replacement)
+			case existing:
+				return transaction.ErrVoterRevoked
+			}
+			if isMarkedPending(tx, pendingReplacementsBucket(), replacement) {
+				return transaction.ErrInvalidReplacement
+			}
+			switch received, err := hasReceivedBallot(tx, replacement); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check ballots of %x", replacement)
+			case received:
+				return transaction.ErrInvalidReplacement
+			}
+			ballot, err := getBallot(tx, revoked)
+			if err != nil {
+				return err
+			}
+			switch pending, err := isPendingSpend(tx, ballot); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check pending transactions for %x", revoked)
+			case pending:
+				return transaction.ErrBallotSpent
+			}
+			tr, err := newRevocationTransaction(ballot, replacement)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create revocation transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save revocation transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
+
+func hasReceivedBallot(tx *bolt.Tx, publicKeyHash []byte) (bool, error) {
+	if isReplacement(tx, publicKeyHash) {
+		return true, nil
+	}
+	if b := tx.Bucket(eligibleVotersBucket()); b != nil && b.Get(publicKeyHash) != nil {
+		return true, nil
+	}
+	if index := tx.Bucket(addressIndexBucket()); index != nil && index.Bucket(publicKeyHash) != nil {
+		return true, nil
+	}
+	switch owned, err := getUTXOsByPublicKey(tx, publicKeyHash); {
+	case err != nil:
+		return false, errors.Wrapf(err, "Failed to retrieve utxos for %x", publicKeyHash)
+	case len(owned) > 0:
+		return true, nil
+	}
+	return false, nil
+}
+
+func getBallot(tx *bolt.Tx, voter []byte) (transaction.UTXO, error) {
+	utxos, err := getUTXOsByPublicKey(tx, voter)
+	if err != nil {
+		return transaction.UTXO{}, errors.Wrapf(err, "Failed to retrieve utxos for %x", voter)
+	}
+	var ballots transaction.UTXOs
+	for _, utxo := range utxos {
+		record, err := getTransactionRecord(tx, utxo.TransactionID)
+		if err != nil {
+			return transaction.UTXO{}, errors.Wrapf(err, "Failed to retrieve transaction %x", utxo.TransactionID)
+		}
+		if record == nil {
+			continue
+		}
+		if _, ok := record.Transaction.Revocation(); ok || record.Transaction.IsBase() {
+			ballots = append(ballots, utxo)
+		}
+	}
+	switch len(ballots) {
+	case 0:
+		return transaction.UTXO{}, transaction.ErrBallotSpent
+	case 1:
+		return ballots[0], nil
+	default:
+		return transaction.UTXO{}, transaction.ErrAmbiguousBallot
+	}
+}
+
+func IsRevoked(db *bolt.DB) transaction.IsRevokedFn {
+	return func(publicKeyHash []byte) (bool, error) {
+		var result bool
+		err := view(db, func(tx *bolt.Tx) error {
+			r, err := getRevocation(tx, publicKeyHash)
+			if err != nil {
+				return err
+			}
+			result = r != nil
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetRevocation(db *bolt.DB) transaction.GetRevocationFn {
+	return func(publicKeyHash []byte) (*transaction.Revocation, error) {
+		var result *transaction.Revocation
+		err := view(db, func(tx *bolt.Tx) error {
+			r, err := getRevocation(tx, publicKeyHash)
+			if err != nil {
+				return err
+			}
+			result = r
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetRevocations(db *bolt.DB) transaction.GetRevocationsFn {
+	return func() (transaction.Revocations, error) {
+		result := transaction.Revocations{}
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(revocationsBucket())
+			if b == nil {
+				return nil
+			}
+			c := b.Cursor()
+			for key, raw := c.First(); key != nil; key, raw = c.Next() {
+				var r revocation
+				if err := json.Unmarshal(raw, &r); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal revocation %s", raw)
+				}
+				result = append(result, r.toRevocation())
+			}
+			return nil
+		})
+		return result, err
+	}
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func voterRingBucket() []byte {
+	return []byte("voter-ring")
+}
+
+func keyImagesBucket() []byte {
+	return []byte("key-images")
+}
+
+func ringKey() []byte {
+	return []byte("ring")
+}
+
+func getRing(tx *bolt.Tx) ([][]byte, error) {
+	b := tx.Bucket(voterRingBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(ringKey())
+	if raw == nil {
+		return nil, nil
+	}
+	var ring [][]byte
+	if err := json.Unmarshal(raw, &ring); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal voter ring %s", raw)
+	}
+	return ring, nil
+}
+
+func saveRing(tx *bolt.Tx, ring [][]byte) error {
+	raw, err := json.Marshal(ring)
+	if err != nil {
+		return errors.Wrap(err, "Failed to serialize voter ring")
+	}
+	if err := putState(tx, voterRingBucket(), ringKey(), raw); err != nil {
+		return errors.Wrap(err, "Failed to save voter ring")
+	}
+	return nil
+}
+
+func getKeyImageSpender(tx *bolt.Tx, keyImage []byte) ([]byte, error) {
+	b := tx.Bucket(keyImagesBucket())
+	if b == nil {
+		return nil, nil
+	}
+	spender := b.Get(keyImage)
+	if spender == nil {
+		return nil, nil
+	}
+	return append([]byte{}, spender...), nil
+}
+
+func saveKeyImageSpender(tx *bolt.Tx, keyImage, transactionID []byte) error {
+	if err := putState(tx, keyImagesBucket(), keyImage, transactionID); err != nil {
+		return errors.Wrapf(err, "Failed to mark key image %x as used", keyImage)
+	}
+	return nil
+}
+
+func saveRingData(tx *bolt.Tx, tr transaction.Transaction) error {
+	if ring, ok := tr.EligibleVoters(); ok {
+		return saveRing(tx, ring)
+	}
+	if keyImage, ok := tr.KeyImage(); ok {
+		return saveKeyImageSpender(tx, keyImage, tr.ID)
+	}
+	return nil
+}
+
+func GetRing(db *bolt.DB) transaction.GetRingFn {
+	return func() ([][]byte, error) {
+		var result [][]byte
+		err := view(db, func(tx *bolt.Tx) error {
+			ring, err := getRing(tx)
+			if err != nil {
+				return err
+			}
+			result = ring
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetKeyImageSpender(db *bolt.DB) transaction.GetKeyImageSpenderFn {
+	return func(keyImage []byte) ([]byte, error) {
+		var result []byte
+		err := view(db, func(tx *bolt.Tx) error {
+			spender, err := getKeyImageSpender(tx, keyImage)
+			if err != nil {
+				return err
+			}
+			result = spender
+			return nil
+		})
+		return result, err
+	}
+}
+
+func CastRingVote(db *bolt.DB) transaction.CastRingVoteFn {
+	return func(to []byte, signature wallet.RingSignature) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			switch spender, err := getKeyImageSpender(tx, signature.KeyImage); {
+			case err != nil:
+				return err
+			case spender != nil || isMarkedPending(tx, pendingKeyImagesBucket(), signature.KeyImage):
+				return transaction.ErrKeyImageUsed
+			}
+			pool := transaction.RingPoolHash()
+			ballot, err := getUnspentPoolBallot(tx, pool)
+			if err != nil {
+				return err
+			}
+			inputs := transaction.Inputs{
+				{
+					PublicKeyHash: pool,
+					TransactionID: ballot.TransactionID,
+					Vout:          ballot.Vout,
+					Ring:          &signature,
+				},
+			}
+			outputs := transaction.Outputs{
+				{
+					PublicKeyHash: to,
+					Value:         ballot.Value,
+				},
+			}
+			tr, err := transaction.NewTypedTransaction(transaction.RingVoteTransaction, inputs, outputs)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create ring vote transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save ring vote transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func TestCastRingVoteRejectsReusedKeyImage(t *testing.T) {
+	db, voters := newTestChainWith(t, genesis.Options{Time: time.Now(), Ring: true})
+	ring, err := GetRing(db)()
+	if err != nil {
+		t.Fatalf("Failed to load ring: %s", err)
+	}
+	castRingVote := CastRingVote(db)
+	cast := func(voter wallet.Wallet, to []byte) error {
+		message, err := transaction.RingVoteMessage(to)
+		if err != nil {
+			t.Fatalf("Failed to create ring vote message: %s", err)
+		}
+		signature, err := wallet.SignRing(message, ring, voter.PrivateKey)
+		if err != nil {
+			t.Fatalf("Failed to sign ring vote: %s", err)
+		}
+		_, err = castRingVote(to, *signature)
+		return err
+	}
+	if err := cast(voters[0], []byte("party")); err != nil {
+		t.Fatalf("Failed to cast ring vote: %s", err)
+	}
+	if err := cast(voters[0], []byte("another party")); !errors.Is(err, transaction.ErrKeyImageUsed) {
+		t.Fatalf("Second ring vote of the same voter is cast with %v", err)
+	}
+	if err := cast(voters[1], []byte("party")); err != nil {
+		t.Fatalf("Failed to cast ring vote of another voter: %s", err)
+	}
+}
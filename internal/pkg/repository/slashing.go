@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+type slashing struct {
+	Forger             string          `json:"forger"`
+	Offence            string          `json:"offence"`
+	Height             int             `json:"height"`
+	BlockHash          string          `json:"blockHash"`
+	StakeTransactionID string          `json:"stakeTransactionId"`
+	Reason             string          `json:"reason"`
+	Evidence           json.RawMessage `json:"evidence"`
+	TransactionID      string          `json:"transactionId"`
+	Timestamp          int64           `json:"timestamp"`
+}
+
+func slashingsBucket() []byte {
+	return []byte("slashings")
+}
+
+func newSlashing(s transaction.Slashing) slashing {
+	return slashing{
+		Forger:             base64.StdEncoding.EncodeToString(s.Forger),
+		Offence:            string(s.Offence),
+		Height:             s.Height,
+		BlockHash:          base64.StdEncoding.EncodeToString(s.BlockHash),
+		StakeTransactionID: base64.StdEncoding.EncodeToString(s.StakeTransactionID),
+		Reason:             s.Reason,
+		Evidence:           s.Evidence,
+		TransactionID:      base64.StdEncoding.EncodeToString(s.TransactionID),
+		Timestamp:          s.Timestamp,
+	}
+}
+
+func (s slashing) toSlashing() transaction.Slashing {
+	forger, _ := base64.StdEncoding.DecodeString(s.Forger)
+	blockHash, _ := base64.StdEncoding.DecodeString(s.BlockHash)
+	stakeTransactionID, _ := base64.StdEncoding.DecodeString(s.StakeTransactionID)
+	transactionID, _ := base64.StdEncoding.DecodeString(s.TransactionID)
+	return transaction.Slashing{
+		Forger:             forger,
+		Offence:            transaction.Offence(s.Offence),
+		Height:             s.Height,
+		BlockHash:          blockHash,
+		StakeTransactionID: stakeTransactionID,
+		Reason:             s.Reason,
+		Evidence:           s.Evidence,
+		TransactionID:      transactionID,
+		Timestamp:          s.Timestamp,
+	}
+}
+
+func saveSlashing(tx *bolt.Tx, s transaction.Slashing) error {
+	raw, err := json.Marshal(newSlashing(s))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize slashing of %x", s.Forger)
+	}
+	if err := putState(tx, slashingsBucket(), s.Forger, raw); err != nil {
+		return errors.Wrapf(err, "Failed to save slashing of %x", s.Forger)
+	}
+	return nil
+}
+
+func getSlashing(tx *bolt.Tx, publicKeyHash []byte) (*transaction.Slashing, error) {
+	b := tx.Bucket(slashingsBucket())
+	if b == nil {
+		return nil, nil
+	}
+	raw := b.Get(publicKeyHash)
+	if raw == nil {
+		return nil, nil
+	}
+	var s slashing
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, errors.Wrapf(err, "Failed to unmarshal slashing %s", raw)
+	}
+	result := s.toSlashing()
+	return &result, nil
+}
+
+func Slash(db *bolt.DB, newSlashingTransaction transaction.NewSlashingTransactionFn) transaction.SlashFn {
+	return func(s transaction.Slashing) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			switch existing, err := getSlashing(tx, s.Forger); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check slashing of %x", s.Forger)
+			case existing != nil || isMarkedPending(tx, pendingSlashingsBucket(), s.Forger):
+				return transaction.ErrForgerSlashed
+			}
+			tr, err := newSlashingTransaction(s)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create slashing transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save slashing transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
+
+func IsSlashed(db *bolt.DB) transaction.IsSlashedFn {
+	return func(publicKeyHash []byte) (bool, error) {
+		var result bool
+		err := view(db, func(tx *bolt.Tx) error {
+			s, err := getSlashing(tx, publicKeyHash)
+			if err != nil {
+				return err
+			}
+			result = s != nil || isMarkedPending(tx, pendingSlashingsBucket(), publicKeyHash)
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetSlashing(db *bolt.DB) transaction.GetSlashingFn {
+	return func(publicKeyHash []byte) (*transaction.Slashing, error) {
+		var result *transaction.Slashing
+		err := view(db, func(tx *bolt.Tx) error {
+			s, err := getSlashing(tx, publicKeyHash)
+			if err != nil {
+				return err
+			}
+			result = s
+			return nil
+		})
+		return result, err
+	}
+}
+
+func GetSlashings(db *bolt.DB) transaction.GetSlashingsFn {
+	return func() (transaction.Slashings, error) {
+		result := transaction.Slashings{}
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(slashingsBucket())
+			if b == nil {
+				return nil
+			}
+			c := b.Cursor()
+			for key, raw := c.First(); key != nil; key, raw = c.Next() {
+				var s slashing
+				if err := json.Unmarshal(raw, &s); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal slashing %s", raw)
+				}
+				result = append(result, s.toSlashing())
+			}
+			return nil
+		})
+		return result, err
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/pkg/errors"
+)
+
+func missedSlotsBucket() []byte {
+	return []byte("missed-slots")
+}
+
+func missedSlotKey(slot int, role blockchain.SlotRole) []byte {
+	return append(intKey(slot), []byte(role)...)
+}
+
+func RecordMissedSlot(db *bolt.DB) blockchain.RecordMissedSlotFn {
+	return func(missed blockchain.MissedSlot) error {
+		return update(db, func(tx *bolt.Tx) error {
+			b, err := getOrCreateBucket(tx, missedSlotsBucket())
+			if err != nil {
+				return err
+			}
+			raw, err := json.Marshal(missed)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to serialize missed slot %d", missed.Slot)
+			}
+			if err := b.Put(missedSlotKey(missed.Slot, missed.Role), raw); err != nil {
+				return errors.Wrapf(err, "Failed to save missed slot %d", missed.Slot)
+			}
+			return nil
+		})
+	}
+}
+
+func GetMissedSlots(db *bolt.DB) blockchain.GetMissedSlotsFn {
+	return func() ([]blockchain.MissedSlot, error) {
+		result := []blockchain.MissedSlot{}
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(missedSlotsBucket())
+			if b == nil {
+				return nil
+			}
+			c := b.Cursor()
+			for key, raw := c.First(); key != nil; key, raw = c.Next() {
+				var missed blockchain.MissedSlot
+				if err := json.Unmarshal(raw, &missed); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal missed slot %s", raw)
+				}
+				result = append(result, missed)
+			}
+			return nil
+		})
+		return result, err
+	}
+}
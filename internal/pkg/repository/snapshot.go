@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func bucketEntries(tx *bolt.Tx, name []byte) ([]blockchain.SnapshotEntry, error) {
+	entries := []blockchain.SnapshotEntry{}
+	b := tx.Bucket(name)
+	if b == nil {
+		return entries, nil
+	}
+	err := b.ForEach(func(key, value []byte) error {
+		if isLocalState(name, key) {
+			return nil
+		}
+		entries = append(entries, blockchain.SnapshotEntry{
+			Key:   append([]byte{}, key...),
+			Value: append([]byte{}, value...),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read bucket %s", name)
+	}
+	return entries, nil
+}
+
+func rebuildUTXOsByPublicKey(tx *bolt.Tx) error {
+	if tx.Bucket(utxoByPublicKeyBucket()) != nil {
+		if err := tx.DeleteBucket(utxoByPublicKeyBucket()); err != nil {
+			return errors.Wrapf(err, "Failed to delete bucket %s", utxoByPublicKeyBucket())
+		}
+	}
+	b := tx.Bucket(utxoByTxBucket())
+	if b == nil {
+		return nil
+	}
+	all := transaction.UTXOs{}
+	err := b.ForEach(func(key, raw []byte) error {
+		var saved utxos
+		if err := json.Unmarshal(raw, &saved); err != nil {
+			return errors.Wrapf(err, "Failed to unmarshal utxos of transaction %x", key)
+		}
+		all = append(all, saved.toUTXOs()...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return saveUTXOsByPublicKey(tx, all)
+}
+
+func ExportSnapshot(db *bolt.DB) blockchain.ExportSnapshotFn {
+	return func() (*blockchain.Snapshot, error) {
+		var result *blockchain.Snapshot
+		err := view(db, func(tx *bolt.Tx) error {
+			tip := getTip(tx)
+			if tip == nil {
+				return errors.New("Blockchain is empty")
+			}
+			headers := make([]blockchain.Header, getBlockHeight(tx, tip))
+			var genesis blockchain.Block
+			for current, i := tip, len(headers)-1; current != nil; i-- {
+				block, err := getBlock(tx, current)
+				switch {
+				case err != nil:
+					return err
+				case block == nil || i < 0:
+					return errors.Errorf("Block %x is not indexed", current)
+				}
+				headers[i] = block.Header
+				if i == 0 {
+					genesis = *block
+				}
+				current = block.Header.Prev
+			}
+			buckets := map[string][]blockchain.SnapshotEntry{}
+			for _, name := range stateBuckets() {
+				entries, err := bucketEntries(tx, name)
+				if err != nil {
+					return errors.Wrapf(err, "Failed to export bucket %s", name)
+				}
+				buckets[string(name)] = entries
+			}
+			result = &blockchain.Snapshot{Genesis: genesis, Headers: headers, Buckets: buckets}
+			return nil
+		})
+		return result, err
+	}
+}
+
+func ImportSnapshot(db *bolt.DB) blockchain.ImportSnapshotFn {
+	return func(snapshot blockchain.Snapshot) error {
+		return update(db, func(tx *bolt.Tx) error {
+			if getTip(tx) != nil {
+				return errors.New("Snapshot can only be imported into an empty blockchain")
+			}
+			parameters, err := blockchain.GenesisParameters(snapshot.Genesis)
+			if err != nil {
+				return errors.Wrap(err, "Failed to load chain parameters of snapshot")
+			}
+			if _, err := storeBlock(tx, snapshot.Genesis); err != nil {
+				return errors.Wrapf(err, "Failed to store genesis block %x", snapshot.Genesis.Header.Hash)
+			}
+			for i, header := range snapshot.Headers[1:] {
+				if _, err := storeBlock(tx, blockchain.PrunedBlock(parameters.Rules(i+2), header)); err != nil {
+					return errors.Wrapf(err, "Failed to store header %x", header.Hash)
+				}
+			}
+			for name, entries := range snapshot.Buckets {
+				if !isStateBucket([]byte(name)) {
+					return errors.Wrapf(blockchain.ErrInvalidSnapshot, "Bucket %s is not part of chain state", name)
+				}
+				b, err := getOrCreateBucket(tx, []byte(name))
+				if err != nil {
+					return err
+				}
+				for _, entry := range entries {
+					if isLocalState([]byte(name), entry.Key) {
+						return errors.Wrapf(blockchain.ErrInvalidSnapshot, "Key %s of bucket %s is not part of chain state", entry.Key, name)
+					}
+					if err := b.Put(entry.Key, entry.Value); err != nil {
+						return errors.Wrapf(err, "Failed to import key %x into bucket %s", entry.Key, name)
+					}
+				}
+			}
+			if err := rebuildUTXOsByPublicKey(tx); err != nil {
+				return errors.Wrap(err, "Failed to rebuild utxos by public key")
+			}
+			if err := rebuildStateDigests(tx); err != nil {
+				return errors.Wrap(err, "Failed to rebuild state digests")
+			}
+			if root := stateRoot(tx); bytes.Compare(root, snapshot.Tip().UTXORoot) != 0 {
+				return errors.Wrapf(blockchain.ErrInvalidSnapshot, "State root of snapshot is %x instead of %x", root, snapshot.Tip().UTXORoot)
+			}
+			return nil
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/nebser/crypto-vote/internal/pkg/genesis"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func openTestDB(t *testing.T) *bolt.DB {
+	file, err := ioutil.TempFile("", "repository-*.db")
+	if err != nil {
+		t.Fatalf("Failed to create database file: %s", err)
+	}
+	file.Close()
+	db, err := bolt.Open(file.Name(), 0600, nil)
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.Remove(file.Name())
+	})
+	return db
+}
+
+func newTestWallet(t *testing.T) wallet.Wallet {
+	w, err := wallet.New()
+	if err != nil {
+		t.Fatalf("Failed to create wallet: %s", err)
+	}
+	return *w
+}
+
+func newTestChain(t *testing.T) (*bolt.DB, wallet.Wallets) {
+	return newTestChainWith(t, genesis.Options{Time: time.Now()})
+}
+
+func newTestChainWith(t *testing.T, options genesis.Options) (*bolt.DB, wallet.Wallets) {
+	voters := wallet.Wallets{newTestWallet(t), newTestWallet(t)}
+	parties := map[string]wallet.Wallet{"party": newTestWallet(t)}
+	spec := genesis.FromWallets(newTestWallet(t), parties, voters, options)
+	block, err := genesis.Build(spec, ProjectGenesisRoot())
+	if err != nil {
+		t.Fatalf("Failed to build genesis block: %s", err)
+	}
+	db := openTestDB(t)
+	if _, err := AddBlock(db)(*block); err != nil {
+		t.Fatalf("Failed to add genesis block: %s", err)
+	}
+	return db, voters
+}
+
+func rootOf(t *testing.T, db *bolt.DB) []byte {
+	var root []byte
+	view(db, func(tx *bolt.Tx) error {
+		root = stateRoot(tx)
+		return nil
+	})
+	return root
+}
+
+func rebuiltRootOf(t *testing.T, db *bolt.DB) []byte {
+	var root []byte
+	err := view(db, func(tx *bolt.Tx) error {
+		digests := map[string][]byte{}
+		for _, name := range stateBuckets() {
+			entries, err := bucketEntries(tx, name)
+			if err != nil {
+				return err
+			}
+			digests[string(name)] = blockchain.StateDigest(name, entries)
+		}
+		root = blockchain.StateRoot(digests)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to rebuild state root: %s", err)
+	}
+	return root
+}
+
+func TestSnapshotImportReproducesStateRoot(t *testing.T) {
+	db, _ := newTestChain(t)
+	snapshot, err := ExportSnapshot(db)()
+	if err != nil {
+		t.Fatalf("Failed to export snapshot: %s", err)
+	}
+	imported := openTestDB(t)
+	if err := ImportSnapshot(imported)(*snapshot); err != nil {
+		t.Fatalf("Failed to import snapshot: %s", err)
+	}
+	if expected, actual := rootOf(t, db), rootOf(t, imported); bytes.Compare(expected, actual) != 0 {
+		t.Errorf("Imported state root is %x instead of %x", actual, expected)
+	}
+	if bytes.Compare(rootOf(t, imported), snapshot.Tip().UTXORoot) != 0 {
+		t.Errorf("Imported state root doesn't match the snapshot tip")
+	}
+}
+
+func TestSnapshotImportRejectsTamperedState(t *testing.T) {
+	db, _ := newTestChain(t)
+	snapshot, err := ExportSnapshot(db)()
+	if err != nil {
+		t.Fatalf("Failed to export snapshot: %s", err)
+	}
+	name := string(utxoByTxBucket())
+	entries := snapshot.Buckets[name]
+	if len(entries) == 0 {
+		t.Fatalf("Snapshot contains no utxos")
+	}
+	snapshot.Buckets[name] = entries[1:]
+	err = ImportSnapshot(openTestDB(t))(*snapshot)
+	if !errors.Is(err, blockchain.ErrInvalidSnapshot) {
+		t.Errorf("Expected invalid snapshot error, got %v", err)
+	}
+}
+
+func TestStateRootIsMaintainedIncrementally(t *testing.T) {
+	db, voters := newTestChain(t)
+	if expected, actual := rebuiltRootOf(t, db), rootOf(t, db); bytes.Compare(expected, actual) != 0 {
+		t.Fatalf("State root after genesis is %x instead of %x", actual, expected)
+	}
+	vote, err := CastVote(db)(voters[0].PublicKeyHash(), voters[1].PublicKeyHash(), nil, voters[0].PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to cast vote: %s", err)
+	}
+	before := rootOf(t, db)
+	err = update(db, func(tx *bolt.Tx) error {
+		return applyTransactions(tx, transaction.Transactions{vote})
+	})
+	if err != nil {
+		t.Fatalf("Failed to apply vote: %s", err)
+	}
+	after := rootOf(t, db)
+	if bytes.Compare(before, after) == 0 {
+		t.Errorf("State root didn't change after applying a vote")
+	}
+	if expected := rebuiltRootOf(t, db); bytes.Compare(expected, after) != 0 {
+		t.Errorf("State root after vote is %x instead of %x", after, expected)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"bytes"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/blockchain"
+	"github.com/pkg/errors"
+)
+
+func stateDigestsBucket() []byte {
+	return []byte("state-digests")
+}
+
+func stateBuckets() [][]byte {
+	return [][]byte{
+		utxoByTxBucket(),
+		partiesBucket(),
+		revocationsBucket(),
+		revocationReplacementsBucket(),
+		slashingsBucket(),
+		spentTokensBucket(),
+		electionBucket(),
+		encryptedTallyBucket(),
+		recastScheduleBucket(),
+		recastsBucket(),
+		latestRecastsBucket(),
+		voterRingBucket(),
+		keyImagesBucket(),
+		eligibleVotersBucket(),
+		upgradesBucket(),
+	}
+}
+
+func isStateBucket(name []byte) bool {
+	for _, bucket := range stateBuckets() {
+		if bytes.Compare(bucket, name) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func isLocalState(bucket, key []byte) bool {
+	return bytes.Compare(bucket, electionBucket()) == 0 && bytes.Compare(key, tallyKey()) == 0
+}
+
+func updateStateDigest(tx *bolt.Tx, name []byte, update func([]byte) []byte) error {
+	b, err := getOrCreateBucket(tx, stateDigestsBucket())
+	if err != nil {
+		return err
+	}
+	if err := b.Put(name, update(b.Get(name))); err != nil {
+		return errors.Wrapf(err, "Failed to update state digest of bucket %s", name)
+	}
+	return nil
+}
+
+func putState(tx *bolt.Tx, name, key, value []byte) error {
+	b, err := getOrCreateBucket(tx, name)
+	if err != nil {
+		return err
+	}
+	if isLocalState(name, key) {
+		return b.Put(key, value)
+	}
+	old := b.Get(key)
+	if old != nil {
+		old = append([]byte{}, old...)
+	}
+	err = updateStateDigest(tx, name, func(digest []byte) []byte {
+		if old != nil {
+			digest = blockchain.RemoveStateEntry(digest, name, blockchain.SnapshotEntry{Key: key, Value: old})
+		}
+		return blockchain.AddStateEntry(digest, name, blockchain.SnapshotEntry{Key: key, Value: value})
+	})
+	if err != nil {
+		return err
+	}
+	return b.Put(key, value)
+}
+
+func stateDigests(tx *bolt.Tx) map[string][]byte {
+	digests := map[string][]byte{}
+	b := tx.Bucket(stateDigestsBucket())
+	if b == nil {
+		return digests
+	}
+	b.ForEach(func(name, digest []byte) error {
+		digests[string(name)] = append([]byte{}, digest...)
+		return nil
+	})
+	return digests
+}
+
+func stateRoot(tx *bolt.Tx) []byte {
+	return blockchain.StateRoot(stateDigests(tx))
+}
+
+func rebuildStateDigests(tx *bolt.Tx) error {
+	if tx.Bucket(stateDigestsBucket()) != nil {
+		if err := tx.DeleteBucket(stateDigestsBucket()); err != nil {
+			return errors.Wrapf(err, "Failed to delete bucket %s", stateDigestsBucket())
+		}
+	}
+	b, err := getOrCreateBucket(tx, stateDigestsBucket())
+	if err != nil {
+		return err
+	}
+	for _, name := range stateBuckets() {
+		entries, err := bucketEntries(tx, name)
+		if err != nil {
+			return err
+		}
+		if err := b.Put(name, blockchain.StateDigest(name, entries)); err != nil {
+			return errors.Wrapf(err, "Failed to save state digest of bucket %s", name)
+		}
+	}
+	return nil
+}
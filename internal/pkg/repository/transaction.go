@@ -1,12 +1,15 @@
 package repository
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"sort"
 
 	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
@@ -16,8 +19,10 @@ func transactionsBucket() []byte {
 
 type tx struct {
 	ID        string              `json:"id"`
+	Type      transaction.Type    `json:"type"`
 	Inputs    []transactionInput  `json:"inputs"`
 	Outputs   []transactionOutput `json:"outputs"`
+	Payload   string              `json:"payload,omitempty"`
 	Timestamp int64               `json:"timestamp"`
 }
 
@@ -31,10 +36,16 @@ func (t tx) toTransaction() transaction.Transaction {
 		outputs = append(outputs, out.toOutput())
 	}
 	id, _ := base64.StdEncoding.DecodeString(t.ID)
+	var payload []byte
+	if t.Payload != "" {
+		payload, _ = base64.StdEncoding.DecodeString(t.Payload)
+	}
 	return transaction.Transaction{
 		ID:        id,
+		Type:      t.Type,
 		Inputs:    inputs,
 		Outputs:   outputs,
+		Payload:   payload,
 		Timestamp: t.Timestamp,
 	}
 }
@@ -50,18 +61,22 @@ func newTX(transaction transaction.Transaction) tx {
 	}
 	return tx{
 		ID:        base64.StdEncoding.EncodeToString(transaction.ID),
+		Type:      transaction.Type,
 		Inputs:    inputs,
 		Outputs:   outputs,
+		Payload:   base64.StdEncoding.EncodeToString(transaction.Payload),
 		Timestamp: transaction.Timestamp,
 	}
 }
 
 type transactionInput struct {
-	TransactionID string `json:"transactionId"`
-	Vout          int    `json:"vout"`
-	PublicKeyHash string `json:"publicKeyHash"`
-	Signature     string `json:"signature"`
-	Verifier      string `json:"verifier"`
+	TransactionID string                `json:"transactionId"`
+	Vout          int                   `json:"vout"`
+	PublicKeyHash string                `json:"publicKeyHash"`
+	Signature     string                `json:"signature"`
+	Verifier      string                `json:"verifier"`
+	Token         string                `json:"token,omitempty"`
+	Ring          *wallet.RingSignature `json:"ring,omitempty"`
 }
 
 func (ti transactionInput) toInput() transaction.Input {
@@ -69,12 +84,15 @@ func (ti transactionInput) toInput() transaction.Input {
 	publicKeyHash, _ := base64.StdEncoding.DecodeString(ti.PublicKeyHash)
 	signature, _ := base64.StdEncoding.DecodeString(ti.Signature)
 	verifier, _ := base64.StdEncoding.DecodeString(ti.Verifier)
+	token, _ := base64.StdEncoding.DecodeString(ti.Token)
 	return transaction.Input{
 		TransactionID: transactionID,
 		Vout:          ti.Vout,
 		PublicKeyHash: publicKeyHash,
 		Signature:     signature,
 		Verifier:      verifier,
+		Token:         token,
+		Ring:          ti.Ring,
 	}
 }
 
@@ -85,6 +103,8 @@ func newTransactionInput(input transaction.Input) transactionInput {
 		PublicKeyHash: base64.StdEncoding.EncodeToString(input.PublicKeyHash),
 		Signature:     base64.StdEncoding.EncodeToString(input.Signature),
 		Verifier:      base64.StdEncoding.EncodeToString(input.Verifier),
+		Token:         base64.StdEncoding.EncodeToString(input.Token),
+		Ring:          input.Ring,
 	}
 }
 
@@ -111,7 +131,13 @@ func newTransactionOutput(output transaction.Output) transactionOutput {
 func CastVote(db *bolt.DB) transaction.CastVote {
 	return func(from, to, signature, verifier []byte) (transaction.Transaction, error) {
 		var result transaction.Transaction
-		err := db.Update(func(tx *bolt.Tx) error {
+		err := update(db, func(tx *bolt.Tx) error {
+			switch revoked, err := isRevoked(tx, from); {
+			case err != nil:
+				return errors.Wrapf(err, "Failed to check revocation of %x", from)
+			case revoked:
+				return transaction.ErrVoterRevoked
+			}
 			utxos, err := getUTXOsByPublicKey(tx, from)
 			switch {
 			case err != nil:
@@ -171,12 +197,15 @@ func saveTransaction(tx *bolt.Tx, transaction transaction.Transaction) error {
 	if err := b.Put(transaction.ID, raw); err != nil {
 		return errors.Wrapf(err, "Failed to save transaction %s", transaction)
 	}
-	return nil
+	return markPending(tx, transaction)
 }
 
 func getInputSum(tx *bolt.Tx, tr transaction.Transaction) (int, error) {
 	sum := 0
 	for _, in := range tr.Inputs {
+		if in.Vout == -1 && (tr.IsAuthority() || tr.IsBase()) {
+			continue
+		}
 		utxo, err := getTransactionUTXO(tx, in.TransactionID, in.Vout)
 		switch {
 		case err != nil:
@@ -191,17 +220,18 @@ func getInputSum(tx *bolt.Tx, tr transaction.Transaction) (int, error) {
 
 func SaveTransaction(db *bolt.DB) transaction.SaveTransaction {
 	return func(tr transaction.Transaction) error {
-		return db.Update(func(tx *bolt.Tx) error {
+		return update(db, func(tx *bolt.Tx) error {
 			sum, err := getInputSum(tx, tr)
 			if err != nil {
 				return err
 			}
-			if sum != tr.Outputs.Sum() {
+			if !tr.IsAuthority() && sum != tr.Outputs.Sum() {
 				return errors.Errorf("Sums of inputs (%d) and outputs (%d) are not the same", sum, tr.Outputs.Sum())
 			}
 			if err := saveTransaction(tx, tr); err != nil {
 				return errors.Wrap(err, "Failed to save transaction")
 			}
+			log.Debug("Transaction saved", logger.TxID(tr.ID))
 			return nil
 		})
 	}
@@ -210,7 +240,7 @@ func SaveTransaction(db *bolt.DB) transaction.SaveTransaction {
 func GetTransactions(db *bolt.DB) transaction.GetTransactionsFn {
 	return func() (transaction.Transactions, error) {
 		var transactions transaction.Transactions
-		err := db.View(func(tx_ *bolt.Tx) error {
+		err := view(db, func(tx_ *bolt.Tx) error {
 			b := tx_.Bucket(transactionsBucket())
 			if b == nil {
 				return nil
@@ -239,7 +269,7 @@ func deleteTransaction(tx *bolt.Tx, transaction transaction.Transaction) error {
 	if err := b.Delete(transaction.ID); err != nil {
 		return errors.Wrapf(err, "Failed to delete transaction %s", transaction)
 	}
-	return nil
+	return releasePending(tx, transaction)
 }
 
 func deleteTransactions(tx *bolt.Tx, transactions transaction.Transactions) error {
@@ -250,3 +280,30 @@ func deleteTransactions(tx *bolt.Tx, transactions transaction.Transactions) erro
 	}
 	return nil
 }
+
+func isPending(tx_ *bolt.Tx, criteria func(transaction.Transaction) bool) (bool, error) {
+	b := tx_.Bucket(transactionsBucket())
+	if b == nil {
+		return false, nil
+	}
+	cursor := b.Cursor()
+	for key, value := cursor.First(); key != nil; key, value = cursor.Next() {
+		var t tx
+		if err := json.Unmarshal(value, &t); err != nil {
+			return false, errors.Wrapf(err, "Failed to unmarshal transaction %s", value)
+		}
+		if criteria(t.toTransaction()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isPendingSpend(tx_ *bolt.Tx, utxo transaction.UTXO) (bool, error) {
+	return isPending(tx_, func(t transaction.Transaction) bool {
+		_, found := t.Inputs.Find(func(in transaction.Input) bool {
+			return in.Vout == utxo.Vout && bytes.Compare(in.TransactionID, utxo.TransactionID) == 0
+		})
+		return found
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"encoding/json"
+
+	"github.com/boltdb/bolt"
+	"github.com/nebser/crypto-vote/internal/pkg/transaction"
+	"github.com/pkg/errors"
+)
+
+func upgradesBucket() []byte {
+	return []byte("upgrades")
+}
+
+func saveUpgradeData(tx *bolt.Tx, tr transaction.Transaction) error {
+	u, ok := tr.Upgrade()
+	if !ok {
+		return nil
+	}
+	raw, err := json.Marshal(u)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize upgrade at height %d", u.Height)
+	}
+	if err := putState(tx, upgradesBucket(), intKey(u.Height), raw); err != nil {
+		return errors.Wrapf(err, "Failed to save upgrade at height %d", u.Height)
+	}
+	return nil
+}
+
+func getUpgrades(tx *bolt.Tx) ([]transaction.Upgrade, error) {
+	upgrades := []transaction.Upgrade{}
+	b := tx.Bucket(upgradesBucket())
+	if b == nil {
+		return upgrades, nil
+	}
+	err := b.ForEach(func(key, raw []byte) error {
+		var u transaction.Upgrade
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return errors.Wrapf(err, "Failed to unmarshal upgrade %s", raw)
+		}
+		upgrades = append(upgrades, u)
+		return nil
+	})
+	return upgrades, err
+}
+
+func GetUpgrades(db *bolt.DB) transaction.GetUpgradesFn {
+	return func() ([]transaction.Upgrade, error) {
+		var result []transaction.Upgrade
+		err := view(db, func(tx *bolt.Tx) error {
+			upgrades, err := getUpgrades(tx)
+			result = upgrades
+			return err
+		})
+		return result, err
+	}
+}
+
+func ScheduleUpgrade(db *bolt.DB, newUpgradeTransaction transaction.NewUpgradeTransactionFn) transaction.ScheduleUpgradeFn {
+	return func(u transaction.Upgrade) (transaction.Transaction, error) {
+		var result transaction.Transaction
+		err := update(db, func(tx *bolt.Tx) error {
+			switch pending, err := isPending(tx, func(t transaction.Transaction) bool {
+				return t.Type == transaction.UpgradeTransaction
+			}); {
+			case err != nil:
+				return errors.Wrap(err, "Failed to check pending upgrades")
+			case pending:
+				return transaction.ErrUpgradePending
+			}
+			tr, err := newUpgradeTransaction(u)
+			if err != nil {
+				return errors.Wrap(err, "Failed to create upgrade transaction")
+			}
+			if err := saveTransaction(tx, *tr); err != nil {
+				return errors.Wrap(err, "Failed to save upgrade transaction")
+			}
+			result = *tr
+			return nil
+		})
+		return result, err
+	}
+}
@@ -93,20 +93,13 @@ func saveUTXOsByPublicKey(tx *bolt.Tx, utxos transaction.UTXOs) error {
 }
 
 func saveUTXOsByTransactionID(tx *bolt.Tx, utxos transaction.UTXOs) error {
-	b := tx.Bucket(utxoByTxBucket())
-	if b == nil {
-		created, err := tx.CreateBucket(utxoByTxBucket())
-		if err != nil {
-			return errors.Wrapf(err, "Failed to create bucket %s", utxoByTxBucket())
-		}
-		b = created
-	}
 	for _, u := range utxos {
 		var saved []utxo
-		raw := b.Get(u.TransactionID)
-		if raw != nil {
-			if err := json.Unmarshal(raw, &saved); err != nil {
-				return errors.Wrap(err, "Failed to unmarshal into utxo array")
+		if b := tx.Bucket(utxoByTxBucket()); b != nil {
+			if raw := b.Get(u.TransactionID); raw != nil {
+				if err := json.Unmarshal(raw, &saved); err != nil {
+					return errors.Wrap(err, "Failed to unmarshal into utxo array")
+				}
 			}
 		}
 		saved = append(saved, newUTXO(u))
@@ -114,7 +107,7 @@ func saveUTXOsByTransactionID(tx *bolt.Tx, utxos transaction.UTXOs) error {
 		if err != nil {
 			return errors.Wrapf(err, "Failed to serialize %#v", saved)
 		}
-		if err := b.Put(u.TransactionID, serialized); err != nil {
+		if err := putState(tx, utxoByTxBucket(), u.TransactionID, serialized); err != nil {
 			return errors.Wrapf(err, "Failed to save utxo set for tx id %x", u.TransactionID)
 		}
 	}
@@ -195,7 +188,7 @@ func deleteUTXOByPublicKey(tx *bolt.Tx, utxo transaction.UTXO) error {
 		return errors.Wrap(err, "Failed to retrieve utxo for deletion")
 	}
 	updated := utxos.Filter(func(u transaction.UTXO) bool {
-		return bytes.Compare(utxo.TransactionID, u.TransactionID) != 0
+		return u.Vout != utxo.Vout || bytes.Compare(utxo.TransactionID, u.TransactionID) != 0
 	})
 	raw, err := json.Marshal(newUTXOs(updated))
 	if err != nil {
@@ -208,8 +201,7 @@ func deleteUTXOByPublicKey(tx *bolt.Tx, utxo transaction.UTXO) error {
 }
 
 func deleteUTXOByTransactionID(tx *bolt.Tx, utxo transaction.UTXO) error {
-	b := tx.Bucket(utxoByPublicKeyBucket())
-	if b == nil {
+	if tx.Bucket(utxoByTxBucket()) == nil {
 		return nil
 	}
 	utxos, err := getUTXOByTransactionID(tx, utxo.TransactionID)
@@ -223,7 +215,7 @@ func deleteUTXOByTransactionID(tx *bolt.Tx, utxo transaction.UTXO) error {
 	if err != nil {
 		return errors.Wrapf(err, "Failed to marshal utxo %#v", utxos)
 	}
-	if err := b.Put(utxo.TransactionID, raw); err != nil {
+	if err := putState(tx, utxoByTxBucket(), utxo.TransactionID, raw); err != nil {
 		return errors.Wrapf(err, "Failed to store utxo %#v", utxos)
 	}
 	return nil
@@ -241,6 +233,9 @@ func deleteUTXO(tx *bolt.Tx, utxo transaction.UTXO) error {
 
 func deleteTransactionUTXOs(tx *bolt.Tx, transaction transaction.Transaction) error {
 	for _, input := range transaction.Inputs {
+		if input.Vout == -1 && (transaction.IsAuthority() || transaction.IsBase()) {
+			continue
+		}
 		utxo, err := getTransactionUTXO(tx, input.TransactionID, input.Vout)
 		if err != nil {
 			return err
@@ -252,6 +247,25 @@ func deleteTransactionUTXOs(tx *bolt.Tx, transaction transaction.Transaction) er
 	return nil
 }
 
+func spendTransactionUTXOs(tx *bolt.Tx, transaction transaction.Transaction) error {
+	for _, input := range transaction.Inputs {
+		if input.Vout < 0 {
+			continue
+		}
+		utxo, err := getTransactionUTXO(tx, input.TransactionID, input.Vout)
+		if err != nil {
+			return err
+		}
+		if utxo == nil {
+			continue
+		}
+		if err := deleteUTXO(tx, *utxo); err != nil {
+			return errors.Wrap(err, "Failed to delete spent utxo")
+		}
+	}
+	return nil
+}
+
 func deleteTransactionsUTXOs(tx *bolt.Tx, transactions transaction.Transactions) error {
 	for _, tr := range transactions {
 		if err := deleteTransactionUTXOs(tx, tr); err != nil {
@@ -264,7 +278,7 @@ func deleteTransactionsUTXOs(tx *bolt.Tx, transactions transaction.Transactions)
 func GetUTXOsByPublicKey(db *bolt.DB) transaction.GetUTXOsByPublicKeyFn {
 	return func(pkeyHash []byte) (transaction.UTXOs, error) {
 		var result transaction.UTXOs
-		err := db.View(func(tx *bolt.Tx) error {
+		err := view(db, func(tx *bolt.Tx) error {
 			utxos, err := getUTXOsByPublicKey(tx, pkeyHash)
 			if err != nil {
 				return err
@@ -276,10 +290,31 @@ func GetUTXOsByPublicKey(db *bolt.DB) transaction.GetUTXOsByPublicKeyFn {
 	}
 }
 
+func CountUTXOs(db *bolt.DB) transaction.CountUTXOsFn {
+	return func() (int, error) {
+		var result int
+		err := view(db, func(tx *bolt.Tx) error {
+			b := tx.Bucket(utxoByTxBucket())
+			if b == nil {
+				return nil
+			}
+			return b.ForEach(func(k, v []byte) error {
+				var saved utxos
+				if err := json.Unmarshal(v, &saved); err != nil {
+					return errors.Wrapf(err, "Failed to unmarshal utxos of transaction %x", k)
+				}
+				result += len(saved)
+				return nil
+			})
+		})
+		return result, err
+	}
+}
+
 func GetTransactionUTXO(db *bolt.DB) transaction.GetTransactionUTXO {
 	return func(id []byte, vout int) (*transaction.UTXO, error) {
 		var tr *transaction.UTXO
-		err := db.View(func(tx *bolt.Tx) error {
+		err := view(db, func(tx *bolt.Tx) error {
 			result, err := getTransactionUTXO(tx, id, vout)
 			if err != nil {
 				return err
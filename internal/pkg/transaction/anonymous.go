@@ -0,0 +1,88 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"time"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type NewTokenCommitmentFn func(voter []byte) ([]byte, error)
+
+type SignBlindedFn func(nonce, challenge []byte) ([]byte, error)
+
+type IssueBallotTokenFn func(voter, signature, verifier, challenge []byte) (Transaction, []byte, error)
+
+type CastAnonymousVoteFn func(to, signature, verifier, token []byte) (Transaction, error)
+
+type GetTokenSpenderFn func(oneTimeKeyHash []byte) ([]byte, error)
+
+const TokenSessionValidity = 5 * time.Minute
+
+var ErrNoTokenSession = errors.New("Token commitment was not requested")
+
+var ErrTokenSessionOpen = errors.New("Previous token commitment is still open")
+
+var ErrTokenSpent = errors.New("Ballot token is already used")
+
+var ErrBallotPoolEmpty = errors.New("There are no anonymous ballots available")
+
+func BallotPoolHash() []byte {
+	hashed := sha256.Sum256([]byte("crypto-vote anonymous ballot pool"))
+	return hashed[:20]
+}
+
+func SignBlinded(w wallet.Wallet) SignBlindedFn {
+	return func(nonce, challenge []byte) ([]byte, error) {
+		return wallet.SignBlinded(w.PrivateKey, nonce, challenge)
+	}
+}
+
+func VerifyBallotTokens(authorityPublicKey []byte, getTokenSpender GetTokenSpenderFn) VerifyTransctionFn {
+	pool := BallotPoolHash()
+	return func(transaction Transaction) bool {
+		if transaction.Type != AnonymousVoteTransaction {
+			_, found := transaction.Inputs.Find(func(in Input) bool {
+				return bytes.Compare(in.PublicKeyHash, pool) == 0
+			})
+			return !found
+		}
+		if len(transaction.Inputs) != 1 || len(transaction.Outputs) != 1 {
+			return false
+		}
+		input := transaction.Inputs[0]
+		if bytes.Compare(input.PublicKeyHash, pool) != 0 {
+			return false
+		}
+		if !wallet.VerifyBlindSignature(input.Verifier, input.Token, authorityPublicKey) {
+			return false
+		}
+		oneTimeKeyHash, err := wallet.HashedPublicKey(input.Verifier)
+		if err != nil {
+			return false
+		}
+		spender, err := getTokenSpender(oneTimeKeyHash)
+		if err != nil {
+			return false
+		}
+		return spender == nil || bytes.Compare(spender, transaction.ID) == 0
+	}
+}
+
+func (ts Transactions) UniqueTokens() bool {
+	used := map[string]bool{}
+	for _, t := range ts {
+		if t.Type != AnonymousVoteTransaction {
+			continue
+		}
+		for _, in := range t.Inputs {
+			if used[string(in.Verifier)] {
+				return false
+			}
+			used[string(in.Verifier)] = true
+		}
+	}
+	return true
+}
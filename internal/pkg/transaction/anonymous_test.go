@@ -0,0 +1,27 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+)
+
+func TestUniqueTokensRejectsRepeatedToken(t *testing.T) {
+	oneTimeKey, err := wallet.New()
+	if err != nil {
+		t.Fatalf("Failed to create one-time key: %s", err)
+	}
+	vote := func(id byte) Transaction {
+		return Transaction{
+			ID:     []byte{id},
+			Type:   AnonymousVoteTransaction,
+			Inputs: Inputs{{Verifier: oneTimeKey.PublicKey}},
+		}
+	}
+	if !(Transactions{vote(1)}).UniqueTokens() {
+		t.Fatal("Single token is rejected")
+	}
+	if (Transactions{vote(1), vote(2)}).UniqueTokens() {
+		t.Fatal("Repeated token is accepted")
+	}
+}
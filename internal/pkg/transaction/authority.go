@@ -0,0 +1,79 @@
+package transaction
+
+import (
+	"bytes"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func newAuthorityInputs(authority wallet.Wallet, s signable) (Inputs, error) {
+	signature, err := wallet.Sign(s, authority.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign authority transaction")
+	}
+	return Inputs{
+		{
+			Vout:          -1,
+			PublicKeyHash: authority.PublicKeyHash(),
+			Signature:     signature,
+			Verifier:      authority.PublicKey,
+		},
+	}, nil
+}
+
+func (t Transaction) IsAuthority() bool {
+	switch t.Type {
+	case PartyTransaction, VoterRegistrationTransaction, SlashingTransaction, UpgradeTransaction:
+		return t.IsBase()
+	default:
+		return false
+	}
+}
+
+func (t Transaction) authoritySignable() signable {
+	s := signable{
+		Sender:  t.Inputs[0].PublicKeyHash,
+		Payload: PayloadHash(t.Payload),
+	}
+	if len(t.Outputs) == 1 {
+		s.Recipient = t.Outputs[0].PublicKeyHash
+		s.Value = t.Outputs[0].Value
+	}
+	return s
+}
+
+func VerifyAuthorityTransactions(alfaKeyHash []byte) VerifyTransctionFn {
+	return func(transaction Transaction) bool {
+		switch transaction.Type {
+		case PartyTransaction, VoterRegistrationTransaction, SlashingTransaction, UpgradeTransaction:
+		default:
+			return true
+		}
+		if !transaction.IsAuthority() || len(transaction.Inputs) != 1 {
+			return false
+		}
+		input := transaction.Inputs[0]
+		verifierHash, err := wallet.HashedPublicKey(input.Verifier)
+		if err != nil || bytes.Compare(verifierHash, alfaKeyHash) != 0 || bytes.Compare(input.PublicKeyHash, alfaKeyHash) != 0 {
+			return false
+		}
+		if !wallet.Verify(transaction.authoritySignable(), input.Signature, input.Verifier) {
+			return false
+		}
+		switch transaction.Type {
+		case PartyTransaction:
+			_, ok := transaction.Party()
+			return ok
+		case SlashingTransaction:
+			_, ok := transaction.Slashing()
+			return ok
+		case UpgradeTransaction:
+			_, ok := transaction.Upgrade()
+			return ok
+		default:
+			voter, ok := transaction.RegisteredVoter()
+			return ok && bytes.Compare(voter, alfaKeyHash) != 0
+		}
+	}
+}
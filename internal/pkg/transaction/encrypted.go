@@ -0,0 +1,135 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/elgamal"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type CastEncryptedVoteFn func(from, signature, verifier, ballot []byte) (Transaction, error)
+
+func BallotBoxHash() []byte {
+	hashed := sha256.Sum256([]byte("crypto-vote encrypted ballot box"))
+	return hashed[:20]
+}
+
+func PayloadHash(payload []byte) []byte {
+	if len(payload) == 0 {
+		return nil
+	}
+	hashed := sha256.Sum256(payload)
+	return hashed[:]
+}
+
+func NewElectionSetupTransaction(creator wallet.Wallet, key elgamal.ElectionKey) (*Transaction, error) {
+	payload, err := json.Marshal(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to serialize election key")
+	}
+	signable := signable{
+		Sender:  creator.PublicKeyHash(),
+		Payload: PayloadHash(payload),
+	}
+	signature, err := wallet.Sign(signable, creator.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign election setup transaction")
+	}
+	inputs := Inputs{
+		{
+			Vout:          -1,
+			PublicKeyHash: creator.PublicKeyHash(),
+			Signature:     signature,
+			Verifier:      creator.PublicKey,
+		},
+	}
+	id, err := newID(ElectionSetupTransaction, inputs, Outputs{}, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create transaction id")
+	}
+	return &Transaction{
+		ID:      id,
+		Type:    ElectionSetupTransaction,
+		Inputs:  inputs,
+		Outputs: Outputs{},
+		Payload: payload,
+	}, nil
+}
+
+func (t Transaction) ElectionKey() (*elgamal.ElectionKey, bool) {
+	if t.Type != ElectionSetupTransaction {
+		return nil, false
+	}
+	var key elgamal.ElectionKey
+	if err := json.Unmarshal(t.Payload, &key); err != nil {
+		return nil, false
+	}
+	return &key, true
+}
+
+func (t Transaction) ElectionSchedule() (*election.Schedule, bool) {
+	if t.Type != ElectionScheduleTransaction {
+		return nil, false
+	}
+	var schedule election.Schedule
+	if err := json.Unmarshal(t.Payload, &schedule); err != nil {
+		return nil, false
+	}
+	return &schedule, true
+}
+
+func (t Transaction) InElectionWindow(closed bool) bool {
+	return t.Type != EncryptedVoteTransaction || !closed
+}
+
+func (t Transaction) EncryptedBallot() (*elgamal.EncryptedBallot, bool) {
+	if t.Type != EncryptedVoteTransaction {
+		return nil, false
+	}
+	ballot, err := elgamal.ParseBallot(t.Payload)
+	if err != nil {
+		return nil, false
+	}
+	return ballot, true
+}
+
+func VerifyEncryptedBallots(getElectionKey election.GetElectionKeyFn) VerifyTransctionFn {
+	box := BallotBoxHash()
+	return func(transaction Transaction) bool {
+		if _, found := transaction.Inputs.Find(func(in Input) bool {
+			return bytes.Compare(in.PublicKeyHash, box) == 0
+		}); found {
+			return false
+		}
+		switch transaction.Type {
+		case ElectionSetupTransaction, ElectionScheduleTransaction:
+			return false
+		case EncryptedVoteTransaction:
+		default:
+			_, found := transaction.Outputs.Find(func(o Output) bool {
+				return bytes.Compare(o.PublicKeyHash, box) == 0
+			})
+			return !found && (len(transaction.Payload) == 0 || transaction.Type == RecastVoteTransaction || transaction.Type == PartyTransaction || transaction.Type == SlashingTransaction || transaction.Type == UpgradeTransaction)
+		}
+		if len(transaction.Inputs) != 1 || len(transaction.Outputs) == 0 || len(transaction.Outputs) > 2 {
+			return false
+		}
+		vote := transaction.Outputs[0]
+		if bytes.Compare(vote.PublicKeyHash, box) != 0 || vote.Value != VoteValue {
+			return false
+		}
+		if len(transaction.Outputs) == 2 && bytes.Compare(transaction.Outputs[1].PublicKeyHash, transaction.Inputs[0].PublicKeyHash) != 0 {
+			return false
+		}
+		key, err := getElectionKey()
+		if err != nil || key == nil {
+			return false
+		}
+		ballot, ok := transaction.EncryptedBallot()
+		return ok && ballot.Verify(*key, transaction.Inputs[0].PublicKeyHash)
+	}
+}
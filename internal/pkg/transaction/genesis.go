@@ -0,0 +1,26 @@
+package transaction
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+func NewGenesisTransaction(txType Type, authority []byte, outputs Outputs, payload []byte, timestamp int64) (*Transaction, error) {
+	authorityHash, err := wallet.HashedPublicKey(authority)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to hash authority public key")
+	}
+	inputs := Inputs{
+		{
+			Vout:          -1,
+			PublicKeyHash: authorityHash,
+			Verifier:      authority,
+		},
+	}
+	t, err := NewPayloadTransaction(txType, inputs, outputs, payload)
+	if err != nil {
+		return nil, err
+	}
+	t.Timestamp = timestamp
+	return t, nil
+}
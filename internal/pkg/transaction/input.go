@@ -1,11 +1,15 @@
 package transaction
 
+import "github.com/nebser/crypto-vote/internal/pkg/wallet"
+
 type Input struct {
 	TransactionID []byte
 	Vout          int
 	PublicKeyHash []byte
 	Verifier      []byte
 	Signature     []byte
+	Token         []byte                `json:",omitempty"`
+	Ring          *wallet.RingSignature `json:",omitempty"`
 }
 
 type Inputs []Input
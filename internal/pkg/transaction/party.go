@@ -0,0 +1,69 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/party"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type partyRecord struct {
+	Address  string            `json:"address"`
+	Name     string            `json:"name"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Active   bool              `json:"active"`
+}
+
+type NewPartyTransactionFn func(party.Party) (*Transaction, error)
+
+type UpdatePartyFn func(address string, change party.ChangeFn) (Transaction, error)
+
+func PartyPayload(p party.Party) ([]byte, error) {
+	payload, err := json.Marshal(partyRecord{
+		Address:  p.Address,
+		Name:     p.Name,
+		Metadata: p.Metadata,
+		Active:   p.Active,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to serialize party %#v", p)
+	}
+	return payload, nil
+}
+
+func NewPartyTransaction(authority wallet.Wallet) NewPartyTransactionFn {
+	return func(p party.Party) (*Transaction, error) {
+		payload, err := PartyPayload(p)
+		if err != nil {
+			return nil, err
+		}
+		inputs, err := newAuthorityInputs(authority, signable{
+			Sender:  authority.PublicKeyHash(),
+			Payload: PayloadHash(payload),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewPayloadTransaction(PartyTransaction, inputs, Outputs{}, payload)
+	}
+}
+
+func (t Transaction) Party() (*party.Party, bool) {
+	if t.Type != PartyTransaction || len(t.Outputs) != 0 {
+		return nil, false
+	}
+	var record partyRecord
+	if err := json.Unmarshal(t.Payload, &record); err != nil || record.Name == "" {
+		return nil, false
+	}
+	if _, err := wallet.ParseAddress(record.Address); err != nil {
+		return nil, false
+	}
+	return &party.Party{
+		Address:  record.Address,
+		Name:     record.Name,
+		Metadata: record.Metadata,
+		Active:   record.Active,
+	}, true
+}
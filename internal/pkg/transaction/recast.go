@@ -0,0 +1,223 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/election"
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type Recast struct {
+	Voter    []byte `json:"voter"`
+	Party    []byte `json:"party"`
+	Sequence int    `json:"sequence"`
+}
+
+type GetRecastScheduleFn func() (*election.Schedule, error)
+
+type GetRecastFn func(transactionID []byte) (*Recast, error)
+
+type GetLatestRecastFn func(voter []byte) ([]byte, error)
+
+type NextRecastSequenceFn func(voter []byte) (int, error)
+
+type CastRecastVoteFn func(recast Recast, signature, verifier []byte) (Transaction, error)
+
+type NewRecastReleaseTransactionFn func(escrow UTXO, party []byte) (*Transaction, error)
+
+type ReleaseRecastsFn func() (Transactions, error)
+
+var ErrRecastPending = errors.New("Previous vote is not included in a block yet")
+
+var ErrInvalidRecastSequence = errors.New("Recast sequence does not follow the previous vote")
+
+func RecastEscrowHash() []byte {
+	hashed := sha256.Sum256([]byte("crypto-vote recast escrow"))
+	return hashed[:20]
+}
+
+func (r Recast) Payload() ([]byte, error) {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to serialize recast %#v", r)
+	}
+	return payload, nil
+}
+
+func NewRecastSetupTransaction(creator wallet.Wallet, schedule election.Schedule) (*Transaction, error) {
+	payload, err := json.Marshal(schedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to serialize recast schedule")
+	}
+	signable := signable{
+		Sender:  creator.PublicKeyHash(),
+		Payload: PayloadHash(payload),
+	}
+	signature, err := wallet.Sign(signable, creator.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign recast setup transaction")
+	}
+	inputs := Inputs{
+		{
+			Vout:          -1,
+			PublicKeyHash: creator.PublicKeyHash(),
+			Signature:     signature,
+			Verifier:      creator.PublicKey,
+		},
+	}
+	id, err := newID(RecastSetupTransaction, inputs, Outputs{}, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create transaction id")
+	}
+	return &Transaction{
+		ID:      id,
+		Type:    RecastSetupTransaction,
+		Inputs:  inputs,
+		Outputs: Outputs{},
+		Payload: payload,
+	}, nil
+}
+
+func NewRecastReleaseTransaction(w wallet.Wallet) NewRecastReleaseTransactionFn {
+	return func(escrow UTXO, party []byte) (*Transaction, error) {
+		signable := signable{
+			Sender:    escrow.PublicKeyHash,
+			Recipient: party,
+			Value:     escrow.Value,
+		}
+		signature, err := wallet.Sign(signable, w.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to sign recast release transaction")
+		}
+		inputs := Inputs{
+			{
+				PublicKeyHash: escrow.PublicKeyHash,
+				Signature:     signature,
+				TransactionID: escrow.TransactionID,
+				Vout:          escrow.Vout,
+				Verifier:      w.PublicKey,
+			},
+		}
+		outputs := Outputs{
+			{
+				PublicKeyHash: party,
+				Value:         escrow.Value,
+			},
+		}
+		return NewTypedTransaction(RecastReleaseTransaction, inputs, outputs)
+	}
+}
+
+func (t Transaction) RecastSchedule() (*election.Schedule, bool) {
+	if t.Type != RecastSetupTransaction {
+		return nil, false
+	}
+	var schedule election.Schedule
+	if err := json.Unmarshal(t.Payload, &schedule); err != nil {
+		return nil, false
+	}
+	return &schedule, true
+}
+
+func (t Transaction) Recast() (*Recast, bool) {
+	if t.Type != RecastVoteTransaction {
+		return nil, false
+	}
+	var recast Recast
+	if err := json.Unmarshal(t.Payload, &recast); err != nil {
+		return nil, false
+	}
+	return &recast, true
+}
+
+func (t Transaction) InRecastWindow(closed bool) bool {
+	switch t.Type {
+	case RecastVoteTransaction:
+		return !closed
+	case RecastReleaseTransaction:
+		return closed
+	default:
+		return true
+	}
+}
+
+func VerifyRecasts(
+	getSchedule GetRecastScheduleFn,
+	getTransactionUTXO GetTransactionUTXO,
+	getRecast GetRecastFn,
+	getLatestRecast GetLatestRecastFn,
+	alfaKeyHash []byte,
+) VerifyTransctionFn {
+	escrow := RecastEscrowHash()
+	return func(transaction Transaction) bool {
+		switch transaction.Type {
+		case RecastSetupTransaction:
+			return false
+		case RecastVoteTransaction, RecastReleaseTransaction:
+		default:
+			_, spends := transaction.Inputs.Find(func(in Input) bool {
+				return bytes.Compare(in.PublicKeyHash, escrow) == 0
+			})
+			_, locks := transaction.Outputs.Find(func(o Output) bool {
+				return bytes.Compare(o.PublicKeyHash, escrow) == 0
+			})
+			return !spends && !locks
+		}
+		schedule, err := getSchedule()
+		if err != nil || schedule == nil || len(transaction.Inputs) != 1 {
+			return false
+		}
+		input := transaction.Inputs[0]
+		if transaction.Type == RecastReleaseTransaction {
+			if len(transaction.Outputs) != 1 || bytes.Compare(input.PublicKeyHash, escrow) != 0 {
+				return false
+			}
+			if signer, err := wallet.HashedPublicKey(input.Verifier); err != nil || bytes.Compare(signer, alfaKeyHash) != 0 {
+				return false
+			}
+			recast, err := getRecast(input.TransactionID)
+			if err != nil || recast == nil || input.Vout != 0 {
+				return false
+			}
+			latest, err := getLatestRecast(recast.Voter)
+			if err != nil || bytes.Compare(latest, input.TransactionID) != 0 {
+				return false
+			}
+			release := transaction.Outputs[0]
+			return bytes.Compare(release.PublicKeyHash, recast.Party) == 0 && release.Value == VoteValue
+		}
+		recast, ok := transaction.Recast()
+		if !ok || len(transaction.Outputs) == 0 || len(transaction.Outputs) > 2 {
+			return false
+		}
+		if bytes.Compare(input.PublicKeyHash, recast.Voter) != 0 {
+			return false
+		}
+		if signer, err := wallet.HashedPublicKey(input.Verifier); err != nil || bytes.Compare(signer, recast.Voter) != 0 {
+			return false
+		}
+		vote := transaction.Outputs[0]
+		if bytes.Compare(vote.PublicKeyHash, escrow) != 0 || vote.Value != VoteValue {
+			return false
+		}
+		if len(transaction.Outputs) == 2 && bytes.Compare(transaction.Outputs[1].PublicKeyHash, recast.Voter) != 0 {
+			return false
+		}
+		latest, err := getLatestRecast(recast.Voter)
+		if err != nil {
+			return false
+		}
+		if latest == nil {
+			utxo, err := getTransactionUTXO(input.TransactionID, input.Vout)
+			return err == nil && utxo != nil && recast.Sequence == 0 && bytes.Compare(utxo.PublicKeyHash, recast.Voter) == 0
+		}
+		previous, err := getRecast(latest)
+		if err != nil || previous == nil {
+			return false
+		}
+		return bytes.Compare(input.TransactionID, latest) == 0 && input.Vout == 0 && recast.Sequence == previous.Sequence+1
+	}
+}
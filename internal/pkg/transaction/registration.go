@@ -0,0 +1,39 @@
+package transaction
+
+import (
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type NewVoterRegistrationTransactionFn func(voter []byte) (*Transaction, error)
+
+type RegisterVoterFn func(voter []byte) (Transaction, error)
+
+var ErrVoterRegistered = errors.New("Voter is already registered")
+
+func NewVoterRegistrationTransaction(authority wallet.Wallet) NewVoterRegistrationTransactionFn {
+	return func(voter []byte) (*Transaction, error) {
+		inputs, err := newAuthorityInputs(authority, signable{
+			Sender:    authority.PublicKeyHash(),
+			Recipient: voter,
+			Value:     VoteValue,
+		})
+		if err != nil {
+			return nil, err
+		}
+		outputs := Outputs{
+			{
+				Value:         VoteValue,
+				PublicKeyHash: voter,
+			},
+		}
+		return NewTypedTransaction(VoterRegistrationTransaction, inputs, outputs)
+	}
+}
+
+func (t Transaction) RegisteredVoter() ([]byte, bool) {
+	if t.Type != VoterRegistrationTransaction || len(t.Outputs) != 1 || t.Outputs[0].Value != VoteValue {
+		return nil, false
+	}
+	return t.Outputs[0].PublicKeyHash, true
+}
@@ -0,0 +1,94 @@
+package transaction
+
+import (
+	"bytes"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type Revocation struct {
+	Revoked       []byte `json:"revoked"`
+	Replacement   []byte `json:"replacement"`
+	TransactionID []byte `json:"transactionId"`
+	Timestamp     int64  `json:"timestamp"`
+}
+
+type Revocations []Revocation
+
+type RevokeFn func(revoked, replacement []byte) (Transaction, error)
+
+type NewRevocationTransactionFn func(ballot UTXO, replacement []byte) (*Transaction, error)
+
+type IsRevokedFn func(publicKeyHash []byte) (bool, error)
+
+type GetRevocationFn func(publicKeyHash []byte) (*Revocation, error)
+
+type GetRevocationsFn func() (Revocations, error)
+
+var ErrVoterRevoked = errors.New("Voter key has been revoked")
+
+var ErrBallotSpent = errors.New("Ballot of the revoked voter is already spent")
+
+var ErrInvalidReplacement = errors.New("Replacement address already owns a ballot")
+
+var ErrAmbiguousBallot = errors.New("Revoked voter owns more than one ballot")
+
+func (t Transaction) Revocation() (Revocation, bool) {
+	if t.Type != RevocationTransaction || len(t.Inputs) != 1 || len(t.Outputs) != 1 {
+		return Revocation{}, false
+	}
+	return Revocation{
+		Revoked:       t.Inputs[0].PublicKeyHash,
+		Replacement:   t.Outputs[0].PublicKeyHash,
+		TransactionID: t.ID,
+		Timestamp:     t.Timestamp,
+	}, true
+}
+
+func NewRevocationTransaction(authority wallet.Wallet) NewRevocationTransactionFn {
+	return func(ballot UTXO, replacement []byte) (*Transaction, error) {
+		signable := signable{
+			Recipient: replacement,
+			Sender:    ballot.PublicKeyHash,
+			Value:     ballot.Value,
+		}
+		signature, err := wallet.Sign(signable, authority.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to sign revocation transaction")
+		}
+		inputs := Inputs{
+			{
+				PublicKeyHash: ballot.PublicKeyHash,
+				Signature:     signature,
+				TransactionID: ballot.TransactionID,
+				Verifier:      authority.PublicKey,
+				Vout:          ballot.Vout,
+			},
+		}
+		outputs := Outputs{
+			{
+				Value:         ballot.Value,
+				PublicKeyHash: replacement,
+			},
+		}
+		return NewTypedTransaction(RevocationTransaction, inputs, outputs)
+	}
+}
+
+func VerifyRevocation(alfaKeyHash []byte) VerifyTransctionFn {
+	return func(transaction Transaction) bool {
+		if transaction.Type != RevocationTransaction {
+			return true
+		}
+		revocation, ok := transaction.Revocation()
+		if !ok || bytes.Compare(revocation.Revoked, revocation.Replacement) == 0 {
+			return false
+		}
+		verifierHash, err := wallet.HashedPublicKey(transaction.Inputs[0].Verifier)
+		if err != nil {
+			return false
+		}
+		return bytes.Compare(verifierHash, alfaKeyHash) == 0
+	}
+}
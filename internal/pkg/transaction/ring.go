@@ -0,0 +1,118 @@
+package transaction
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type GetRingFn func() ([][]byte, error)
+
+type GetKeyImageSpenderFn func(keyImage []byte) ([]byte, error)
+
+type CastRingVoteFn func(to []byte, signature wallet.RingSignature) (Transaction, error)
+
+var ErrKeyImageUsed = errors.New("Key image is already used")
+
+func RingPoolHash() []byte {
+	hashed := sha256.Sum256([]byte("crypto-vote ring ballot pool"))
+	return hashed[:20]
+}
+
+func RingVoteMessage(recipient []byte) ([]byte, error) {
+	return signable{
+		Sender:    RingPoolHash(),
+		Recipient: recipient,
+		Value:     VoteValue,
+	}.Signable()
+}
+
+func NewEligibleVotersTransaction(creator wallet.Wallet, publicKeys [][]byte) (*Transaction, error) {
+	payload, err := json.Marshal(publicKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to serialize eligible voters")
+	}
+	signable := signable{
+		Sender:  creator.PublicKeyHash(),
+		Payload: PayloadHash(payload),
+	}
+	signature, err := wallet.Sign(signable, creator.PrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to sign eligible voters transaction")
+	}
+	inputs := Inputs{
+		{
+			Vout:          -1,
+			PublicKeyHash: creator.PublicKeyHash(),
+			Signature:     signature,
+			Verifier:      creator.PublicKey,
+		},
+	}
+	id, err := newID(EligibleVotersTransaction, inputs, Outputs{}, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create transaction id")
+	}
+	return &Transaction{
+		ID:      id,
+		Type:    EligibleVotersTransaction,
+		Inputs:  inputs,
+		Outputs: Outputs{},
+		Payload: payload,
+	}, nil
+}
+
+func (t Transaction) EligibleVoters() ([][]byte, bool) {
+	if t.Type != EligibleVotersTransaction {
+		return nil, false
+	}
+	var publicKeys [][]byte
+	if err := json.Unmarshal(t.Payload, &publicKeys); err != nil {
+		return nil, false
+	}
+	return publicKeys, true
+}
+
+func (t Transaction) KeyImage() ([]byte, bool) {
+	if t.Type != RingVoteTransaction || len(t.Inputs) != 1 || t.Inputs[0].Ring == nil {
+		return nil, false
+	}
+	return t.Inputs[0].Ring.KeyImage, true
+}
+
+func VerifyRingVotes(getRing GetRingFn, getKeyImageSpender GetKeyImageSpenderFn) VerifyTransctionFn {
+	pool := RingPoolHash()
+	return func(transaction Transaction) bool {
+		if transaction.Type == EligibleVotersTransaction {
+			return false
+		}
+		if transaction.Type != RingVoteTransaction {
+			_, found := transaction.Inputs.Find(func(in Input) bool {
+				return bytes.Compare(in.PublicKeyHash, pool) == 0 || in.Ring != nil
+			})
+			return !found
+		}
+		if len(transaction.Inputs) != 1 || len(transaction.Outputs) != 1 || transaction.Outputs[0].Value != VoteValue {
+			return false
+		}
+		input := transaction.Inputs[0]
+		if input.Ring == nil || bytes.Compare(input.PublicKeyHash, pool) != 0 {
+			return false
+		}
+		ring, err := getRing()
+		if err != nil || len(ring) == 0 {
+			return false
+		}
+		message, err := RingVoteMessage(transaction.Outputs[0].PublicKeyHash)
+		if err != nil || !wallet.VerifyRing(message, ring, *input.Ring) {
+			return false
+		}
+		spender, err := getKeyImageSpender(input.Ring.KeyImage)
+		if err != nil {
+			return false
+		}
+		return spender == nil || bytes.Compare(spender, transaction.ID) == 0
+	}
+}
@@ -0,0 +1,72 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+)
+
+func newTestRingVote(t *testing.T, ring [][]byte, signer wallet.Wallet, to []byte) Transaction {
+	message, err := RingVoteMessage(to)
+	if err != nil {
+		t.Fatalf("Failed to create ring vote message: %s", err)
+	}
+	signature, err := wallet.SignRing(message, ring, signer.PrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign ring vote: %s", err)
+	}
+	tr, err := NewTypedTransaction(RingVoteTransaction, Inputs{{PublicKeyHash: RingPoolHash(), Ring: signature}}, Outputs{{PublicKeyHash: to, Value: VoteValue}})
+	if err != nil {
+		t.Fatalf("Failed to create ring vote: %s", err)
+	}
+	return *tr
+}
+
+func TestVerifyRingVotes(t *testing.T) {
+	voters := wallet.Wallets{}
+	ring := [][]byte{}
+	for i := 0; i < 3; i++ {
+		w, err := wallet.New()
+		if err != nil {
+			t.Fatalf("Failed to create voter: %s", err)
+		}
+		voters = append(voters, *w)
+		ring = append(ring, wallet.RingMember(w.PrivateKey.PublicKey))
+	}
+	spenders := map[string][]byte{}
+	verify := VerifyRingVotes(
+		func() ([][]byte, error) { return ring, nil },
+		func(keyImage []byte) ([]byte, error) { return spenders[string(keyImage)], nil },
+	)
+	party := []byte("party")
+	vote := newTestRingVote(t, ring, voters[0], party)
+	if !verify(vote) {
+		t.Fatal("Valid ring vote is rejected")
+	}
+
+	redirected := newTestRingVote(t, ring, voters[0], party)
+	redirected.Outputs[0].PublicKeyHash = []byte("another party")
+	if verify(redirected) {
+		t.Fatal("Ring vote with a tampered recipient is accepted")
+	}
+
+	spenders[string(vote.Inputs[0].Ring.KeyImage)] = vote.ID
+	if !verify(vote) {
+		t.Fatal("Ring vote is rejected after its own key image is recorded")
+	}
+	if verify(newTestRingVote(t, ring, voters[0], []byte("another party"))) {
+		t.Fatal("Ring vote with a reused key image is accepted")
+	}
+	if !verify(newTestRingVote(t, ring, voters[1], party)) {
+		t.Fatal("Ring vote of another voter is rejected")
+	}
+
+	outsider, err := wallet.New()
+	if err != nil {
+		t.Fatalf("Failed to create outsider: %s", err)
+	}
+	forged := [][]byte{ring[0], ring[1], wallet.RingMember(outsider.PrivateKey.PublicKey)}
+	if verify(newTestRingVote(t, forged, voters[1], party)) {
+		t.Fatal("Ring vote signed over another ring is accepted")
+	}
+}
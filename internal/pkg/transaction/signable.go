@@ -8,6 +8,7 @@ type signable struct {
 	Sender    []byte `json:"sender"`
 	Recipient []byte `json:"recipient"`
 	Value     int    `json:"value"`
+	Payload   []byte `json:"payload,omitempty"`
 }
 
 func (s signable) Signable() ([]byte, error) {
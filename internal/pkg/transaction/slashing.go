@@ -0,0 +1,92 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type Offence string
+
+const (
+	InvalidBlockOffence Offence = "invalid-block"
+	EquivocationOffence Offence = "equivocation"
+)
+
+type Slashing struct {
+	Forger             []byte          `json:"forger"`
+	Offence            Offence         `json:"offence"`
+	Height             int             `json:"height"`
+	BlockHash          []byte          `json:"blockHash"`
+	StakeTransactionID []byte          `json:"stakeTransactionId"`
+	Reason             string          `json:"reason"`
+	Evidence           json.RawMessage `json:"evidence"`
+	TransactionID      []byte          `json:"transactionId,omitempty"`
+	Timestamp          int64           `json:"timestamp,omitempty"`
+}
+
+type Slashings []Slashing
+
+type NewSlashingTransactionFn func(Slashing) (*Transaction, error)
+
+type SlashFn func(Slashing) (Transaction, error)
+
+type IsSlashedFn func(publicKeyHash []byte) (bool, error)
+
+type GetSlashingFn func(publicKeyHash []byte) (*Slashing, error)
+
+type GetSlashingsFn func() (Slashings, error)
+
+type VerifyEvidenceFn func(Slashing) bool
+
+var ErrForgerSlashed = errors.New("Forger has already been slashed")
+
+func (s Slashing) payload() ([]byte, error) {
+	s.TransactionID = nil
+	s.Timestamp = 0
+	return json.Marshal(s)
+}
+
+func NewSlashingTransaction(authority wallet.Wallet) NewSlashingTransactionFn {
+	return func(s Slashing) (*Transaction, error) {
+		payload, err := s.payload()
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to serialize slashing of %x", s.Forger)
+		}
+		inputs, err := newAuthorityInputs(authority, signable{
+			Sender:  authority.PublicKeyHash(),
+			Payload: PayloadHash(payload),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewPayloadTransaction(SlashingTransaction, inputs, Outputs{}, payload)
+	}
+}
+
+func (t Transaction) Slashing() (Slashing, bool) {
+	if t.Type != SlashingTransaction || len(t.Outputs) != 0 {
+		return Slashing{}, false
+	}
+	var s Slashing
+	if err := json.Unmarshal(t.Payload, &s); err != nil || len(s.Forger) == 0 || len(s.BlockHash) == 0 || len(s.Evidence) == 0 {
+		return Slashing{}, false
+	}
+	if s.Offence != InvalidBlockOffence && s.Offence != EquivocationOffence {
+		return Slashing{}, false
+	}
+	s.TransactionID = t.ID
+	s.Timestamp = t.Timestamp
+	return s, true
+}
+
+func VerifySlashings(verifyEvidence VerifyEvidenceFn) VerifyTransctionFn {
+	return func(transaction Transaction) bool {
+		if transaction.Type != SlashingTransaction {
+			return true
+		}
+		s, ok := transaction.Slashing()
+		return ok && verifyEvidence(s)
+	}
+}
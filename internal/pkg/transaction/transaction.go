@@ -6,7 +6,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
@@ -34,10 +33,75 @@ type NewReturnStakeTransactionFn func(Transaction) (*Transaction, error)
 
 const VoteValue = 10
 
+type Type int
+
+const (
+	RegularTransaction Type = iota
+	RevocationTransaction
+	BallotTokenTransaction
+	AnonymousVoteTransaction
+	ElectionSetupTransaction
+	EncryptedVoteTransaction
+	EligibleVotersTransaction
+	RingVoteTransaction
+	RecastSetupTransaction
+	RecastVoteTransaction
+	RecastReleaseTransaction
+	PartyTransaction
+	VoterRegistrationTransaction
+	SlashingTransaction
+	ChainParametersTransaction
+	UpgradeTransaction
+	ElectionScheduleTransaction
+)
+
+func (t Type) String() string {
+	switch t {
+	case RegularTransaction:
+		return "regular"
+	case RevocationTransaction:
+		return "revocation"
+	case BallotTokenTransaction:
+		return "ballot-token"
+	case AnonymousVoteTransaction:
+		return "anonymous-vote"
+	case ElectionSetupTransaction:
+		return "election-setup"
+	case EncryptedVoteTransaction:
+		return "encrypted-vote"
+	case EligibleVotersTransaction:
+		return "eligible-voters"
+	case RingVoteTransaction:
+		return "ring-vote"
+	case RecastSetupTransaction:
+		return "recast-setup"
+	case RecastVoteTransaction:
+		return "recast-vote"
+	case RecastReleaseTransaction:
+		return "recast-release"
+	case PartyTransaction:
+		return "party"
+	case VoterRegistrationTransaction:
+		return "voter-registration"
+	case SlashingTransaction:
+		return "slashing"
+	case ChainParametersTransaction:
+		return "chain-parameters"
+	case UpgradeTransaction:
+		return "upgrade"
+	case ElectionScheduleTransaction:
+		return "election-schedule"
+	default:
+		return fmt.Sprintf("Unknown transaction type %d", t)
+	}
+}
+
 type Transaction struct {
 	ID        []byte  `json:"id"`
+	Type      Type    `json:"type,omitempty"`
 	Inputs    Inputs  `json:"inputs"`
 	Outputs   Outputs `json:"outputs"`
+	Payload   []byte  `json:"payload,omitempty"`
 	Timestamp int64   `json:"timestamp"`
 }
 
@@ -46,6 +110,9 @@ var ErrInsufficientVotes = errors.New("Not enough votes available")
 func (tx Transaction) String() string {
 	builder := strings.Builder{}
 	builder.WriteString(fmt.Sprintf("ID: %x\n", tx.ID))
+	if tx.Type != RegularTransaction {
+		builder.WriteString(fmt.Sprintf("Type: %s\n", tx.Type))
+	}
 	builder.WriteString("Inputs:\n")
 	for _, in := range tx.Inputs {
 		builder.WriteString(fmt.Sprintf("\tFrom: %x\n", in.PublicKeyHash))
@@ -60,15 +127,19 @@ func (tx Transaction) String() string {
 }
 
 type hashable struct {
+	Type      Type    `json:"type,omitempty"`
 	Inputs    Inputs  `json:"inputs"`
 	Outputs   Outputs `json:"outputs"`
+	Payload   []byte  `json:"payload,omitempty"`
 	Timestamp int64   `json:"timestamp"`
 }
 
-func newID(inputs Inputs, outputs Outputs) ([]byte, error) {
+func newID(txType Type, inputs Inputs, outputs Outputs, payload []byte) ([]byte, error) {
 	hashable := hashable{
+		Type:    txType,
 		Inputs:  inputs,
 		Outputs: outputs,
+		Payload: payload,
 	}
 	return hash(hashable)
 }
@@ -83,14 +154,24 @@ func hash(data interface{}) ([]byte, error) {
 }
 
 func NewTransaction(inputs Inputs, outputs Outputs) (*Transaction, error) {
-	id, err := newID(inputs, outputs)
+	return NewTypedTransaction(RegularTransaction, inputs, outputs)
+}
+
+func NewTypedTransaction(txType Type, inputs Inputs, outputs Outputs) (*Transaction, error) {
+	return NewPayloadTransaction(txType, inputs, outputs, nil)
+}
+
+func NewPayloadTransaction(txType Type, inputs Inputs, outputs Outputs, payload []byte) (*Transaction, error) {
+	id, err := newID(txType, inputs, outputs, payload)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create id")
 	}
 	return &Transaction{
 		ID:        id,
+		Type:      txType,
 		Inputs:    inputs,
 		Outputs:   outputs,
+		Payload:   payload,
 		Timestamp: time.Now().Unix(),
 	}, nil
 }
@@ -101,10 +182,10 @@ func NewStakeTransaction(getUTXOs GetUTXOsByPublicKeyFn, signer wallet.Signer, s
 		if err != nil {
 			return nil, errors.Wrapf(err, "Failed to retrieve utxos for stake tx for %x", stakeCreator.PublicKeyHash())
 		}
-		target := utxos.Sum() / 2
-		if target < VoteValue/2 {
+		if !utxos.CanStake() {
 			return nil, ErrCantForge
 		}
+		target := utxos.Sum() / 2
 		sum := 0
 		var inputs Inputs
 		for _, utxo := range utxos {
@@ -207,7 +288,7 @@ func NewBaseTransaction(creator wallet.Wallet, recipientAddress string, value in
 			Verifier:      creator.PublicKey,
 		},
 	}
-	id, err := newID(inputs, outputs)
+	id, err := newID(RegularTransaction, inputs, outputs, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create transaction id")
 	}
@@ -230,6 +311,16 @@ func (t Transaction) UTXOs() (utxos []UTXO) {
 	return
 }
 
+func (t Transaction) IsBase() bool {
+	if len(t.Inputs) == 0 {
+		return false
+	}
+	_, found := t.Inputs.Find(func(input Input) bool {
+		return input.Vout != -1
+	})
+	return !found
+}
+
 func (t Transaction) AreInputsFrom(pkeyHash []byte) bool {
 	_, found := t.Inputs.Find(func(input Input) bool {
 		return bytes.Compare(input.PublicKeyHash, pkeyHash) != 0
@@ -237,9 +328,26 @@ func (t Transaction) AreInputsFrom(pkeyHash []byte) bool {
 	return !found
 }
 
-func VerifyTransactions(getTransactionUTXO GetTransactionUTXO, verifier wallet.VerifierFn) VerifyTransctionFn {
+func (v VerifyTransctionFn) And(other VerifyTransctionFn) VerifyTransctionFn {
+	return func(transaction Transaction) bool {
+		return v(transaction) && other(transaction)
+	}
+}
+
+func VerifyTransactions(getTransactionUTXO GetTransactionUTXO, isRevoked IsRevokedFn, verifier wallet.VerifierFn) VerifyTransctionFn {
 	return func(transaction Transaction) bool {
 		for _, input := range transaction.Inputs {
+			if input.Vout == -1 {
+				if !transaction.IsAuthority() {
+					return false
+				}
+				continue
+			}
+			if transaction.Type != RevocationTransaction {
+				if revoked, err := isRevoked(input.PublicKeyHash); err != nil || revoked {
+					return false
+				}
+			}
 			receiver, found := transaction.Outputs.Find(func(o Output) bool {
 				return bytes.Compare(o.PublicKeyHash, input.PublicKeyHash) != 0
 			})
@@ -247,13 +355,23 @@ func VerifyTransactions(getTransactionUTXO GetTransactionUTXO, verifier wallet.V
 				return false
 			}
 			utxo, err := getTransactionUTXO(input.TransactionID, input.Vout)
-			if err != nil || utxo == nil {
+			if err != nil || utxo == nil || bytes.Compare(utxo.PublicKeyHash, input.PublicKeyHash) != 0 {
+				return false
+			}
+			if input.Ring != nil {
+				if transaction.Type != RingVoteTransaction {
+					return false
+				}
+				continue
+			}
+			if !transaction.isSpendableBy(input) {
 				return false
 			}
 			signable := signable{
 				Recipient: receiver.PublicKeyHash,
 				Sender:    input.PublicKeyHash,
 				Value:     utxo.Value,
+				Payload:   PayloadHash(transaction.Payload),
 			}
 			signature := base64.StdEncoding.EncodeToString(input.Signature)
 			pKey := base64.StdEncoding.EncodeToString(input.Verifier)
@@ -265,26 +383,34 @@ func VerifyTransactions(getTransactionUTXO GetTransactionUTXO, verifier wallet.V
 	}
 }
 
+func (t Transaction) isSpendableBy(input Input) bool {
+	switch {
+	case t.Type == RevocationTransaction, t.Type == RecastReleaseTransaction:
+		return true
+	case t.Type == AnonymousVoteTransaction && bytes.Compare(input.PublicKeyHash, BallotPoolHash()) == 0:
+		return true
+	}
+	owner, err := wallet.HashedPublicKey(input.Verifier)
+	return err == nil && bytes.Compare(owner, input.PublicKeyHash) == 0
+}
+
 func IsStakeTransaction(alfaKeyHash []byte) IsStakeTransactionFn {
 	return func(transaction Transaction) bool {
 		if len(transaction.Outputs) > 2 {
-			log.Printf("Len of outputs ids %d", len(transaction.Outputs))
 			return false
 		}
 		_, found := transaction.Outputs.Find(func(o Output) bool {
 			return bytes.Compare(o.PublicKeyHash, alfaKeyHash) == 0
 		})
-		if !found {
-			log.Println("No public key output found")
-			return false
-		}
-		log.Println("GOOD STAKE TX")
-		return true
+		return found
 	}
 }
 
 func IsReturnStakeTransaction(alfaKeyHash []byte) IsReturnStakeTransactionFn {
 	return func(transaction Transaction) bool {
-		return len(transaction.Inputs) == 1 && bytes.Compare(transaction.Inputs[0].PublicKeyHash, alfaKeyHash) == 0
+		return transaction.Type == RegularTransaction &&
+			len(transaction.Inputs) == 1 &&
+			transaction.Inputs[0].Vout != -1 &&
+			bytes.Compare(transaction.Inputs[0].PublicKeyHash, alfaKeyHash) == 0
 	}
 }
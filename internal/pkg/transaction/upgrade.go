@@ -0,0 +1,50 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/nebser/crypto-vote/internal/pkg/wallet"
+	"github.com/pkg/errors"
+)
+
+type Upgrade struct {
+	Height       int `json:"height"`
+	Version      int `json:"version"`
+	MaxBlockSize int `json:"maxBlockSize,omitempty"`
+}
+
+type NewUpgradeTransactionFn func(Upgrade) (*Transaction, error)
+
+type ScheduleUpgradeFn func(Upgrade) (Transaction, error)
+
+type GetUpgradesFn func() ([]Upgrade, error)
+
+var ErrUpgradePending = errors.New("Previous upgrade is not included in a block yet")
+
+func NewUpgradeTransaction(authority wallet.Wallet) NewUpgradeTransactionFn {
+	return func(u Upgrade) (*Transaction, error) {
+		payload, err := json.Marshal(u)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to serialize upgrade %#v", u)
+		}
+		inputs, err := newAuthorityInputs(authority, signable{
+			Sender:  authority.PublicKeyHash(),
+			Payload: PayloadHash(payload),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return NewPayloadTransaction(UpgradeTransaction, inputs, Outputs{}, payload)
+	}
+}
+
+func (t Transaction) Upgrade() (*Upgrade, bool) {
+	if t.Type != UpgradeTransaction || len(t.Outputs) != 0 {
+		return nil, false
+	}
+	var u Upgrade
+	if err := json.Unmarshal(t.Payload, &u); err != nil || u.Height <= 1 || u.Version <= 0 || u.MaxBlockSize < 0 {
+		return nil, false
+	}
+	return &u, true
+}
@@ -36,8 +36,14 @@ func (utxos UTXOs) Sum() (sum int) {
 	return
 }
 
+func (utxos UTXOs) CanStake() bool {
+	return utxos.Sum()/2 >= VoteValue/2
+}
+
 type SaveUTXO func(UTXO) error
 
 type GetUTXOsByPublicKeyFn func(publicKeyHash []byte) (UTXOs, error)
 
 type GetTransactionUTXO func(id []byte, vout int) (*UTXO, error)
+
+type CountUTXOsFn func() (int, error)
@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+const scalarLength = 32
+
+type BlindingFactors struct {
+	Alpha      []byte `json:"alpha"`
+	Commitment []byte `json:"commitment"`
+	Message    []byte `json:"message"`
+}
+
+var ErrInvalidPoint = errors.New("Point is not on the curve")
+
+func encodePoint(x, y *big.Int) []byte {
+	return append(encodeScalar(x), encodeScalar(y)...)
+}
+
+func decodePoint(raw []byte) (*big.Int, *big.Int, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, nil, ErrInvalidPoint
+	}
+	x := new(big.Int).SetBytes(raw[:len(raw)/2])
+	y := new(big.Int).SetBytes(raw[len(raw)/2:])
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, nil, ErrInvalidPoint
+	}
+	return x, y, nil
+}
+
+func encodeScalar(s *big.Int) []byte {
+	raw := s.Bytes()
+	result := make([]byte, scalarLength-len(raw), scalarLength)
+	return append(result, raw...)
+}
+
+func randomScalar() (*big.Int, error) {
+	n := elliptic.P256().Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to generate random scalar")
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+func challenge(commitment, publicKey, message []byte) *big.Int {
+	hashed := sha256.Sum256(append(append(append([]byte{}, commitment...), publicKey...), message...))
+	e := new(big.Int).SetBytes(hashed[:])
+	return e.Mod(e, elliptic.P256().Params().N)
+}
+
+func NewBlindingNonce() (nonce, commitment []byte, err error) {
+	k, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := elliptic.P256().ScalarBaseMult(encodeScalar(k))
+	return encodeScalar(k), encodePoint(x, y), nil
+}
+
+func Blind(commitment, signerPublicKey, message []byte) (*BlindingFactors, []byte, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	rx, ry, err := decodePoint(commitment)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid commitment")
+	}
+	px, py, err := decodePoint(signerPublicKey)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Invalid signer public key")
+	}
+	alpha, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	beta, err := randomScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	ax, ay := curve.ScalarBaseMult(encodeScalar(alpha))
+	bx, by := curve.ScalarMult(px, py, encodeScalar(beta))
+	cx, cy := curve.Add(rx, ry, ax, ay)
+	cx, cy = curve.Add(cx, cy, bx, by)
+	blindedCommitment := encodePoint(cx, cy)
+	e := challenge(blindedCommitment, encodePoint(px, py), message)
+	blindedChallenge := new(big.Int).Add(e, beta)
+	blindedChallenge.Mod(blindedChallenge, n)
+	return &BlindingFactors{
+		Alpha:      encodeScalar(alpha),
+		Commitment: blindedCommitment,
+		Message:    message,
+	}, encodeScalar(blindedChallenge), nil
+}
+
+func SignBlinded(privateKey ecdsa.PrivateKey, nonce, blindedChallenge []byte) ([]byte, error) {
+	n := elliptic.P256().Params().N
+	k := new(big.Int).SetBytes(nonce)
+	e := new(big.Int).SetBytes(blindedChallenge)
+	if k.Sign() == 0 || k.Cmp(n) >= 0 || e.Cmp(n) >= 0 {
+		return nil, errors.New("Invalid nonce or challenge")
+	}
+	s := new(big.Int).Mul(e, privateKey.D)
+	s.Add(s, k)
+	s.Mod(s, n)
+	return encodeScalar(s), nil
+}
+
+func Unblind(factors BlindingFactors, blindSignature []byte) []byte {
+	n := elliptic.P256().Params().N
+	s := new(big.Int).SetBytes(blindSignature)
+	s.Add(s, new(big.Int).SetBytes(factors.Alpha))
+	s.Mod(s, n)
+	return append(append([]byte{}, factors.Commitment...), encodeScalar(s)...)
+}
+
+func VerifyBlindSignature(message, signature, signerPublicKey []byte) bool {
+	curve := elliptic.P256()
+	if len(signature) != 3*scalarLength {
+		return false
+	}
+	commitment := signature[:2*scalarLength]
+	s := signature[2*scalarLength:]
+	rx, ry, err := decodePoint(commitment)
+	if err != nil {
+		return false
+	}
+	px, py, err := decodePoint(signerPublicKey)
+	if err != nil {
+		return false
+	}
+	e := challenge(commitment, encodePoint(px, py), message)
+	lx, ly := curve.ScalarBaseMult(s)
+	ex, ey := curve.ScalarMult(px, py, encodeScalar(e))
+	rightX, rightY := curve.Add(rx, ry, ex, ey)
+	return lx.Cmp(rightX) == 0 && ly.Cmp(rightY) == 0
+}
@@ -0,0 +1,79 @@
+package wallet
+
+import (
+	"testing"
+)
+
+func blindSign(t *testing.T, signer Wallet, message []byte) []byte {
+	nonce, commitment, err := NewBlindingNonce()
+	if err != nil {
+		t.Fatalf("Failed to create blinding nonce: %s", err)
+	}
+	factors, blindedChallenge, err := Blind(commitment, signer.PublicKey, message)
+	if err != nil {
+		t.Fatalf("Failed to blind message: %s", err)
+	}
+	blindSignature, err := SignBlinded(signer.PrivateKey, nonce, blindedChallenge)
+	if err != nil {
+		t.Fatalf("Failed to sign blinded challenge: %s", err)
+	}
+	return Unblind(*factors, blindSignature)
+}
+
+func newTestWallet(t *testing.T) Wallet {
+	w, err := New()
+	if err != nil {
+		t.Fatalf("Failed to create wallet: %s", err)
+	}
+	return *w
+}
+
+func TestBlindSignatureRoundTrip(t *testing.T) {
+	signer := newTestWallet(t)
+	message := []byte("one-time key")
+	signature := blindSign(t, signer, message)
+	if !VerifyBlindSignature(message, signature, signer.PublicKey) {
+		t.Fatal("Unblinded signature is not valid")
+	}
+}
+
+func TestBlindSignatureRejectsTampering(t *testing.T) {
+	signer := newTestWallet(t)
+	message := []byte("one-time key")
+	signature := blindSign(t, signer, message)
+	tamperedScalar := append([]byte{}, signature...)
+	tamperedScalar[len(tamperedScalar)-1] ^= 1
+	tamperedCommitment := append([]byte{}, signature...)
+	tamperedCommitment[0] ^= 1
+	cases := map[string]struct {
+		message   []byte
+		signature []byte
+		signer    []byte
+	}{
+		"other message":       {[]byte("other key"), signature, signer.PublicKey},
+		"other signer":        {message, signature, newTestWallet(t).PublicKey},
+		"tampered scalar":     {message, tamperedScalar, signer.PublicKey},
+		"tampered commitment": {message, tamperedCommitment, signer.PublicKey},
+		"truncated":           {message, signature[:len(signature)-1], signer.PublicKey},
+	}
+	for name, c := range cases {
+		if VerifyBlindSignature(c.message, c.signature, c.signer) {
+			t.Errorf("Signature with %s is accepted", name)
+		}
+	}
+}
+
+func TestSignBlindedRejectsInvalidNonce(t *testing.T) {
+	signer := newTestWallet(t)
+	_, commitment, err := NewBlindingNonce()
+	if err != nil {
+		t.Fatalf("Failed to create blinding nonce: %s", err)
+	}
+	_, blindedChallenge, err := Blind(commitment, signer.PublicKey, []byte("one-time key"))
+	if err != nil {
+		t.Fatalf("Failed to blind message: %s", err)
+	}
+	if _, err := SignBlinded(signer.PrivateKey, make([]byte, scalarLength), blindedChallenge); err == nil {
+		t.Fatal("Zero nonce is accepted")
+	}
+}
@@ -0,0 +1,178 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+type RingSignature struct {
+	KeyImage  []byte   `json:"keyImage"`
+	Challenge []byte   `json:"challenge"`
+	Responses [][]byte `json:"responses"`
+}
+
+type ringMember struct {
+	x, y   *big.Int
+	hx, hy *big.Int
+}
+
+var ErrNotInRing = errors.New("Public key is not a member of the ring")
+
+func hashToPoint(x, y *big.Int) (*big.Int, *big.Int) {
+	params := elliptic.P256().Params()
+	exponent := new(big.Int).Add(params.P, big.NewInt(1))
+	exponent.Rsh(exponent, 2)
+	counter := make([]byte, 4)
+	for i := uint32(0); ; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		hashed := sha256.Sum256(append(append([]byte("crypto-vote key image"), encodePoint(x, y)...), counter...))
+		px := new(big.Int).SetBytes(hashed[:])
+		px.Mod(px, params.P)
+		rhs := new(big.Int).Exp(px, big.NewInt(3), params.P)
+		threeX := new(big.Int).Mul(px, big.NewInt(3))
+		rhs.Sub(rhs, threeX)
+		rhs.Add(rhs, params.B)
+		rhs.Mod(rhs, params.P)
+		py := new(big.Int).Exp(rhs, exponent, params.P)
+		if new(big.Int).Exp(py, big.NewInt(2), params.P).Cmp(rhs) == 0 {
+			return px, py
+		}
+	}
+}
+
+func ringHash(ring [][]byte) []byte {
+	hasher := sha256.New()
+	for _, member := range ring {
+		hasher.Write(member)
+	}
+	return hasher.Sum(nil)
+}
+
+func ringChallenge(message, ringDigest, keyImage []byte, lx, ly, rx, ry *big.Int) *big.Int {
+	hasher := sha256.New()
+	hasher.Write(message)
+	hasher.Write(ringDigest)
+	hasher.Write(keyImage)
+	hasher.Write(encodePoint(lx, ly))
+	hasher.Write(encodePoint(rx, ry))
+	e := new(big.Int).SetBytes(hasher.Sum(nil))
+	return e.Mod(e, elliptic.P256().Params().N)
+}
+
+func decodeRing(ring [][]byte) ([]ringMember, error) {
+	members := make([]ringMember, 0, len(ring))
+	for _, publicKey := range ring {
+		x, y, err := decodePoint(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		hx, hy := hashToPoint(x, y)
+		members = append(members, ringMember{x: x, y: y, hx: hx, hy: hy})
+	}
+	return members, nil
+}
+
+func ringStep(member ringMember, kx, ky, s, c *big.Int) (lx, ly, rx, ry *big.Int) {
+	curve := elliptic.P256()
+	sgx, sgy := curve.ScalarBaseMult(encodeScalar(s))
+	cpx, cpy := curve.ScalarMult(member.x, member.y, encodeScalar(c))
+	lx, ly = curve.Add(sgx, sgy, cpx, cpy)
+	shx, shy := curve.ScalarMult(member.hx, member.hy, encodeScalar(s))
+	cix, ciy := curve.ScalarMult(kx, ky, encodeScalar(c))
+	rx, ry = curve.Add(shx, shy, cix, ciy)
+	return
+}
+
+func RingMember(publicKey ecdsa.PublicKey) []byte {
+	return encodePoint(publicKey.X, publicKey.Y)
+}
+
+func KeyImage(privateKey ecdsa.PrivateKey) []byte {
+	hx, hy := hashToPoint(privateKey.PublicKey.X, privateKey.PublicKey.Y)
+	return encodePoint(elliptic.P256().ScalarMult(hx, hy, encodeScalar(privateKey.D)))
+}
+
+func SignRing(message []byte, ring [][]byte, privateKey ecdsa.PrivateKey) (*RingSignature, error) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	members, err := decodeRing(ring)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid ring")
+	}
+	signer := -1
+	for i, m := range members {
+		if m.x.Cmp(privateKey.PublicKey.X) == 0 && m.y.Cmp(privateKey.PublicKey.Y) == 0 {
+			signer = i
+			break
+		}
+	}
+	if signer == -1 {
+		return nil, ErrNotInRing
+	}
+	keyImage := KeyImage(privateKey)
+	kx, ky, _ := decodePoint(keyImage)
+	digest := ringHash(ring)
+	u, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+	challenges := make([]*big.Int, len(members))
+	responses := make([][]byte, len(members))
+	ugx, ugy := curve.ScalarBaseMult(encodeScalar(u))
+	uhx, uhy := curve.ScalarMult(members[signer].hx, members[signer].hy, encodeScalar(u))
+	next := (signer + 1) % len(members)
+	challenges[next] = ringChallenge(message, digest, keyImage, ugx, ugy, uhx, uhy)
+	for i := next; i != signer; i = (i + 1) % len(members) {
+		s, err := randomScalar()
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = encodeScalar(s)
+		lx, ly, rx, ry := ringStep(members[i], kx, ky, s, challenges[i])
+		challenges[(i+1)%len(members)] = ringChallenge(message, digest, keyImage, lx, ly, rx, ry)
+	}
+	s := new(big.Int).Mul(challenges[signer], privateKey.D)
+	s.Sub(u, s)
+	s.Mod(s, n)
+	responses[signer] = encodeScalar(s)
+	return &RingSignature{
+		KeyImage:  keyImage,
+		Challenge: encodeScalar(challenges[0]),
+		Responses: responses,
+	}, nil
+}
+
+func VerifyRing(message []byte, ring [][]byte, signature RingSignature) bool {
+	n := elliptic.P256().Params().N
+	if len(ring) == 0 || len(signature.Responses) != len(ring) {
+		return false
+	}
+	members, err := decodeRing(ring)
+	if err != nil {
+		return false
+	}
+	kx, ky, err := decodePoint(signature.KeyImage)
+	if err != nil {
+		return false
+	}
+	digest := ringHash(ring)
+	start := new(big.Int).SetBytes(signature.Challenge)
+	if start.Cmp(n) >= 0 {
+		return false
+	}
+	c := start
+	for i, member := range members {
+		s := new(big.Int).SetBytes(signature.Responses[i])
+		if s.Cmp(n) >= 0 {
+			return false
+		}
+		lx, ly, rx, ry := ringStep(member, kx, ky, s, c)
+		c = ringChallenge(message, digest, signature.KeyImage, lx, ly, rx, ry)
+	}
+	return c.Cmp(start) == 0
+}
@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestRing(t *testing.T, size int) (Wallets, [][]byte) {
+	members := Wallets{}
+	ring := [][]byte{}
+	for i := 0; i < size; i++ {
+		w := newTestWallet(t)
+		members = append(members, w)
+		ring = append(ring, RingMember(w.PrivateKey.PublicKey))
+	}
+	return members, ring
+}
+
+func signRing(t *testing.T, message []byte, ring [][]byte, signer Wallet) RingSignature {
+	signature, err := SignRing(message, ring, signer.PrivateKey)
+	if err != nil {
+		t.Fatalf("Failed to sign with ring: %s", err)
+	}
+	return *signature
+}
+
+func TestRingSignatureRoundTrip(t *testing.T) {
+	members, ring := newTestRing(t, 4)
+	message := []byte("vote")
+	for i, member := range members {
+		if !VerifyRing(message, ring, signRing(t, message, ring, member)) {
+			t.Fatalf("Signature of ring member %d is not valid", i)
+		}
+	}
+}
+
+func TestRingSignatureRejectsTamperedMessage(t *testing.T) {
+	members, ring := newTestRing(t, 4)
+	signature := signRing(t, []byte("vote"), ring, members[1])
+	if VerifyRing([]byte("another vote"), ring, signature) {
+		t.Fatal("Signature is accepted for another message")
+	}
+}
+
+func TestRingSignatureRejectsTamperedRing(t *testing.T) {
+	members, ring := newTestRing(t, 4)
+	message := []byte("vote")
+	signature := signRing(t, message, ring, members[1])
+	_, other := newTestRing(t, 1)
+	replaced := append([][]byte{}, ring...)
+	replaced[2] = other[0]
+	if VerifyRing(message, replaced, signature) {
+		t.Fatal("Signature is accepted for a ring with a replaced member")
+	}
+	reordered := append([][]byte{}, ring...)
+	reordered[0], reordered[3] = reordered[3], reordered[0]
+	if VerifyRing(message, reordered, signature) {
+		t.Fatal("Signature is accepted for a reordered ring")
+	}
+	shortened := append([][]byte{}, ring[:3]...)
+	signature.Responses = signature.Responses[:3]
+	if VerifyRing(message, shortened, signature) {
+		t.Fatal("Signature is accepted for a shortened ring")
+	}
+}
+
+func TestRingSignatureRejectsForeignKeyImage(t *testing.T) {
+	members, ring := newTestRing(t, 4)
+	message := []byte("vote")
+	signature := signRing(t, message, ring, members[1])
+	signature.KeyImage = KeyImage(members[2].PrivateKey)
+	if VerifyRing(message, ring, signature) {
+		t.Fatal("Signature is accepted with the key image of another member")
+	}
+}
+
+func TestRingSignatureLinksSigner(t *testing.T) {
+	members, ring := newTestRing(t, 4)
+	first := signRing(t, []byte("vote"), ring, members[1])
+	second := signRing(t, []byte("another vote"), ring, members[1])
+	if !bytes.Equal(first.KeyImage, second.KeyImage) {
+		t.Fatal("Signatures of the same member have different key images")
+	}
+	other := signRing(t, []byte("vote"), ring, members[2])
+	if bytes.Equal(first.KeyImage, other.KeyImage) {
+		t.Fatal("Signatures of different members have the same key image")
+	}
+}
+
+func TestRingSignatureRequiresMembership(t *testing.T) {
+	_, ring := newTestRing(t, 3)
+	outsider := newTestWallet(t)
+	if _, err := SignRing([]byte("vote"), ring, outsider.PrivateKey); err != ErrNotInRing {
+		t.Fatalf("Expected %s, got %v", ErrNotInRing, err)
+	}
+}
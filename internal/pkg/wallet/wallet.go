@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -120,19 +121,34 @@ func Import(keyfiles keyfiles.KeyFiles) (*Wallet, error) {
 }
 
 func ExtractAddress(publicKey []byte) (string, error) {
-	publicSHA256 := sha256.Sum256(publicKey)
-	RIPEMD160Hasher := ripemd160.New()
-	if _, err := RIPEMD160Hasher.Write(publicSHA256[:]); err != nil {
-		return "", errors.Wrap(err, "Failed to write to RIPEMD")
+	publicRIPEMD160, err := HashedPublicKey(publicKey)
+	if err != nil {
+		return "", err
 	}
+	return AddressFromPublicKeyHash(publicRIPEMD160), nil
+}
 
-	publicRIPEMD160 := RIPEMD160Hasher.Sum(nil)
-	versionedPublicKey := append([]byte{version}, publicRIPEMD160...)
+func AddressFromPublicKeyHash(publicKeyHash []byte) string {
+	versionedPublicKey := append([]byte{version}, publicKeyHash...)
 	checksum := getChecksum(versionedPublicKey)
 
 	payload := append(versionedPublicKey, checksum...)
-	encoded := base58.Encode(payload)
-	return encoded, nil
+	return base58.Encode(payload)
+}
+
+func ParseAddress(address string) ([]byte, error) {
+	decoded := base58.Decode(address)
+	if len(decoded) <= 1+addressLength {
+		return nil, errors.Errorf("Address %s is too short", address)
+	}
+	if decoded[0] != version {
+		return nil, errors.Errorf("Address %s has unsupported version %d", address, decoded[0])
+	}
+	payload := decoded[:len(decoded)-addressLength]
+	if !bytes.Equal(getChecksum(payload), decoded[len(decoded)-addressLength:]) {
+		return nil, errors.Errorf("Address %s has invalid checksum", address)
+	}
+	return decoded[1 : len(decoded)-addressLength], nil
 }
 
 func HashedPublicKey(publicKey []byte) ([]byte, error) {
@@ -27,3 +27,12 @@ func PublicKeyAuthorizer(pkey string, verify wallet.VerifierFn) Authorizer {
 		}
 	}
 }
+
+func SignatureAuthorizer() Authorizer {
+	return func(ping Ping) error {
+		if !ping.Verified() {
+			return ErrUnauthorized(ping.Sender)
+		}
+		return nil
+	}
+}
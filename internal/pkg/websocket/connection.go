@@ -2,48 +2,51 @@ package websocket
 
 import (
 	"io"
-	"log"
 	"net/http"
 	"sync"
 
 	"github.com/gorilla/websocket"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
+	"github.com/nebser/crypto-vote/internal/pkg/metrics"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
 )
 
 type Connection func(resp http.ResponseWriter, request *http.Request) error
 
-func (c Connection) ServeHTTP(resp http.ResponseWriter, request *http.Request) {
-	if err := c(resp, request); err != nil {
-		log.Printf("Error occurred %s\n", err)
-	}
+func (c Connection) Logged(log logger.Logger) http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, request *http.Request) {
+		if err := c(resp, request); err != nil {
+			log.Warn("Connection closed with error", logger.F("remote", request.RemoteAddr), logger.Err(err))
+		}
+	})
 }
 
-func reader(conn *websocket.Conn, id string, hub *Hub, router Router, responseChan chan Pong, wg *sync.WaitGroup) {
+func reader(conn *websocket.Conn, id string, hub *Hub, router Router, responseChan chan Pong, wg *sync.WaitGroup, log logger.Logger) {
 	defer wg.Done()
-	defer close(responseChan)
 	defer hub.Unregister(id)
 	for {
 		var ping Ping
 		if err := conn.ReadJSON(&ping); err != nil {
 			if err != io.ErrUnexpectedEOF {
-				log.Println("Closing reader")
+				log.Debug("Closing reader", logger.Err(err))
 				return
 			}
-			log.Printf("Failed to parse message %+v, %t\n", err, errors.Is(err, io.ErrUnexpectedEOF))
-			responseChan <- Pong{
-				Message: ErrorMessage,
-			}
+			log.Warn("Failed to parse message", logger.Err(err))
+			responseChan <- *NewErrorPong(failure.Newf(failure.InvalidData, "Failed to parse message"))
 			continue
 		}
+		metrics.Messages.Inc(metrics.In, ping.Message.label())
+		log.Debug("Message received", logger.MessageType(ping.Message), logger.CorrelationID(ping.CorrelationID))
 		if ping.Message == CloseConnectionMessage {
 			return
 		}
 		if ping.Message == ErrorMessage {
-			log.Printf("Received error message %s\n", ping.Body)
+			log.Warn("Received error message", logger.F("body", string(ping.Body)))
 			continue
 		}
-		pong := router.Route(ping, id)
+		pong := router.Route(ping, id, log)
 		switch {
 		case pong == nil || pong.Message == NoActionMessage:
 			continue
@@ -55,14 +58,21 @@ func reader(conn *websocket.Conn, id string, hub *Hub, router Router, responseCh
 	}
 }
 
-func writer(conn *websocket.Conn, responseChan chan Pong, signer wallet.Signer, wg *sync.WaitGroup) {
+func writer(conn *websocket.Conn, responseChan chan Pong, done <-chan struct{}, signer wallet.Signer, wg *sync.WaitGroup, log logger.Logger) {
 	defer wg.Done()
-	for pong := range responseChan {
+	for {
+		var pong Pong
+		select {
+		case pong = <-responseChan:
+		case <-done:
+			return
+		}
 		signed, err := pong.Signed(signer)
 		if err != nil {
-			log.Printf("Failed to sign message %#v", pong)
+			log.Error("Failed to sign message", logger.MessageType(pong.Message), logger.Err(err))
 			continue
 		}
+		metrics.Messages.Inc(metrics.Out, pong.Message.label())
 		conn.WriteJSON(signed)
 	}
 }
@@ -77,11 +87,13 @@ func PingPongConnection(router Router, hub *Hub, signer wallet.Signer) Connectio
 		defer conn.Close()
 
 		responseChan := make(chan Pong, 5)
-		id := hub.Add(responseChan)
+		id, done := hub.Add(responseChan)
+		log := hub.log.With(logger.F("connection", id), logger.F("remote", request.RemoteAddr))
+		log.Debug("Connection opened")
 		wg := sync.WaitGroup{}
 		wg.Add(2)
-		go reader(conn, id, hub, router, responseChan, &wg)
-		go writer(conn, responseChan, signer, &wg)
+		go reader(conn, id, hub, router, responseChan, &wg, log)
+		go writer(conn, responseChan, done, signer, &wg, log)
 
 		wg.Wait()
 
@@ -93,12 +105,13 @@ func MaintainConnection(conn *websocket.Conn, router Router, hub *Hub, nodeID st
 	defer conn.Close()
 
 	responseChan := make(chan Pong, 5)
-	id := hub.Add(responseChan)
+	id, done := hub.Add(responseChan)
 	hub.Register(id, nodeID)
+	log := hub.log.With(logger.F("connection", id), logger.PeerID(nodeID))
 	wg := sync.WaitGroup{}
 	wg.Add(2)
-	go reader(conn, id, hub, router, responseChan, &wg)
-	go writer(conn, responseChan, signer, &wg)
+	go reader(conn, id, hub, router, responseChan, &wg, log)
+	go writer(conn, responseChan, done, signer, &wg, log)
 
 	wg.Wait()
 }
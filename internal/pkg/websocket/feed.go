@@ -0,0 +1,90 @@
+package websocket
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+const (
+	feedBufferSize       = 64
+	subscriberBufferSize = 16
+)
+
+type Event struct {
+	Name string      `json:"event"`
+	Data interface{} `json:"data"`
+}
+
+type ToEventsFn func(Pong) []Event
+
+type Feed struct {
+	incoming    chan Pong
+	subscribers map[string]chan Event
+	lock        *sync.Mutex
+}
+
+func NewFeed() *Feed {
+	return &Feed{
+		incoming:    make(chan Pong, feedBufferSize),
+		subscribers: make(map[string]chan Event),
+		lock:        &sync.Mutex{},
+	}
+}
+
+func (f *Feed) QueueDepth() int {
+	return len(f.incoming)
+}
+
+func (f *Feed) Publish(message Pong) int {
+	select {
+	case f.incoming <- message:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (f *Feed) Tee(broadcast BroadcastFn) BroadcastFn {
+	return func(message Pong) int {
+		f.Publish(message)
+		return broadcast(message)
+	}
+}
+
+func (f *Feed) Run(toEvents ToEventsFn) {
+	for message := range f.incoming {
+		for _, event := range toEvents(message) {
+			f.send(event)
+		}
+	}
+}
+
+func (f *Feed) send(event Event) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, ch := range f.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func (f *Feed) Subscribe() (string, <-chan Event) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	id := uuid.New().String()
+	ch := make(chan Event, subscriberBufferSize)
+	f.subscribers[id] = ch
+	return id, ch
+}
+
+func (f *Feed) Unsubscribe(id string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if ch, ok := f.subscribers[id]; ok {
+		close(ch)
+		delete(f.subscribers, id)
+	}
+}
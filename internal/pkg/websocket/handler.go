@@ -2,7 +2,10 @@ package websocket
 
 import (
 	"errors"
-	"log"
+
+	"github.com/google/uuid"
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 )
 
 type Handler func(Ping, string) (*Pong, error)
@@ -12,10 +15,7 @@ func (h Handler) Authorized(a Authorizer) Handler {
 		unauthotizedErr := ErrUnauthorized("")
 		switch err := a(ping); {
 		case errors.As(err, &unauthotizedErr):
-			return &Pong{
-				Message: ErrorMessage,
-				Body:    NewUnauthorizedError(err),
-			}, nil
+			return nil, failure.Newf(failure.Unauthorized, "Unathorized. Error: %s", err)
 		case err != nil:
 			return nil, err
 		default:
@@ -26,21 +26,22 @@ func (h Handler) Authorized(a Authorizer) Handler {
 
 type Router map[Message]Handler
 
-func (r Router) Route(p Ping, id string) *Pong {
+func (r Router) Route(p Ping, id string, log logger.Logger) *Pong {
+	correlationID := p.CorrelationID
+	if correlationID == "" {
+		correlationID = uuid.New().String()
+	}
 	handler, ok := r[p.Message]
 	if !ok {
-		return &Pong{
-			Message: ErrorMessage,
-			Body:    NewUnknownMessageError(p.Message),
-		}
+		return NewErrorPong(failure.Newf(failure.UnknownMessage, "Unknown message %s", p.Message).WithCorrelationID(correlationID))
 	}
 	result, err := handler(p, id)
 	if err != nil {
-		log.Printf("Error occurred while forwarding message %s. Error: %s", p.Message, err)
-		return &Pong{
-			Message: ErrorMessage,
-			Body:    NewUnknownError(),
+		e, ok := failure.From(err)
+		if !ok {
+			log.Error("Failed to handle message", logger.MessageType(p.Message), logger.CorrelationID(correlationID), logger.Err(err))
 		}
+		return NewErrorPong(e.WithCorrelationID(correlationID))
 	}
 	return result
 }
@@ -2,22 +2,30 @@ package websocket
 
 import (
 	"math/rand"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/nebser/crypto-vote/internal/pkg/logger"
 	"github.com/pkg/errors"
 )
 
+const unicastTimeout = time.Second
+
 type node struct {
 	ch     chan Pong
+	done   chan struct{}
 	nodeID string
+	sender string
 }
 
 type Hub struct {
 	pending      map[string]node
 	receivers    map[string]node
 	registerLock *sync.Mutex
-	lastReceiver int
+	lastReceiver string
+	log          logger.Logger
 }
 
 type BroadcastFn func(Pong) int
@@ -26,48 +34,101 @@ type RegisteredNodesFn func() []string
 
 type RandomUnicastFn func(Pong) error
 
-func NewHub() *Hub {
+type UnicastFn func(nodeID string, message Pong) error
+
+type ExcludedFn func(sender string) bool
+
+type Forger struct {
+	NodeID string `json:"nodeId"`
+	Sender string `json:"sender"`
+}
+
+type ForgersFn func() []Forger
+
+type IsRegisteredFn func(nodeID string) bool
+
+type PeersFn func() int
+
+func NewHub(log logger.Logger) *Hub {
 	return &Hub{
 		receivers:    make(map[string]node),
 		pending:      make(map[string]node),
 		registerLock: &sync.Mutex{},
-		lastReceiver: -1,
+		log:          log,
 	}
 }
 
-func (h Hub) Add(ch chan Pong) string {
+func (h Hub) Add(ch chan Pong) (string, <-chan struct{}) {
+	h.registerLock.Lock()
+	defer h.registerLock.Unlock()
 	id := uuid.New().String()
-	h.pending[id] = node{ch: ch}
-	return id
+	done := make(chan struct{})
+	h.pending[id] = node{ch: ch, done: done}
+	return id, done
 }
 
 func (h Hub) Register(internalID, externalID string) {
+	h.register(internalID, externalID, "")
+}
+
+func (h Hub) register(internalID, externalID, sender string) {
 	temp := h.pending[internalID]
 	temp.nodeID = externalID
+	temp.sender = sender
 	h.receivers[internalID] = temp
 	delete(h.pending, internalID)
+	h.log.Info("Peer registered", logger.F("connection", internalID), logger.PeerID(externalID))
 }
 
-func (h Hub) RegisterAtomically(internalID, externalID string) []string {
+func (h Hub) RegisterAtomically(internalID, externalID, sender string) []string {
 	h.registerLock.Lock()
 	defer h.registerLock.Unlock()
 	nodes := h.RegisteredNodes()
-	h.Register(internalID, externalID)
+	h.register(internalID, externalID, sender)
 	return nodes
 }
 
 func (h Hub) Unregister(internalID string) {
 	h.registerLock.Lock()
 	defer h.registerLock.Unlock()
+	if receiver, ok := h.receivers[internalID]; ok {
+		h.log.Info("Peer unregistered", logger.F("connection", internalID), logger.PeerID(receiver.nodeID))
+		close(receiver.done)
+	} else if pending, ok := h.pending[internalID]; ok {
+		close(pending.done)
+	}
 	delete(h.receivers, internalID)
 	delete(h.pending, internalID)
 }
 
+func (h Hub) registered() []node {
+	h.registerLock.Lock()
+	defer h.registerLock.Unlock()
+	nodes := make([]node, 0, len(h.receivers))
+	for _, receiver := range h.receivers {
+		nodes = append(nodes, receiver)
+	}
+	return nodes
+}
+
+func (n node) deliver(message Pong) bool {
+	select {
+	case n.ch <- message:
+		return true
+	case <-n.done:
+		return false
+	}
+}
+
 func (h Hub) Broadcast(message Pong) int {
-	for _, node := range h.receivers {
-		node.ch <- message
+	sentCount := 0
+	for _, node := range h.registered() {
+		if node.deliver(message) {
+			sentCount++
+		}
 	}
-	return len(h.receivers)
+	h.log.Debug("Message broadcast", logger.MessageType(message.Message), logger.F("receivers", sentCount))
+	return sentCount
 }
 
 func arrayContains(array []string, target string) bool {
@@ -81,11 +142,13 @@ func arrayContains(array []string, target string) bool {
 
 func (h Hub) Multicast(message Pong, receiveCount int, blacklist []string) int {
 	sentCount := 0
-	for _, node := range h.receivers {
+	for _, node := range h.registered() {
 		if arrayContains(blacklist, node.nodeID) {
 			continue
 		}
-		node.ch <- message
+		if !node.deliver(message) {
+			continue
+		}
 		sentCount++
 		if sentCount == receiveCount {
 			return sentCount
@@ -95,22 +158,90 @@ func (h Hub) Multicast(message Pong, receiveCount int, blacklist []string) int {
 }
 
 func (h *Hub) RandomUnicast(message Pong) error {
-	h.registerLock.Lock()
-	defer h.registerLock.Unlock()
-	receiverNum := rand.Intn(len(h.receivers))
-	for receiverNum == h.lastReceiver {
-		receiverNum = rand.Intn(len(h.receivers))
+	return h.RandomUnicastExcept(nil)(message)
+}
+
+func (h *Hub) RandomUnicastExcept(excluded ExcludedFn) RandomUnicastFn {
+	return func(message Pong) error {
+		h.registerLock.Lock()
+		candidates := []node{}
+		for _, receiver := range h.receivers {
+			if excluded != nil && excluded(receiver.sender) {
+				h.log.Debug("Excluded receiver skipped", logger.PeerID(receiver.nodeID))
+				continue
+			}
+			candidates = append(candidates, receiver)
+		}
+		if len(candidates) > 1 {
+			for i, candidate := range candidates {
+				if candidate.nodeID == h.lastReceiver {
+					candidates = append(candidates[:i], candidates[i+1:]...)
+					break
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			defer h.registerLock.Unlock()
+			return errors.Errorf("None of the %d registered receivers can receive the message", len(h.receivers))
+		}
+		receiver := candidates[rand.Intn(len(candidates))]
+		h.lastReceiver = receiver.nodeID
+		h.registerLock.Unlock()
+		if !receiver.deliver(message) {
+			return errors.Errorf("Receiver %s is disconnected", receiver.nodeID)
+		}
+		h.log.Debug("Message unicast", logger.MessageType(message.Message), logger.PeerID(receiver.nodeID))
+		return nil
 	}
-	h.lastReceiver = receiverNum
-	num := 0
-	for _, receiver := range h.receivers {
-		if num == receiverNum {
-			receiver.ch <- message
-			return nil
+}
+
+func send(receiver node, message Pong, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case receiver.ch <- message:
+		return nil
+	case <-receiver.done:
+		return errors.New("Receiver is disconnected")
+	case <-timer.C:
+		return errors.Errorf("Receiver didn't accept the message within %s", timeout)
+	}
+}
+
+func (h *Hub) Unicast(nodeID string, message Pong) error {
+	var target *node
+	for _, receiver := range h.registered() {
+		if receiver.nodeID == nodeID {
+			target = &receiver
+			break
 		}
-		num++
 	}
-	return errors.Errorf("Receiver number (%d) is greater than the number of registered receivers (%d)", receiverNum, len(h.receivers))
+	if target == nil {
+		return errors.Errorf("Receiver %s is not registered", nodeID)
+	}
+	if err := send(*target, message, unicastTimeout); err != nil {
+		return errors.Wrapf(err, "Failed to unicast %s to %s", message.Message, nodeID)
+	}
+	h.log.Debug("Message unicast", logger.MessageType(message.Message), logger.PeerID(nodeID))
+	return nil
+}
+
+func (h *Hub) Forgers(excluded ExcludedFn) ForgersFn {
+	return func() []Forger {
+		h.registerLock.Lock()
+		defer h.registerLock.Unlock()
+		forgers := []Forger{}
+		for _, receiver := range h.receivers {
+			if receiver.sender == "" || (excluded != nil && excluded(receiver.sender)) {
+				continue
+			}
+			forgers = append(forgers, Forger{NodeID: receiver.nodeID, Sender: receiver.sender})
+		}
+		sort.Slice(forgers, func(i, j int) bool {
+			return forgers[i].Sender < forgers[j].Sender
+		})
+		return forgers
+	}
 }
 
 func (h Hub) RegisteredNodes() (nodes []string) {
@@ -119,3 +250,33 @@ func (h Hub) RegisteredNodes() (nodes []string) {
 	}
 	return
 }
+
+func (h Hub) IsRegistered(nodeID string) bool {
+	h.registerLock.Lock()
+	defer h.registerLock.Unlock()
+	for _, node := range h.receivers {
+		if node.nodeID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+func (h Hub) Peers() int {
+	h.registerLock.Lock()
+	defer h.registerLock.Unlock()
+	return len(h.receivers)
+}
+
+func (h Hub) QueueDepth() int {
+	h.registerLock.Lock()
+	defer h.registerLock.Unlock()
+	depth := 0
+	for _, node := range h.receivers {
+		depth += len(node.ch)
+	}
+	for _, node := range h.pending {
+		depth += len(node.ch)
+	}
+	return depth
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/nebser/crypto-vote/internal/pkg/failure"
 	"github.com/nebser/crypto-vote/internal/pkg/transaction"
 	"github.com/nebser/crypto-vote/internal/pkg/wallet"
 	"github.com/pkg/errors"
@@ -15,7 +16,7 @@ type Message int
 const (
 	GetBlockchainHeightMessage Message = iota + 1
 	CloseConnectionMessage
-	GetMissingBlocksMessage
+	GetHeadersMessage
 	GetBlockMessage
 	RegisterMessage
 	ErrorMessage
@@ -25,6 +26,10 @@ const (
 	ForgeBlockMessage
 	BlockForgedMessage
 	DisconnectMessage
+	EquivocationMessage
+	CheckpointMessage
+	GetCheckpointMessage
+	GetSnapshotMessage
 )
 
 func (m Message) String() string {
@@ -33,8 +38,8 @@ func (m Message) String() string {
 		return "get-blockchain-height"
 	case CloseConnectionMessage:
 		return "close-connection"
-	case GetMissingBlocksMessage:
-		return "get-missing-blocks"
+	case GetHeadersMessage:
+		return "get-headers"
 	case GetBlockMessage:
 		return "get-block"
 	case RegisterMessage:
@@ -53,13 +58,30 @@ func (m Message) String() string {
 		return "block-forged"
 	case DisconnectMessage:
 		return "disconnect"
+	case EquivocationMessage:
+		return "equivocation"
+	case CheckpointMessage:
+		return "checkpoint"
+	case GetCheckpointMessage:
+		return "get-checkpoint"
+	case GetSnapshotMessage:
+		return "get-snapshot"
 	default:
 		return fmt.Sprintf("Unknown message %d", m)
 	}
 }
 
+func (m Message) label() string {
+	if m < GetBlockchainHeightMessage || m > GetSnapshotMessage {
+		return "unknown"
+	}
+	return m.String()
+}
+
 type ForgeBlockBody struct {
-	Height int `json:"height"`
+	Height   int   `json:"height"`
+	Slot     int   `json:"slot"`
+	Deadline int64 `json:"deadline"`
 }
 
 type BlockForgedBody struct {
@@ -72,10 +94,11 @@ type SaveTransactionBody struct {
 }
 
 type Ping struct {
-	Message   Message         `json:"message"`
-	Body      json.RawMessage `json:"body"`
-	Signature string          `json:"signature,omitempty"`
-	Sender    string          `json:"sender,omitempty"`
+	Message       Message         `json:"message"`
+	Body          json.RawMessage `json:"body"`
+	Signature     string          `json:"signature,omitempty"`
+	Sender        string          `json:"sender,omitempty"`
+	CorrelationID string          `json:"correlationId,omitempty"`
 }
 
 type signablePing struct {
@@ -98,7 +121,7 @@ func (p Ping) Verified() bool {
 	if err != nil {
 		return false
 	}
-	signature, err := base64.RawStdEncoding.DecodeString(p.Signature)
+	signature, err := base64.StdEncoding.DecodeString(p.Signature)
 	if err != nil {
 		return false
 	}
@@ -141,10 +164,10 @@ func (p Pong) Signed(signer wallet.Signer) (Pong, error) {
 	}, nil
 }
 
-func NewErrorPong(e Error) *Pong {
+func NewErrorPong(e failure.Error) *Pong {
 	return &Pong{
 		Message: ErrorMessage,
-		Body:    e,
+		Body:    e.Envelope(),
 	}
 }
 
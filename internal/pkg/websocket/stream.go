@@ -0,0 +1,125 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const keepAliveInterval = 15 * time.Second
+
+type SnapshotFn func() ([]Event, error)
+
+func writeServerSentEvent(resp http.ResponseWriter, event Event) error {
+	raw, err := json.Marshal(event.Data)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to serialize event %s", event.Name)
+	}
+	if _, err := fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", event.Name, raw); err != nil {
+		return errors.Wrapf(err, "Failed to write event %s", event.Name)
+	}
+	return nil
+}
+
+func ServerSentEvents(feed *Feed, snapshot SnapshotFn) Connection {
+	return func(resp http.ResponseWriter, request *http.Request) error {
+		flusher, ok := resp.(http.Flusher)
+		if !ok {
+			http.Error(resp, "Streaming unsupported", http.StatusInternalServerError)
+			return errors.New("Response writer does not support flushing")
+		}
+		id, events := feed.Subscribe()
+		defer feed.Unsubscribe(id)
+		initial, err := snapshot()
+		if err != nil {
+			http.Error(resp, "Unexpected error occurred", http.StatusInternalServerError)
+			return errors.Wrap(err, "Failed to retrieve event snapshot")
+		}
+		resp.Header().Set("Content-Type", "text/event-stream")
+		resp.Header().Set("Cache-Control", "no-cache")
+		resp.Header().Set("Connection", "keep-alive")
+		resp.WriteHeader(http.StatusOK)
+		for _, event := range initial {
+			if err := writeServerSentEvent(resp, event); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-request.Context().Done():
+				return nil
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(resp, ": keep-alive\n\n"); err != nil {
+					return errors.Wrap(err, "Failed to write keep alive")
+				}
+			case event, ok := <-events:
+				if !ok {
+					return nil
+				}
+				if err := writeServerSentEvent(resp, event); err != nil {
+					return err
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func EventSocket(feed *Feed, snapshot SnapshotFn) Connection {
+	return func(resp http.ResponseWriter, request *http.Request) error {
+		upgrader := websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return true },
+		}
+		conn, err := upgrader.Upgrade(resp, request, nil)
+		if err != nil {
+			return errors.Wrap(err, "Failed to open websocket")
+		}
+		defer conn.Close()
+		id, events := feed.Subscribe()
+		defer feed.Unsubscribe(id)
+		initial, err := snapshot()
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve event snapshot")
+		}
+		for _, event := range initial {
+			if err := conn.WriteJSON(event); err != nil {
+				return errors.Wrapf(err, "Failed to write event %s", event.Name)
+			}
+		}
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-closed:
+				return nil
+			case <-keepAlive.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second)); err != nil {
+					return nil
+				}
+			case event, ok := <-events:
+				if !ok {
+					return nil
+				}
+				if err := conn.WriteJSON(event); err != nil {
+					return errors.Wrapf(err, "Failed to write event %s", event.Name)
+				}
+			}
+		}
+	}
+}